@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -34,11 +35,25 @@ func main() {
 		log.Printf("Failed to migrate table [%v]\n", err)
 	}
 
-	module := module.New(storageDB)
+	module, err := module.New(storageDB, conf.Inventory)
 	if err != nil {
 		log.Fatalf("Failed create module instance [%v]\n", err)
 	}
 
+	// cost layer is built from stock movement
+	// this script is only run when cost layer has not been built yet
+	err = module.SyncCostLayer(context.Background())
+	if err != nil {
+		log.Printf("Failed to sync cost layer [%v]\n", err)
+	}
+
+	// order which is stored before cost snapshot is introduced
+	// is snapshotted at the time the order is made
+	err = module.SyncOrderCost(context.Background())
+	if err != nil {
+		log.Printf("Failed to sync order cost [%v]\n", err)
+	}
+
 	tmpl, err := template.New()
 	if err != nil {
 		log.Fatalf("Failed create template instance [%v]\n", err)
@@ -63,6 +78,7 @@ func main() {
 		r.HandleFunc("/inventory/product", handlr.API.StoreProduct).Methods("POST")
 		r.HandleFunc("/inventory/product/{id:[0-9]+}", handlr.API.GetDetailProduct).Methods("GET")
 		r.HandleFunc("/inventory/product/{id:[0-9]+}", handlr.API.DeleteProduct).Methods("DELETE")
+		r.HandleFunc("/inventory/product/{id:[0-9]+}/movements", handlr.API.GetProductMovement).Methods("GET")
 	}
 
 	{
@@ -71,6 +87,16 @@ func main() {
 		r.HandleFunc("/inventory/purchase/{date_start}/{date_end}", handlr.API.GetPurchaseByDate).Methods("GET")
 		r.HandleFunc("/inventory/purchase/{id}", handlr.API.GetDetailPurchase).Methods("GET")
 		r.HandleFunc("/inventory/purchase", handlr.API.StorePurchase).Methods("POST")
+		r.HandleFunc("/inventory/purchase/{id:[0-9]+}/receipts", handlr.API.StorePurchaseReceipt).Methods("POST")
+		r.HandleFunc("/inventory/purchase/replenishment", handlr.API.StoreReplenishmentPurchase).Methods("POST")
+	}
+
+	{
+		// serve supplier request
+		r.HandleFunc("/inventory/supplier", handlr.API.GetSupplier).Methods("GET")
+		r.HandleFunc("/inventory/supplier", handlr.API.StoreSupplier).Methods("POST")
+		r.HandleFunc("/inventory/supplier/{id:[0-9]+}", handlr.API.GetDetailSupplier).Methods("GET")
+		r.HandleFunc("/inventory/supplier/{id:[0-9]+}", handlr.API.DeleteSupplier).Methods("DELETE")
 	}
 
 	{
@@ -81,19 +107,98 @@ func main() {
 		r.HandleFunc("/inventory/order", handlr.API.StoreOrder).Methods("POST")
 	}
 
+	{
+		// serve sales order request
+		r.HandleFunc("/inventory/sales_order", handlr.API.GetSalesOrder).Methods("GET")
+		r.HandleFunc("/inventory/sales_order/{id:[0-9]+}", handlr.API.GetDetailSalesOrder).Methods("GET")
+	}
+
+	{
+		// serve product parent request
+		r.HandleFunc("/inventory/product_parent", handlr.API.GetProductParent).Methods("GET")
+		r.HandleFunc("/inventory/product_parent", handlr.API.StoreProductParent).Methods("POST")
+		r.HandleFunc("/inventory/product_parent/{id:[0-9]+}", handlr.API.GetDetailProductParent).Methods("GET")
+		r.HandleFunc("/inventory/product_parent/{id:[0-9]+}", handlr.API.DeleteProductParent).Methods("DELETE")
+		r.HandleFunc("/inventory/product_parent/{id:[0-9]+}/variants", handlr.API.StoreProductVariant).Methods("POST")
+
+		// serve location request
+		r.HandleFunc("/inventory/location", handlr.API.GetLocation).Methods("GET")
+		r.HandleFunc("/inventory/location", handlr.API.StoreLocation).Methods("POST")
+		r.HandleFunc("/inventory/location/{id:[0-9]+}", handlr.API.GetDetailLocation).Methods("GET")
+		r.HandleFunc("/inventory/location/{id:[0-9]+}", handlr.API.DeleteLocation).Methods("DELETE")
+
+		// serve transfer request
+		r.HandleFunc("/inventory/transfer", handlr.API.GetTransfer).Methods("GET")
+		r.HandleFunc("/inventory/transfer", handlr.API.StoreTransfer).Methods("POST")
+		r.HandleFunc("/inventory/transfer/{id:[0-9]+}", handlr.API.GetDetailTransfer).Methods("GET")
+
+		// serve adjustment request
+		r.HandleFunc("/inventory/adjustment", handlr.API.GetAdjustment).Methods("GET")
+		r.HandleFunc("/inventory/adjustment", handlr.API.StoreAdjustment).Methods("POST")
+		r.HandleFunc("/inventory/adjustment/{id:[0-9]+}", handlr.API.GetDetailAdjustment).Methods("GET")
+
+		// serve lot request
+		r.HandleFunc("/inventory/lot", handlr.API.GetLot).Methods("GET")
+
+		// serve serial request
+		r.HandleFunc("/inventory/serial/{serial}", handlr.API.GetSerial).Methods("GET")
+	}
+
+	{
+		// serve stock take request
+		r.HandleFunc("/inventory/stock_take", handlr.API.GetStockTake).Methods("GET")
+		r.HandleFunc("/inventory/stock_take", handlr.API.OpenStockTake).Methods("POST")
+		r.HandleFunc("/inventory/stock_take/{id:[0-9]+}", handlr.API.GetDetailStockTake).Methods("GET")
+		r.HandleFunc("/inventory/stock_take/{id:[0-9]+}", handlr.API.DeleteStockTake).Methods("DELETE")
+		r.HandleFunc("/inventory/stock_take/{id:[0-9]+}/counts", handlr.API.StoreStockTakeCount).Methods("POST")
+		r.HandleFunc("/inventory/stock_take/{id:[0-9]+}/upload", handlr.API.UploadStockTakeCount).Methods("POST")
+		r.HandleFunc("/inventory/stock_take/{id:[0-9]+}/post", handlr.API.PostStockTake).Methods("POST")
+	}
+
+	{
+		// serve purchase return request
+		r.HandleFunc("/inventory/purchase_return", handlr.API.GetPurchaseReturn).Methods("GET")
+		r.HandleFunc("/inventory/purchase_return", handlr.API.StorePurchaseReturn).Methods("POST")
+		r.HandleFunc("/inventory/purchase_return/{id:[0-9]+}", handlr.API.GetDetailPurchaseReturn).Methods("GET")
+	}
+
+	{
+		// serve sales return request
+		r.HandleFunc("/inventory/sales_return", handlr.API.GetSalesReturn).Methods("GET")
+		r.HandleFunc("/inventory/sales_return", handlr.API.StoreSalesReturn).Methods("POST")
+		r.HandleFunc("/inventory/sales_return/{id:[0-9]+}", handlr.API.GetDetailSalesReturn).Methods("GET")
+	}
+
+	{
+		// serve customer request
+		r.HandleFunc("/inventory/customer", handlr.API.GetCustomer).Methods("GET")
+		r.HandleFunc("/inventory/customer", handlr.API.StoreCustomer).Methods("POST")
+		r.HandleFunc("/inventory/customer/{id:[0-9]+}", handlr.API.GetDetailCustomer).Methods("GET")
+		r.HandleFunc("/inventory/customer/{id:[0-9]+}", handlr.API.DeleteCustomer).Methods("DELETE")
+		r.HandleFunc("/inventory/customer/{id:[0-9]+}/history", handlr.API.GetCustomerHistory).Methods("GET")
+	}
+
 	{
 		// serve report request
 		r.HandleFunc("/inventory/report/product", handlr.API.GetProductReport).Methods("GET")
 		r.HandleFunc("/inventory/report/order/{date_start}/{date_end}", handlr.API.GetOrderReport).Methods("GET")
+		r.HandleFunc("/inventory/report/purchase_outstanding", handlr.API.GetOutstandingPurchaseReport).Methods("GET")
+		r.HandleFunc("/inventory/report/adjustment/{date_start}/{date_end}", handlr.API.GetAdjustmentReport).Methods("GET")
+		r.HandleFunc("/inventory/report/low_stock", handlr.API.GetLowStockReport).Methods("GET")
+		r.HandleFunc("/inventory/report/replenishment", handlr.API.GetReplenishmentReport).Methods("GET")
+		r.HandleFunc("/inventory/report/near_expiry", handlr.API.GetNearExpiryReport).Methods("GET")
 	}
 
 	{
 		// serve export request
 		r.HandleFunc("/inventory/export/product", handlr.API.GetProductCSV).Methods("GET")
+		r.HandleFunc("/inventory/export/product/{id:[0-9]+}/movements", handlr.API.GetProductMovementCSV).Methods("GET")
 		r.HandleFunc("/inventory/export/purchase", handlr.API.GetPurchaseCSV).Methods("GET")
 		r.HandleFunc("/inventory/export/order", handlr.API.GetOrderCSV).Methods("GET")
 		r.HandleFunc("/inventory/export/report_product", handlr.API.GetProductReportCSV).Methods("GET")
 		r.HandleFunc("/inventory/export/report_order/{date_start}/{date_end}", handlr.API.GetOrderReportCSV).Methods("GET")
+		r.HandleFunc("/inventory/export/report_purchase_outstanding", handlr.API.GetOutstandingPurchaseReportCSV).Methods("GET")
+		r.HandleFunc("/inventory/export/report_adjustment/{date_start}/{date_end}", handlr.API.GetAdjustmentReportCSV).Methods("GET")
 	}
 
 	// optional task
@@ -107,6 +212,8 @@ func main() {
 		r.HandleFunc("/orders", handlr.Orders).Methods("GET")
 		r.HandleFunc("/product/report", handlr.ProductReport).Methods("GET")
 		r.HandleFunc("/orders/report", handlr.OrderReport).Methods("GET")
+		r.HandleFunc("/purchase/report/outstanding", handlr.PurchaseOutstandingReport).Methods("GET")
+		r.HandleFunc("/adjustment/report", handlr.AdjustmentReport).Methods("GET")
 	}
 
 	http.ListenAndServe(":8080", r)
@@ -4,7 +4,8 @@ import gcfg "gopkg.in/gcfg.v1"
 
 // Config is main entity of package config
 type Config struct {
-	Storage Storage
+	Storage   Storage
+	Inventory Inventory
 }
 
 // Storage is entity of config Storage
@@ -12,6 +13,11 @@ type Storage map[string]*struct {
 	Host string
 }
 
+// Inventory is entity of config Inventory
+type Inventory struct {
+	CostingMethod string
+}
+
 // New to create instance of config
 func New(filePath string) (Config, error) {
 	var config Config
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/sog01/ijahshop/handler/internal/api"
@@ -27,13 +28,16 @@ func New(mod module.Module, tmpl tmplInt.Template) Handler {
 }
 
 // Product is http func that handle index page
+// product which need to be reordered is highlighted above the list
 func (h Handler) Product(w http.ResponseWriter, r *http.Request) {
 	finalTemplate := h.tmpl["product"]
 
 	product, _ := h.mod.GetProduct(r.Context())
+	lowStockReport, _ := h.mod.GetLowStockReport(r.Context())
 
 	finalTemplate.ExecuteTemplate(w, "layout", map[string]interface{}{
-		"Data": product,
+		"Data":     product,
+		"LowStock": lowStockReport.LowStockProduct,
 	})
 }
 
@@ -41,7 +45,12 @@ func (h Handler) Product(w http.ResponseWriter, r *http.Request) {
 func (h Handler) Purchase(w http.ResponseWriter, r *http.Request) {
 	finalTemplate := h.tmpl["purchase"]
 
-	purchase, _ := h.mod.GetPurchaseWithProduct(r.Context(), module.ReqFilterPurchase{})
+	// purchase can be filtered by supplier
+	supplierID, _ := strconv.ParseInt(r.FormValue("supplier_id"), 10, 64)
+
+	purchase, _ := h.mod.GetPurchaseWithProduct(r.Context(), module.ReqFilterPurchase{
+		SupplierID: supplierID,
+	})
 
 	finalTemplate.ExecuteTemplate(w, "layout", map[string]interface{}{
 		"Data": purchase,
@@ -63,7 +72,14 @@ func (h Handler) Orders(w http.ResponseWriter, r *http.Request) {
 func (h Handler) ProductReport(w http.ResponseWriter, r *http.Request) {
 	finalTemplate := h.tmpl["product_report"]
 
-	productReport, _ := h.mod.GetProductAvgValue(r.Context())
+	// date format: yyyy-MM-dd
+	asOf, _ := time.Parse("2006-01-02", r.FormValue("as_of"))
+
+	productReport, _ := h.mod.GetProductAvgValue(r.Context(), module.ReqFilterProductAvgValue{
+		CostingMethod: r.FormValue("costing_method"),
+		AsOf:          asOf,
+		ByParent:      r.FormValue("group_by") == "parent",
+	})
 
 	finalTemplate.ExecuteTemplate(w, "layout", map[string]interface{}{
 		"Data":    productReport.ProductAvgValue,
@@ -79,8 +95,9 @@ func (h Handler) OrderReport(w http.ResponseWriter, r *http.Request) {
 	dateEnd, _ := time.Parse("2006-01-02", "2018-01-10")
 
 	orderReport, _ := h.mod.GetOrderWithProductAvgValueByDate(r.Context(), module.ReqFilterOrder{
-		DateStart: dateStart,
-		DateEnd:   dateEnd,
+		DateStart:     dateStart,
+		DateEnd:       dateEnd,
+		CostingMethod: r.FormValue("costing_method"),
 	})
 
 	finalTemplate.ExecuteTemplate(w, "layout", map[string]interface{}{
@@ -88,3 +105,44 @@ func (h Handler) OrderReport(w http.ResponseWriter, r *http.Request) {
 		"Summary": orderReport.Summary,
 	})
 }
+
+// PurchaseOutstandingReport is http func that handle PurchaseOutstandingReport page
+func (h Handler) PurchaseOutstandingReport(w http.ResponseWriter, r *http.Request) {
+	finalTemplate := h.tmpl["purchase_outstanding_report"]
+
+	outstandingPurchaseReport, _ := h.mod.GetOutstandingPurchase(r.Context())
+
+	finalTemplate.ExecuteTemplate(w, "layout", map[string]interface{}{
+		"Data":    outstandingPurchaseReport.OutstandingPurchase,
+		"Summary": outstandingPurchaseReport.Summary,
+	})
+}
+
+// AdjustmentReport is http func that handle AdjustmentReport page
+// adjustment of current month is shown when no date is requested
+func (h Handler) AdjustmentReport(w http.ResponseWriter, r *http.Request) {
+	finalTemplate := h.tmpl["adjustment_report"]
+
+	// date format: yyyy-MM-dd
+	now := time.Now()
+	dateStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	dateEnd := now
+	if date, err := time.Parse("2006-01-02", r.FormValue("date_start")); err == nil {
+		dateStart = date
+	}
+	if date, err := time.Parse("2006-01-02", r.FormValue("date_end")); err == nil {
+		dateEnd = date
+	}
+
+	adjustmentReport, _ := h.mod.GetAdjustmentReport(r.Context(), module.ReqFilterAdjustment{
+		DateStart: dateStart,
+		DateEnd:   dateEnd,
+	})
+
+	finalTemplate.ExecuteTemplate(w, "layout", map[string]interface{}{
+		"Data":      adjustmentReport.Adjustment,
+		"Summary":   adjustmentReport.Summary,
+		"DateStart": dateStart.Format("2006-01-02"),
+		"DateEnd":   dateEnd.Format("2006-01-02"),
+	})
+}
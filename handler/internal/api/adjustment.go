@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetAdjustment is to serve API which get all adjustment with product
+func (h API) GetAdjustment(w http.ResponseWriter, r *http.Request) {
+	adjustments, err := h.mod.GetAdjustment(r.Context())
+	if err != nil {
+		log.Printf("Error Get Adjustment [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "adjustments", adjustments)
+}
+
+// GetDetailAdjustment is to serve API which get one adjustment
+func (h API) GetDetailAdjustment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	adjustment, err := h.mod.GetAdjustmentByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Adjustment By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "adjustment", adjustment)
+}
+
+// StoreAdjustment is to serve API which store manual adjustment of stock into database
+func (h API) StoreAdjustment(w http.ResponseWriter, r *http.Request) {
+	var reqAdjustment module.ReqAdjustment
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqAdjustment)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : product_id, location_id, quantity, reason (lost|damaged|sample|correction), note, user, serial_numbers, date_raw (yyyy-MM-dd HH:mm:ss)",
+		})
+		return
+	}
+
+	reqAdjustment.AdjustmentID, err = h.mod.StoreAdjustment(r.Context(), reqAdjustment)
+	if errStock, ok := err.(module.ErrInsufficientStock); ok {
+		log.Printf("Conflict Store adjustment into database [err = %v], [req = %+v]\n", err, reqAdjustment)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": "Insufficient stock",
+			"products":    errStock.Shortages,
+		})
+		return
+	}
+	if err == module.ErrProductNotFound {
+		log.Printf("Not Found Store adjustment [err = %v], [req = %+v]\n", err, reqAdjustment)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrInvalidAdjustmentReason || err == module.ErrInvalidAdjustmentQuantity || err == module.ErrLocationNotFound || err == module.ErrBundleStock || err == module.ErrInvalidSerialNumber || err == module.ErrSerialRegistered || err == module.ErrSerialNotAvailable {
+		log.Printf("Bad Request Store adjustment [err = %v], [req = %+v]\n", err, reqAdjustment)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store adjustment into database [err = %v], [req = %+v]\n", err, reqAdjustment)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	adjustment, err := h.mod.GetAdjustmentByID(r.Context(), reqAdjustment.AdjustmentID)
+	if err != nil {
+		log.Printf("Error Get Adjustment By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "adjustment", adjustment)
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetCustomer is to serve API which get all customer with its lifetime orders and revenue
+func (h API) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.mod.GetCustomer(r.Context())
+	if err != nil {
+		log.Printf("Error Get Customer [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "customers", customers)
+}
+
+// GetDetailCustomer is to serve API which get one customer
+func (h API) GetDetailCustomer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	customer, err := h.mod.GetCustomerByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Customer By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "customer", customer)
+}
+
+// StoreCustomer is to serve API which store customer into database
+func (h API) StoreCustomer(w http.ResponseWriter, r *http.Request) {
+	var reqCustomer module.ReqCustomer
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqCustomer)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : customer_id, customer_name, phone, address, channel",
+		})
+		return
+	}
+
+	reqCustomer.CustomerID, err = h.mod.StoreCustomer(r.Context(), reqCustomer)
+	if err == module.ErrCustomerNotFound {
+		log.Printf("Not Found Store customer [err = %v], [req = %+v]\n", err, reqCustomer)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrEmptyCustomerName {
+		log.Printf("Bad Request Store customer [err = %v], [req = %+v]\n", err, reqCustomer)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store customer into database [err = %v], [req = %+v]\n", err, reqCustomer)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	customer, err := h.mod.GetCustomerByID(r.Context(), reqCustomer.CustomerID)
+	if err != nil {
+		log.Printf("Error Get Customer By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "customer", customer)
+}
+
+// GetCustomerHistory is to serve API which get one customer with history of its orders
+func (h API) GetCustomerHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	customerWithOrder, err := h.mod.GetCustomerHistory(r.Context(), ID)
+	if err == module.ErrCustomerNotFound {
+		log.Printf("Not Found Get customer history [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error Get Customer History [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "customer", customerWithOrder)
+}
+
+// DeleteCustomer is to serve API which delete customer
+func (h API) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	err = h.mod.DeleteCustomer(r.Context(), ID)
+	if err == module.ErrCustomerHasOrder {
+		log.Printf("Conflict Delete customer [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Delete Customer [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "customer", map[string]interface{}{"success": "true"})
+}
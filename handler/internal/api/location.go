@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetLocation is to serve API which get all location with its total stock
+func (h API) GetLocation(w http.ResponseWriter, r *http.Request) {
+	locations, err := h.mod.GetLocation(r.Context())
+	if err != nil {
+		log.Printf("Error Get Location [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "locations", locations)
+}
+
+// GetDetailLocation is to serve API which get one location
+func (h API) GetDetailLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	location, err := h.mod.GetLocationByID(r.Context(), ID)
+	if err == module.ErrLocationNotFound {
+		log.Printf("Not Found Get Location By ID [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error Get Location By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "location", location)
+}
+
+// StoreLocation is to serve API which store location into database
+func (h API) StoreLocation(w http.ResponseWriter, r *http.Request) {
+	var reqLocation module.ReqLocation
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqLocation)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : location_id, location_name, description",
+		})
+		return
+	}
+
+	reqLocation.LocationID, err = h.mod.StoreLocation(r.Context(), reqLocation)
+	if err == module.ErrLocationNotFound {
+		log.Printf("Not Found Store location [err = %v], [req = %+v]\n", err, reqLocation)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrEmptyLocationName || err == module.ErrDuplicateLocationName {
+		log.Printf("Bad Request Store location [err = %v], [req = %+v]\n", err, reqLocation)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store location into database [err = %v], [req = %+v]\n", err, reqLocation)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	location, err := h.mod.GetLocationByID(r.Context(), reqLocation.LocationID)
+	if err != nil {
+		log.Printf("Error Get Location By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "location", location)
+}
+
+// DeleteLocation is to serve API which delete location
+func (h API) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	err = h.mod.DeleteLocation(r.Context(), ID)
+	if err == module.ErrLocationNotFound {
+		log.Printf("Not Found Delete location [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrDefaultLocation || err == module.ErrDamagedLocation || err == module.ErrLocationHasMovement {
+		log.Printf("Conflict Delete location [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Delete Location [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "location", map[string]interface{}{"success": "true"})
+}
@@ -0,0 +1,20 @@
+package internal
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/sog01/ijahshop/handler/internal"
+)
+
+// GetLot is to serve API which get all lot which still has stock with its location
+func (h API) GetLot(w http.ResponseWriter, r *http.Request) {
+	lots, err := h.mod.GetLot(r.Context())
+	if err != nil {
+		log.Printf("Error Get Lot [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "lots", lots)
+}
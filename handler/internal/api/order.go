@@ -2,6 +2,7 @@ package internal
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
@@ -90,13 +91,26 @@ func (h API) GetDetailOrder(w http.ResponseWriter, r *http.Request) {
 }
 
 // StoreOrder is to serve API which store order into database
+// payload with lines is stored as sales order with multiple lines
 func (h API) StoreOrder(w http.ResponseWriter, r *http.Request) {
-	var reqOrder module.ReqOrder
+	var (
+		reqOrder      module.ReqOrder
+		reqSalesOrder module.ReqSalesOrder
+	)
 
 	// validate request of json
-	decoder := json.NewDecoder(r.Body)
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &reqSalesOrder)
+	}
+	if err == nil && len(reqSalesOrder.Lines) > 0 {
+		h.storeSalesOrder(w, r, reqSalesOrder)
+		return
+	}
 
-	err := decoder.Decode(&reqOrder)
+	if err == nil {
+		err = json.Unmarshal(body, &reqOrder)
+	}
 	if err != nil {
 		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
 		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
@@ -106,6 +120,21 @@ func (h API) StoreOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	reqOrder.OrderID, err = h.mod.StoreOrder(r.Context(), reqOrder)
+	if errStock, ok := err.(module.ErrInsufficientStock); ok {
+		log.Printf("Conflict Store order into database [err = %v], [req = %+v]\n", err, reqOrder)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": "Insufficient stock",
+			"products":    errStock.Shortages,
+		})
+		return
+	}
+	if err == module.ErrInvalidOrderLine || err == module.ErrCustomerNotFound || err == module.ErrReturnedOrderLine || err == module.ErrLocationNotFound || err == module.ErrInvalidSerialNumber || err == module.ErrSerialNotAvailable || err == module.ErrUnitNotFound {
+		log.Printf("Bad Request Store order [err = %v], [req = %+v]\n", err, reqOrder)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error Store order into database [err = %v], [req = %+v]\n", err, reqOrder)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
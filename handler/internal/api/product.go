@@ -60,19 +60,39 @@ func (h API) StoreProduct(w http.ResponseWriter, r *http.Request) {
 		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
 		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
 			"description": "Invalid Json Request",
-			"info":        "json format : product_id, name, sku, stock",
+			"info":        "json format : product_id, name, sku, stock, reorder_point, reorder_quantity, is_bundle, is_serialized, unit, units (name, factor), components (product_id, quantity)",
 		})
 		return
 	}
 
 	reqProduct.ProductID, err = h.mod.StoreProduct(r.Context(), reqProduct)
+	if err == module.ErrProductNotFound {
+		log.Printf("Not Found Store product [err = %v], [req = %+v]\n", err, reqProduct)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrStockNotEditable || err == module.ErrBundleStock || err == module.ErrBundleNotEditable || err == module.ErrEmptyBundleComponent || err == module.ErrInvalidBundleComponent || err == module.ErrBundleSold || err == module.ErrInvalidReorder || err == module.ErrSerializedBundle || err == module.ErrSerializedNotEditable || err == module.ErrSerializedStock || err == module.ErrInvalidProductUnit {
+		log.Printf("Bad Request Store product [err = %v], [req = %+v]\n", err, reqProduct)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error Store product into database [err = %v], [req = %+v]\n", err, reqProduct)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 
-	internal.ConstructRespSucces(w, "product", reqProduct)
+	// stored product is shown with its base unit and packs
+	product, err := h.mod.GetProductByID(r.Context(), reqProduct.ProductID)
+	if err != nil {
+		log.Printf("Error Get Product By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "product", product)
 }
 
 // DeleteProduct is to serve API which delete product
@@ -91,6 +111,13 @@ func (h API) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.mod.DeleteProduct(r.Context(), ID)
+	if err == module.ErrProductIsComponent {
+		log.Printf("Conflict Delete Product [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error Get Product By ID [%v]\n", err)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
@@ -111,3 +138,53 @@ func (h API) GetProductCSV(w http.ResponseWriter, r *http.Request) {
 
 	internal.DownloadFile(w, "Catatan Jumlah Barang.csv")
 }
+
+// GetProductMovement is to serve API which get stock card of one product
+func (h API) GetProductMovement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	stockMovements, err := h.mod.GetStockMovementByProductID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Stock Movement By Product ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "movements", stockMovements)
+}
+
+// GetProductMovementCSV is to serve API which get csv file of stock card of one product
+func (h API) GetProductMovementCSV(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	err = h.mod.WriteStockMovementToCSV(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Stock Movement CSV [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.DownloadFile(w, "Kartu Stok.csv")
+}
@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetProductParent is to serve API which get all product parent
+func (h API) GetProductParent(w http.ResponseWriter, r *http.Request) {
+	productParents, err := h.mod.GetProductParent(r.Context())
+	if err != nil {
+		log.Printf("Error Get Product Parent [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "product_parents", productParents)
+}
+
+// GetDetailProductParent is to serve API which get one product parent with its variant
+func (h API) GetDetailProductParent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	productParent, err := h.mod.GetProductParentByID(r.Context(), ID)
+	if err == module.ErrProductParentNotFound {
+		log.Printf("Not Found Get Product Parent By ID [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error Get Product Parent By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "product_parent", productParent)
+}
+
+// StoreProductParent is to serve API which store product parent into database
+func (h API) StoreProductParent(w http.ResponseWriter, r *http.Request) {
+	var reqProductParent module.ReqProductParent
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqProductParent)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : parent_id, parent_name, parent_sku, description",
+		})
+		return
+	}
+
+	reqProductParent.ParentID, err = h.mod.StoreProductParent(r.Context(), reqProductParent)
+	if err == module.ErrProductParentNotFound {
+		log.Printf("Not Found Store product parent [err = %v], [req = %+v]\n", err, reqProductParent)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrEmptyProductParent || err == module.ErrDuplicateProductParentSku {
+		log.Printf("Bad Request Store product parent [err = %v], [req = %+v]\n", err, reqProductParent)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store product parent into database [err = %v], [req = %+v]\n", err, reqProductParent)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	productParent, err := h.mod.GetProductParentByID(r.Context(), reqProductParent.ParentID)
+	if err != nil {
+		log.Printf("Error Get Product Parent By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "product_parent", productParent)
+}
+
+// StoreProductVariant is to serve API which generate variant of product parent
+func (h API) StoreProductVariant(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	var reqProductVariant module.ReqProductVariant
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err = decoder.Decode(&reqProductVariant)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : attributes (name, options (value, code))",
+		})
+		return
+	}
+
+	_, err = h.mod.StoreProductVariant(r.Context(), ID, reqProductVariant)
+	if err == module.ErrProductParentNotFound {
+		log.Printf("Not Found Store product variant [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrEmptyVariantAttribute {
+		log.Printf("Bad Request Store product variant [err = %v], [req = %+v]\n", err, reqProductVariant)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err == module.ErrVariantSkuUsed {
+		log.Printf("Conflict Store product variant [err = %v], [req = %+v]\n", err, reqProductVariant)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store product variant into database [err = %v], [req = %+v]\n", err, reqProductVariant)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	productParent, err := h.mod.GetProductParentByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Product Parent By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "product_parent", productParent)
+}
+
+// DeleteProductParent is to serve API which delete product parent while keeping its variant
+func (h API) DeleteProductParent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	err = h.mod.DeleteProductParent(r.Context(), ID)
+	if err == module.ErrProductParentNotFound {
+		log.Printf("Not Found Delete product parent [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error Delete Product Parent [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "product_parent", map[string]interface{}{"success": "true"})
+}
@@ -13,8 +13,20 @@ import (
 )
 
 // GetPurchase is to serve API which get all Purchase
+// purchase can be filtered by supplier using query supplier_id
 func (h API) GetPurchase(w http.ResponseWriter, r *http.Request) {
-	purchasesWithProduct, err := h.mod.GetPurchaseWithProduct(r.Context(), module.ReqFilterPurchase{})
+	supplierID, err := formSupplierID(r)
+	if err != nil {
+		log.Printf("Bad Request supplier id [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid supplier id",
+		})
+		return
+	}
+
+	purchasesWithProduct, err := h.mod.GetPurchaseWithProduct(r.Context(), module.ReqFilterPurchase{
+		SupplierID: supplierID,
+	})
 	if err != nil {
 		log.Printf("Error Get Purchase [%v]\n", err)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
@@ -50,9 +62,19 @@ func (h API) GetPurchaseByDate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	supplierID, err := formSupplierID(r)
+	if err != nil {
+		log.Printf("Bad Request supplier id [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid supplier id",
+		})
+		return
+	}
+
 	purchasesWithProduct, err := h.mod.GetPurchaseWithProduct(r.Context(), module.ReqFilterPurchase{
-		DateStart: dateStart,
-		DateEnd:   dateEnd,
+		DateStart:  dateStart,
+		DateEnd:    dateEnd,
+		SupplierID: supplierID,
 	})
 	if err != nil {
 		log.Printf("Error Get Purchase [%v]\n", err)
@@ -105,6 +127,34 @@ func (h API) StorePurchase(w http.ResponseWriter, r *http.Request) {
 	}
 
 	reqPurchase.PurchaseID, err = h.mod.StorePurchase(r.Context(), reqPurchase)
+	if err == module.ErrSupplierNotFound {
+		log.Printf("Bad Request Store purchase [err = %v], [req = %+v]\n", err, reqPurchase)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Supplier not found",
+		})
+		return
+	}
+	if err == module.ErrLocationNotFound {
+		log.Printf("Bad Request Store purchase [err = %v], [req = %+v]\n", err, reqPurchase)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err == module.ErrInvalidReceiptQuantity {
+		log.Printf("Bad Request Store purchase [err = %v], [req = %+v]\n", err, reqPurchase)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid receipt quantity",
+		})
+		return
+	}
+	if err == module.ErrPurchaseDtlNotFound || err == module.ErrReturnedPurchase || err == module.ErrBundleStock || err == module.ErrInvalidExpiryDate || err == module.ErrInvalidSerialNumber || err == module.ErrSerialRegistered || err == module.ErrSerialNotAvailable || err == module.ErrSerializedNotEditable || err == module.ErrUnitNotFound {
+		log.Printf("Bad Request Store purchase [err = %v], [req = %+v]\n", err, reqPurchase)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error Store purchase into database [err = %v], [req = %+v]\n", err, reqPurchase)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
@@ -114,6 +164,106 @@ func (h API) StorePurchase(w http.ResponseWriter, r *http.Request) {
 	internal.ConstructRespSucces(w, "purchase", reqPurchase)
 }
 
+// StoreReplenishmentPurchase is to serve API which convert selected replenishment into purchase
+func (h API) StoreReplenishmentPurchase(w http.ResponseWriter, r *http.Request) {
+	var reqReplenishment module.ReqReplenishmentPurchase
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqReplenishment)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : product_ids, window, cover_days, lead_time, date_raw (yyyy-MM-dd HH:mm:ss)",
+		})
+		return
+	}
+
+	IDs, err := h.mod.StoreReplenishmentPurchase(r.Context(), reqReplenishment)
+	if err == module.ErrInvalidReplenishmentFilter || err == module.ErrEmptyReplenishment || err == module.ErrReplenishmentNotFound {
+		log.Printf("Bad Request Store replenishment purchase [err = %v], [req = %+v]\n", err, reqReplenishment)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store replenishment purchase into database [err = %v], [req = %+v]\n", err, reqReplenishment)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "purchase_ids", IDs)
+}
+
+// StorePurchaseReceipt is to serve API which store receipt (partial delivery) of purchase into database
+func (h API) StorePurchaseReceipt(w http.ResponseWriter, r *http.Request) {
+	var reqPurchaseDtl module.ReqPurchaseDtl
+
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err = decoder.Decode(&reqPurchaseDtl)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : quantity, description, location_id, lot_number, expiry_date (yyyy-MM-dd), serial_numbers, date_raw",
+		})
+		return
+	}
+
+	_, err = h.mod.StorePurchaseReceipt(r.Context(), ID, reqPurchaseDtl)
+	if err == module.ErrPurchaseNotFound {
+		log.Printf("Not Found Store purchase receipt [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrLocationNotFound || err == module.ErrBundleStock || err == module.ErrInvalidExpiryDate || err == module.ErrInvalidSerialNumber || err == module.ErrSerialRegistered {
+		log.Printf("Bad Request Store purchase receipt [err = %v], [req = %+v]\n", err, reqPurchaseDtl)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err == module.ErrInvalidReceiptQuantity {
+		log.Printf("Bad Request Store purchase receipt [err = %v], [req = %+v]\n", err, reqPurchaseDtl)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid receipt quantity",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store purchase receipt into database [err = %v], [req = %+v]\n", err, reqPurchaseDtl)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	purchaseWithProduct, err := h.mod.GetPurchaseWithProductByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Purchase By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "purchase", purchaseWithProduct)
+}
+
 // GetPurchaseCSV is to serve API which get csv file of purchase entity
 func (h API) GetPurchaseCSV(w http.ResponseWriter, r *http.Request) {
 	err := h.mod.WritePurchaseToCSV(r.Context())
@@ -125,3 +275,13 @@ func (h API) GetPurchaseCSV(w http.ResponseWriter, r *http.Request) {
 
 	internal.DownloadFile(w, "Catatan Barang Masuk.csv")
 }
+
+// formSupplierID is to parse optional query supplier_id
+func formSupplierID(r *http.Request) (int64, error) {
+	supplierIDStr := r.FormValue("supplier_id")
+	if supplierIDStr == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(supplierIDStr, 10, 64)
+}
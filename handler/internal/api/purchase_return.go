@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetPurchaseReturn is to serve API which get all purchase return with its purchase
+func (h API) GetPurchaseReturn(w http.ResponseWriter, r *http.Request) {
+	purchaseReturns, err := h.mod.GetPurchaseReturn(r.Context())
+	if err != nil {
+		log.Printf("Error Get Purchase Return [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "purchase_returns", purchaseReturns)
+}
+
+// GetDetailPurchaseReturn is to serve API which get one purchase return
+func (h API) GetDetailPurchaseReturn(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	purchaseReturn, err := h.mod.GetPurchaseReturnByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Purchase Return By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "purchase_return", purchaseReturn)
+}
+
+// StorePurchaseReturn is to serve API which store goods which is sent back to supplier into database
+func (h API) StorePurchaseReturn(w http.ResponseWriter, r *http.Request) {
+	var reqPurchaseReturn module.ReqPurchaseReturn
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqPurchaseReturn)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : purchase_id, purchase_detail_id, quantity, description, serial_numbers, date_raw (yyyy-MM-dd HH:mm:ss)",
+		})
+		return
+	}
+
+	reqPurchaseReturn.PurchaseReturnID, err = h.mod.StorePurchaseReturn(r.Context(), reqPurchaseReturn)
+	if errStock, ok := err.(module.ErrInsufficientStock); ok {
+		log.Printf("Conflict Store purchase return into database [err = %v], [req = %+v]\n", err, reqPurchaseReturn)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": "Insufficient stock",
+			"products":    errStock.Shortages,
+		})
+		return
+	}
+	if err == module.ErrPurchaseNotFound {
+		log.Printf("Not Found Store purchase return [err = %v], [req = %+v]\n", err, reqPurchaseReturn)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrInvalidReturnQuantity || err == module.ErrPurchaseReceiptNotFound || err == module.ErrInvalidSerialNumber || err == module.ErrSerialNotAvailable {
+		log.Printf("Bad Request Store purchase return [err = %v], [req = %+v]\n", err, reqPurchaseReturn)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store purchase return into database [err = %v], [req = %+v]\n", err, reqPurchaseReturn)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	purchaseReturn, err := h.mod.GetPurchaseReturnByID(r.Context(), reqPurchaseReturn.PurchaseReturnID)
+	if err != nil {
+		log.Printf("Error Get Purchase Return By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "purchase_return", purchaseReturn)
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -14,7 +15,33 @@ import (
 
 // GetProductReport is to serve API which get all productAvgValue
 func (h API) GetProductReport(w http.ResponseWriter, r *http.Request) {
-	productAvgValuesWithProduct, err := h.mod.GetProductAvgValue(r.Context())
+	// sanitize request
+	// date format: yyyy-MM-dd
+	var asOf time.Time
+	if asOfStr := r.FormValue("as_of"); asOfStr != "" {
+		var err error
+		asOf, err = time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			log.Printf("Bad Request as of [%v]\n", err)
+			internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+				"description": "Invalid date format",
+			})
+			return
+		}
+	}
+
+	productAvgValuesWithProduct, err := h.mod.GetProductAvgValue(r.Context(), module.ReqFilterProductAvgValue{
+		CostingMethod: r.FormValue("costing_method"),
+		AsOf:          asOf,
+		ByParent:      r.FormValue("group_by") == "parent",
+	})
+	if err == module.ErrInvalidCostingMethod {
+		log.Printf("Bad Request costing method [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid costing method",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error Get productAvgValue [%v]\n", err)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
@@ -60,10 +87,18 @@ func (h API) GetOrderReport(w http.ResponseWriter, r *http.Request) {
 	}
 
 	orderWithProductValueWithSummary, err := h.mod.GetOrderWithProductAvgValueByDate(r.Context(), module.ReqFilterOrder{
-		DateStart: dateStart,
-		DateEnd:   dateEnd,
+		DateStart:     dateStart,
+		DateEnd:       dateEnd,
+		CostingMethod: r.FormValue("costing_method"),
+		ByParent:      r.FormValue("group_by") == "parent",
 	})
-
+	if err == module.ErrInvalidCostingMethod {
+		log.Printf("Bad Request costing method [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid costing method",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error Get Order [%v]\n", err)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
@@ -82,9 +117,221 @@ func (h API) GetOrderReport(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+// GetOutstandingPurchaseReport is to serve API which get all purchase which has not been fully received
+func (h API) GetOutstandingPurchaseReport(w http.ResponseWriter, r *http.Request) {
+	outstandingPurchaseWithSummary, err := h.mod.GetOutstandingPurchase(r.Context())
+	if err != nil {
+		log.Printf("Error Get Outstanding Purchase [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	// mapping summary which will be shown as meta data
+	summary := map[string]interface{}{
+		"summary": outstandingPurchaseWithSummary.Summary,
+	}
+
+	internal.ConstructRespSuccesWithMeta(w,
+		"purchase_outstanding_report",
+		outstandingPurchaseWithSummary.OutstandingPurchase,
+		summary,
+	)
+}
+
+// GetLowStockReport is to serve API which get all product which stock is at or below its reorder point
+func (h API) GetLowStockReport(w http.ResponseWriter, r *http.Request) {
+	lowStockWithSummary, err := h.mod.GetLowStockReport(r.Context())
+	if err != nil {
+		log.Printf("Error Get Low Stock Report [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	// mapping summary which will be shown as meta data
+	summary := map[string]interface{}{
+		"summary": lowStockWithSummary.Summary,
+	}
+
+	internal.ConstructRespSuccesWithMeta(w,
+		"low_stock_report",
+		lowStockWithSummary.LowStockProduct,
+		summary,
+	)
+}
+
+// GetReplenishmentReport is to serve API which get suggested purchase of product from its sales velocity
+func (h API) GetReplenishmentReport(w http.ResponseWriter, r *http.Request) {
+	// sanitize request
+	// empty filter means its default value is used
+	var reqFilter module.ReqFilterReplenishment
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{
+		{"window", &reqFilter.Window},
+		{"cover_days", &reqFilter.CoverDays},
+		{"lead_time", &reqFilter.LeadTime},
+	} {
+		valueStr := r.FormValue(param.name)
+		if valueStr == "" {
+			continue
+		}
+
+		var err error
+		*param.value, err = strconv.Atoi(valueStr)
+		if err != nil {
+			log.Printf("Bad Request %s [%v]\n", param.name, err)
+			internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+				"description": "Invalid " + param.name,
+			})
+			return
+		}
+	}
+
+	replenishmentWithSummary, err := h.mod.GetReplenishment(r.Context(), reqFilter)
+	if err == module.ErrInvalidReplenishmentFilter {
+		log.Printf("Bad Request replenishment filter [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Get Replenishment Report [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	// mapping summary which will be shown as meta data
+	summary := map[string]interface{}{
+		"summary": replenishmentWithSummary.Summary,
+	}
+
+	internal.ConstructRespSuccesWithMeta(w,
+		"replenishment_report",
+		replenishmentWithSummary.Replenishment,
+		summary,
+	)
+}
+
+// GetNearExpiryReport is to serve API which get all lot which expires within filtered days
+func (h API) GetNearExpiryReport(w http.ResponseWriter, r *http.Request) {
+	// sanitize request
+	// empty days means lot which expires within 30 days
+	var reqFilter module.ReqFilterNearExpiry
+	if daysStr := r.FormValue("days"); daysStr != "" {
+		var err error
+		reqFilter.Days, err = strconv.Atoi(daysStr)
+		if err != nil {
+			log.Printf("Bad Request days [%v]\n", err)
+			internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+				"description": "Invalid days",
+			})
+			return
+		}
+	}
+
+	nearExpiryWithSummary, err := h.mod.GetNearExpiryReport(r.Context(), reqFilter)
+	if err == module.ErrInvalidNearExpiryDays {
+		log.Printf("Bad Request days [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Get Near Expiry Report [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	// mapping summary which will be shown as meta data
+	summary := map[string]interface{}{
+		"summary": nearExpiryWithSummary.Summary,
+	}
+
+	internal.ConstructRespSuccesWithMeta(w,
+		"near_expiry_report",
+		nearExpiryWithSummary.NearExpiryLot,
+		summary,
+	)
+}
+
+// GetAdjustmentReport is to serve API which get all adjustment by filter date
+func (h API) GetAdjustmentReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// sanitize request
+	// date format: yyyy-MM-dd
+	dateStart, err := time.Parse("2006-01-02", vars["date_start"])
+	if err != nil {
+		log.Printf("Bad Request date start [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid date format",
+		})
+		return
+	}
+
+	dateEnd, err := time.Parse("2006-01-02", vars["date_end"])
+	if err != nil {
+		log.Printf("Bad Request date end [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid date format",
+		})
+		return
+	}
+
+	adjustmentWithSummary, err := h.mod.GetAdjustmentReport(r.Context(), module.ReqFilterAdjustment{
+		DateStart: dateStart,
+		DateEnd:   dateEnd,
+	})
+	if err != nil {
+		log.Printf("Error Get Adjustment Report [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	// mapping summary which will be shown as meta data
+	summary := map[string]interface{}{
+		"summary": adjustmentWithSummary.Summary,
+	}
+
+	internal.ConstructRespSuccesWithMeta(w,
+		"adjustment_report",
+		adjustmentWithSummary.Adjustment,
+		summary,
+	)
+}
+
 // GetProductReportCSV is to serve API which get csv file of entity product report
 func (h API) GetProductReportCSV(w http.ResponseWriter, r *http.Request) {
-	err := h.mod.WriteProductReportToCSV(r.Context())
+	// sanitize request
+	// date format: yyyy-MM-dd
+	var asOf time.Time
+	if asOfStr := r.FormValue("as_of"); asOfStr != "" {
+		var err error
+		asOf, err = time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			log.Printf("Bad Request as of [%v]\n", err)
+			internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+				"description": "Invalid date format",
+			})
+			return
+		}
+	}
+
+	err := h.mod.WriteProductReportToCSV(r.Context(), module.ReqFilterProductAvgValue{
+		CostingMethod: r.FormValue("costing_method"),
+		AsOf:          asOf,
+		ByParent:      r.FormValue("group_by") == "parent",
+	})
+	if err == module.ErrInvalidCostingMethod {
+		log.Printf("Bad Request costing method [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid costing method",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error Get Product Report CSV [%v]\n", err)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
@@ -121,9 +368,18 @@ func (h API) GetOrderReportCSV(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.mod.WriteOrderReportToCSV(r.Context(), module.ReqFilterOrder{
-		DateStart: dateStart,
-		DateEnd:   dateEnd,
+		DateStart:     dateStart,
+		DateEnd:       dateEnd,
+		CostingMethod: r.FormValue("costing_method"),
+		ByParent:      r.FormValue("group_by") == "parent",
 	})
+	if err == module.ErrInvalidCostingMethod {
+		log.Printf("Bad Request costing method [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid costing method",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error Get Order Report CSV [%v]\n", err)
 		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
@@ -164,3 +420,52 @@ func (h API) ImportExcelFile(w http.ResponseWriter, r *http.Request) {
 	internal.ConstructRespSucces(w, "success", true)
 
 }
+
+// GetOutstandingPurchaseReportCSV is to serve API which get csv file of entity outstanding purchase report
+func (h API) GetOutstandingPurchaseReportCSV(w http.ResponseWriter, r *http.Request) {
+	err := h.mod.WriteOutstandingPurchaseReportToCSV(r.Context())
+	if err != nil {
+		log.Printf("Error Get Outstanding Purchase Report CSV [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.DownloadFile(w, "Laporan Barang Belum Diterima.csv")
+}
+
+// GetAdjustmentReportCSV is to serve API which get csv file of entity adjustment report
+func (h API) GetAdjustmentReportCSV(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// sanitize request
+	// date format: yyyy-MM-dd
+	dateStart, err := time.Parse("2006-01-02", vars["date_start"])
+	if err != nil {
+		log.Printf("Bad Request date start [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid date format",
+		})
+		return
+	}
+
+	dateEnd, err := time.Parse("2006-01-02", vars["date_end"])
+	if err != nil {
+		log.Printf("Bad Request date end [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid date format",
+		})
+		return
+	}
+
+	err = h.mod.WriteAdjustmentReportToCSV(r.Context(), module.ReqFilterAdjustment{
+		DateStart: dateStart,
+		DateEnd:   dateEnd,
+	})
+	if err != nil {
+		log.Printf("Error Get Adjustment Report CSV [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.DownloadFile(w, "Laporan Penyesuaian Stok.csv")
+}
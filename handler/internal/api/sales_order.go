@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetSalesOrder is to serve API which get all sales order
+func (h API) GetSalesOrder(w http.ResponseWriter, r *http.Request) {
+	salesOrders, err := h.mod.GetSalesOrder(r.Context())
+	if err != nil {
+		log.Printf("Error Get Sales Order [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "sales_orders", salesOrders)
+}
+
+// GetDetailSalesOrder is to serve API which get one sales order with its lines
+func (h API) GetDetailSalesOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	salesOrder, err := h.mod.GetSalesOrderByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Sales Order By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "sales_order", salesOrder)
+}
+
+// storeSalesOrder is to store sales order with multiple lines into database
+func (h API) storeSalesOrder(w http.ResponseWriter, r *http.Request, reqSalesOrder module.ReqSalesOrder) {
+	ID, err := h.mod.StoreSalesOrder(r.Context(), reqSalesOrder)
+	if errStock, ok := err.(module.ErrInsufficientStock); ok {
+		log.Printf("Conflict Store sales order into database [err = %v], [req = %+v]\n", err, reqSalesOrder)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": "Insufficient stock",
+			"products":    errStock.Shortages,
+		})
+		return
+	}
+	switch err {
+	case module.ErrEmptySalesOrder, module.ErrInvalidSalesOrderStatus, module.ErrDuplicateOrderNumber, module.ErrInvalidOrderLine, module.ErrCustomerNotFound, module.ErrReturnedOrderLine, module.ErrLocationNotFound, module.ErrInvalidSerialNumber, module.ErrSerialNotAvailable, module.ErrUnitNotFound:
+		log.Printf("Bad Request Store sales order [err = %v], [req = %+v]\n", err, reqSalesOrder)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store sales order into database [err = %v], [req = %+v]\n", err, reqSalesOrder)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	salesOrder, err := h.mod.GetSalesOrderByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Sales Order By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "sales_order", salesOrder)
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetSalesReturn is to serve API which get all sales return with its order line
+func (h API) GetSalesReturn(w http.ResponseWriter, r *http.Request) {
+	salesReturns, err := h.mod.GetSalesReturn(r.Context())
+	if err != nil {
+		log.Printf("Error Get Sales Return [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "sales_returns", salesReturns)
+}
+
+// GetDetailSalesReturn is to serve API which get one sales return
+func (h API) GetDetailSalesReturn(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	salesReturn, err := h.mod.GetSalesReturnByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Sales Return By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "sales_return", salesReturn)
+}
+
+// StoreSalesReturn is to serve API which store return of order line into database
+func (h API) StoreSalesReturn(w http.ResponseWriter, r *http.Request) {
+	var reqSalesReturn module.ReqSalesReturn
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqSalesReturn)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : order_id, quantity, price, condition (good|damaged), description, serial_numbers, date_raw (yyyy-MM-dd HH:mm:ss)",
+		})
+		return
+	}
+
+	reqSalesReturn.SalesReturnID, err = h.mod.StoreSalesReturn(r.Context(), reqSalesReturn)
+	if err == module.ErrOrderNotFound {
+		log.Printf("Not Found Store sales return [err = %v], [req = %+v]\n", err, reqSalesReturn)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrInvalidReturnQuantity || err == module.ErrInvalidReturnCondition || err == module.ErrInvalidSerialNumber || err == module.ErrSerialNotAvailable {
+		log.Printf("Bad Request Store sales return [err = %v], [req = %+v]\n", err, reqSalesReturn)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store sales return into database [err = %v], [req = %+v]\n", err, reqSalesReturn)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	salesReturn, err := h.mod.GetSalesReturnByID(r.Context(), reqSalesReturn.SalesReturnID)
+	if err != nil {
+		log.Printf("Error Get Sales Return By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "sales_return", salesReturn)
+}
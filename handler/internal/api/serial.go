@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetSerial is to serve API which get unit by its serial number with its history
+func (h API) GetSerial(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serialNumber := vars["serial"]
+
+	serial, err := h.mod.GetSerial(r.Context(), serialNumber)
+	if err == module.ErrSerialNotFound {
+		log.Printf("Not Found Get Serial [err = %v], [serial = %s]\n", err, serialNumber)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error Get Serial [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "serial", serial)
+}
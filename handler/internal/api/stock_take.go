@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetStockTake is to serve API which get all stock take
+func (h API) GetStockTake(w http.ResponseWriter, r *http.Request) {
+	stockTakes, err := h.mod.GetStockTake(r.Context())
+	if err != nil {
+		log.Printf("Error Get Stock Take [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "stock_takes", stockTakes)
+}
+
+// GetDetailStockTake is to serve API which get one stock take with variance of each product
+func (h API) GetDetailStockTake(w http.ResponseWriter, r *http.Request) {
+	ID, ok := formStockTakeID(w, r)
+	if !ok {
+		return
+	}
+
+	stockTake, err := h.mod.GetStockTakeByID(r.Context(), ID)
+	if err != nil {
+		constructStockTakeError(w, err, ID)
+		return
+	}
+
+	internal.ConstructRespSucces(w, "stock_take", stockTake)
+}
+
+// OpenStockTake is to serve API which open stock take and snapshot stock of all product on location
+func (h API) OpenStockTake(w http.ResponseWriter, r *http.Request) {
+	var reqStockTake module.ReqStockTake
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqStockTake)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : location_id, description",
+		})
+		return
+	}
+
+	ID, err := h.mod.OpenStockTake(r.Context(), reqStockTake)
+	if err != nil {
+		constructStockTakeError(w, err, ID)
+		return
+	}
+
+	h.getDetailStockTake(w, r, ID)
+}
+
+// StoreStockTakeCount is to serve API which store counted quantity of product into stock take
+func (h API) StoreStockTakeCount(w http.ResponseWriter, r *http.Request) {
+	var reqStockTakeCounts []module.ReqStockTakeCount
+
+	ID, ok := formStockTakeID(w, r)
+	if !ok {
+		return
+	}
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqStockTakeCounts)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : [{product_id or product_sku, quantity_counted}]",
+		})
+		return
+	}
+
+	err = h.mod.StoreStockTakeCount(r.Context(), ID, reqStockTakeCounts)
+	if err != nil {
+		constructStockTakeError(w, err, ID)
+		return
+	}
+
+	h.getDetailStockTake(w, r, ID)
+}
+
+// UploadStockTakeCount is to serve API which store counted quantity from csv into stock take
+// csv has the same layout as Catatan Jumlah Barang
+func (h API) UploadStockTakeCount(w http.ResponseWriter, r *http.Request) {
+	ID, ok := formStockTakeID(w, r)
+	if !ok {
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		log.Printf("Error Bad Request [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	defer file.Close()
+
+	err = h.mod.StoreStockTakeCountFromCSV(r.Context(), ID, file)
+	if err != nil {
+		constructStockTakeError(w, err, ID)
+		return
+	}
+
+	h.getDetailStockTake(w, r, ID)
+}
+
+// PostStockTake is to serve API which post variance of stock take as adjustment
+func (h API) PostStockTake(w http.ResponseWriter, r *http.Request) {
+	ID, ok := formStockTakeID(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.mod.PostStockTake(r.Context(), ID)
+	if err != nil {
+		constructStockTakeError(w, err, ID)
+		return
+	}
+
+	h.getDetailStockTake(w, r, ID)
+}
+
+// DeleteStockTake is to serve API which cancel stock take which has not been posted
+func (h API) DeleteStockTake(w http.ResponseWriter, r *http.Request) {
+	ID, ok := formStockTakeID(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.mod.DeleteStockTake(r.Context(), ID)
+	if err != nil {
+		constructStockTakeError(w, err, ID)
+		return
+	}
+
+	internal.ConstructRespSucces(w, "stock_take", map[string]interface{}{"success": "true"})
+}
+
+func (h API) getDetailStockTake(w http.ResponseWriter, r *http.Request, ID int64) {
+	stockTake, err := h.mod.GetStockTakeByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Stock Take By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "stock_take", stockTake)
+}
+
+// formStockTakeID is to validate id of stock take from url
+func formStockTakeID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return 0, false
+	}
+
+	return ID, true
+}
+
+// constructStockTakeError is to construct response of error from stock take request
+func constructStockTakeError(w http.ResponseWriter, err error, ID int64) {
+	if errStock, ok := err.(module.ErrInsufficientStock); ok {
+		log.Printf("Conflict stock take [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": "Insufficient stock",
+			"products":    errStock.Shortages,
+		})
+		return
+	}
+
+	switch err {
+	case module.ErrStockTakeNotFound:
+		log.Printf("Not Found stock take [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+	case module.ErrStockTakeStillOpen, module.ErrStockTakeNotOpen:
+		log.Printf("Conflict stock take [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": err.Error(),
+		})
+	case module.ErrStockTakeProductNotFound, module.ErrInvalidCountQuantity, module.ErrInvalidStockTakeCSV, module.ErrSerializedVariance, module.ErrLocationNotFound:
+		log.Printf("Bad Request stock take [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+	default:
+		log.Printf("Error stock take [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+	}
+}
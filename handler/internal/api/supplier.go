@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetSupplier is to serve API which get all supplier with its spend and lead time
+func (h API) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := h.mod.GetSupplier(r.Context())
+	if err != nil {
+		log.Printf("Error Get Supplier [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "suppliers", suppliers)
+}
+
+// GetDetailSupplier is to serve API which get one supplier
+func (h API) GetDetailSupplier(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	supplier, err := h.mod.GetSupplierByID(r.Context(), ID)
+	if err != nil {
+		log.Printf("Error Get Supplier By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "supplier", supplier)
+}
+
+// StoreSupplier is to serve API which store supplier into database
+func (h API) StoreSupplier(w http.ResponseWriter, r *http.Request) {
+	var reqSupplier module.ReqSupplier
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqSupplier)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : supplier_id, supplier_name, phone, email, address",
+		})
+		return
+	}
+
+	reqSupplier.SupplierID, err = h.mod.StoreSupplier(r.Context(), reqSupplier)
+	if err == module.ErrEmptySupplierName || err == module.ErrDuplicateSupplierName {
+		log.Printf("Bad Request Store supplier [err = %v], [req = %+v]\n", err, reqSupplier)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store supplier into database [err = %v], [req = %+v]\n", err, reqSupplier)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	supplier, err := h.mod.GetSupplierByID(r.Context(), reqSupplier.SupplierID)
+	if err != nil {
+		log.Printf("Error Get Supplier By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "supplier", supplier)
+}
+
+// DeleteSupplier is to serve API which delete supplier
+func (h API) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	err = h.mod.DeleteSupplier(r.Context(), ID)
+	if err == module.ErrSupplierHasPurchase {
+		log.Printf("Conflict Delete supplier [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Delete Supplier [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "supplier", map[string]interface{}{"success": "true"})
+}
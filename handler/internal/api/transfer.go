@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sog01/ijahshop/handler/internal"
+	"github.com/sog01/ijahshop/module"
+)
+
+// GetTransfer is to serve API which get all transfer
+func (h API) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	transfers, err := h.mod.GetTransfer(r.Context())
+	if err != nil {
+		log.Printf("Error Get Transfer [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "transfers", transfers)
+}
+
+// GetDetailTransfer is to serve API which get one transfer with its transferred product
+func (h API) GetDetailTransfer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	IDstr := vars["id"]
+
+	// validate request
+	ID, err := strconv.ParseInt(IDstr, 10, 64)
+	if err != nil {
+		log.Printf("Bad Request Form ID [%v]\n", err)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid id",
+		})
+		return
+	}
+
+	transfer, err := h.mod.GetTransferByID(r.Context(), ID)
+	if err == module.ErrTransferNotFound {
+		log.Printf("Not Found Get Transfer By ID [err = %v], [id = %d]\n", err, ID)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error Get Transfer By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "transfer", transfer)
+}
+
+// StoreTransfer is to serve API which store transfer of stock between location into database
+func (h API) StoreTransfer(w http.ResponseWriter, r *http.Request) {
+	var reqTransfer module.ReqTransfer
+
+	// validate request of json
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&reqTransfer)
+	if err != nil {
+		log.Printf("bad request [err = %v, body = %+v]\n", err, r.Body)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": "Invalid Json Request",
+			"info":        "json format : from_location_id, to_location_id, description, date_raw (yyyy-MM-dd HH:mm:ss), details (product_id, quantity, serial_numbers)",
+		})
+		return
+	}
+
+	reqTransfer.TransferID, err = h.mod.StoreTransfer(r.Context(), reqTransfer)
+	if errStock, ok := err.(module.ErrInsufficientStock); ok {
+		log.Printf("Conflict Store transfer into database [err = %v], [req = %+v]\n", err, reqTransfer)
+		internal.ConstructRespErrorWithDetail(w, http.StatusConflict, "Conflict", map[string]interface{}{
+			"description": "Insufficient stock",
+			"products":    errStock.Shortages,
+		})
+		return
+	}
+	if err == module.ErrProductNotFound {
+		log.Printf("Not Found Store transfer [err = %v], [req = %+v]\n", err, reqTransfer)
+		internal.ConstructRespError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err == module.ErrLocationNotFound || err == module.ErrEmptyTransfer || err == module.ErrSameTransferLocation || err == module.ErrInvalidTransferQuantity || err == module.ErrBundleStock || err == module.ErrInvalidSerialNumber || err == module.ErrSerialNotAvailable {
+		log.Printf("Bad Request Store transfer [err = %v], [req = %+v]\n", err, reqTransfer)
+		internal.ConstructRespErrorWithDetail(w, http.StatusBadRequest, "Bad Request", map[string]interface{}{
+			"description": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error Store transfer into database [err = %v], [req = %+v]\n", err, reqTransfer)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	transfer, err := h.mod.GetTransferByID(r.Context(), reqTransfer.TransferID)
+	if err != nil {
+		log.Printf("Error Get Transfer By ID [%v]\n", err)
+		internal.ConstructRespError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	internal.ConstructRespSucces(w, "transfer", transfer)
+}
@@ -0,0 +1,219 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqAdjustment is entity of inputed adjustment
+// use to make request that will be stored into database
+type ReqAdjustment struct {
+	internal.Adjustment
+	DateRaw string `json:"date_raw"`
+}
+
+// ReqFilterAdjustment is entity to filter adjustment report
+type ReqFilterAdjustment struct {
+	DateStart time.Time
+	DateEnd   time.Time
+}
+
+// error of adjustment request
+var (
+	// ErrInvalidAdjustmentReason is error when reason of adjustment is not supported
+	ErrInvalidAdjustmentReason = errors.New("invalid adjustment reason")
+	// ErrInvalidAdjustmentQuantity is error when adjusted quantity is zero
+	// or positive for reason which only takes product out of stock
+	ErrInvalidAdjustmentQuantity = errors.New("invalid adjustment quantity")
+)
+
+// GetAdjustment is used to get all adjustment with product
+func (mod Module) GetAdjustment(ctx context.Context) ([]internal.AdjustmentWithProduct, error) {
+	adjustments, err := mod.internal.GetAdjustmentWithProduct(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// calculate total
+	for index, adjustment := range adjustments {
+		adjustments[index].Total = adjustment.Cost * int64(adjustment.Quantity)
+	}
+
+	return adjustments, nil
+}
+
+// GetAdjustmentByID is used to get adjustment with product by ID
+func (mod Module) GetAdjustmentByID(ctx context.Context, ID int64) (internal.AdjustmentWithProduct, error) {
+	adjustment, err := mod.internal.GetAdjustmentWithProductByID(ctx, ID)
+	if err != nil {
+		return internal.AdjustmentWithProduct{}, err
+	}
+
+	// calculate total
+	adjustment.Total = adjustment.Cost * int64(adjustment.Quantity)
+
+	return adjustment, nil
+}
+
+// StoreAdjustment is to store manual adjustment of stock into database
+// lost, damaged and sample only take product out of stock,
+// while correction may take product in or out of stock
+func (mod Module) StoreAdjustment(ctx context.Context, reqAdjustment ReqAdjustment) (ID int64, err error) {
+	date := time.Now()
+	if reqAdjustment.DateRaw != "" {
+		// date format: yyyy-MM-dd HH:mm:ss
+		date, err = time.Parse("2006-01-02 15:04:05", reqAdjustment.DateRaw)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	switch reqAdjustment.Reason {
+	case internal.AdjustmentLost, internal.AdjustmentDamaged, internal.AdjustmentSample:
+		if reqAdjustment.Quantity >= 0 {
+			return 0, ErrInvalidAdjustmentQuantity
+		}
+	case internal.AdjustmentCorrection:
+		if reqAdjustment.Quantity == 0 {
+			return 0, ErrInvalidAdjustmentQuantity
+		}
+	default:
+		return 0, ErrInvalidAdjustmentReason
+	}
+
+	product, err := mod.internal.GetProductByID(ctx, reqAdjustment.ProductID)
+	if err != nil {
+		return 0, err
+	}
+
+	if product.ProductID == 0 {
+		return 0, ErrProductNotFound
+	}
+
+	locationID, err := mod.getLocationID(ctx, reqAdjustment.LocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	// adjustment is valued at current unit cost of product
+	cost, err := mod.getProductCost(ctx, product.ProductID)
+	if err != nil {
+		return 0, err
+	}
+
+	adjustment := internal.Adjustment{
+		ProductID:     product.ProductID,
+		LocationID:    locationID,
+		Quantity:      reqAdjustment.Quantity,
+		Reason:        reqAdjustment.Reason,
+		Note:          reqAdjustment.Note,
+		User:          reqAdjustment.User,
+		Cost:          int64(cost),
+		Date:          date,
+		SerialNumbers: reqAdjustment.SerialNumbers,
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	ID, err = mod.storeAdjustment(ctx, tx, adjustment)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
+
+// storeAdjustment is to store adjustment with its stock movement within transaction
+// adjustment which takes product out of stock can't make stock of its location negative
+func (mod Module) storeAdjustment(ctx context.Context, tx *sql.Tx, adjustment internal.Adjustment) (ID int64, err error) {
+	ID, err = mod.internal.StoreAdjustment(ctx, tx, adjustment)
+	if err != nil {
+		return 0, err
+	}
+
+	stockMovement := internal.StockMovement{
+		ProductID:     adjustment.ProductID,
+		Quantity:      adjustment.Quantity,
+		Cost:          adjustment.Cost,
+		ReferenceType: internal.MovementAdjustment,
+		ReferenceID:   ID,
+		Description:   "Penyesuaian Stok (" + adjustment.Reason + ")",
+		LocationID:    adjustment.LocationID,
+		Date:          adjustment.Date,
+	}
+	if adjustment.Note != "" {
+		stockMovement.Description += " " + adjustment.Note
+	}
+
+	err = mod.storeStockMovement(ctx, tx, stockMovement)
+	if err != nil {
+		return 0, err
+	}
+
+	if adjustment.Quantity < 0 {
+		err = mod.checkStockAvailability(ctx, tx, map[productLocation]int{
+			{adjustment.ProductID, adjustment.LocationID}: -adjustment.Quantity,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = mod.storeCostLayer(ctx, tx, stockMovement)
+	if err != nil {
+		return 0, err
+	}
+
+	// product which is taken out of stock is taken from the lots which expire first
+	if adjustment.Quantity < 0 {
+		_, err = mod.consumeLot(ctx, tx, adjustment.ProductID, adjustment.LocationID, -adjustment.Quantity, internal.MovementAdjustment, ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = mod.adjustSerial(ctx, tx, ID, adjustment, stockMovement.Description)
+	if err != nil {
+		return 0, err
+	}
+
+	return ID, nil
+}
+
+// adjustSerial is to take unit of serialized product out of stock by adjustment
+// or to register new unit when the adjustment takes product into stock
+func (mod Module) adjustSerial(ctx context.Context, tx *sql.Tx, ID int64, adjustment internal.Adjustment, description string) error {
+	serialHistory := internal.SerialHistory{
+		ReferenceType: internal.MovementAdjustment,
+		ReferenceID:   ID,
+		LocationID:    adjustment.LocationID,
+		Status:        internal.SerialRemoved,
+		Description:   description,
+		Date:          adjustment.Date,
+	}
+
+	if adjustment.Quantity < 0 {
+		return mod.takeSerial(ctx, tx, adjustment.ProductID, adjustment.LocationID, adjustment.SerialNumbers, -adjustment.Quantity, serialHistory)
+	}
+
+	serialized, err := mod.isSerialized(ctx, tx, adjustment.ProductID, adjustment.SerialNumbers)
+	if err != nil || !serialized {
+		return err
+	}
+
+	serialNumbers, err := sanitizeSerial(adjustment.SerialNumbers, adjustment.Quantity)
+	if err != nil {
+		return err
+	}
+
+	return mod.registerSerial(ctx, tx, internal.Serial{ProductID: adjustment.ProductID}, serialNumbers, serialHistory)
+}
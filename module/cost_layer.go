@@ -0,0 +1,303 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// SyncCostLayer is to build cost layer from stock movement
+// when cost layer has not been built yet (e.g. right after migration)
+func (mod Module) SyncCostLayer(ctx context.Context) error {
+	total, err := mod.internal.CountCostLayer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if total > 0 {
+		return nil
+	}
+
+	return mod.rebuildCostLayer(ctx)
+}
+
+// rebuildCostLayer is to replay all stock movement ordered by date
+// to rebuild cost layer and its consumption
+func (mod Module) rebuildCostLayer(ctx context.Context) error {
+	type documentKey struct {
+		ReferenceType string
+		ReferenceID   int64
+	}
+
+	stockMovements, err := mod.internal.GetNetStockMovement(ctx)
+	if err != nil {
+		return err
+	}
+
+	// updated purchase and order has more than one movement,
+	// so only the net movement of each document is replayed
+	netMovements := make(map[documentKey]internal.StockMovement)
+	for _, stockMovement := range stockMovements {
+		key := documentKey{stockMovement.ReferenceType, stockMovement.ReferenceID}
+		if netMovement, ok := netMovements[key]; ok {
+			stockMovement.Quantity += netMovement.Quantity
+			stockMovement.Date = netMovement.Date
+		}
+		netMovements[key] = stockMovement
+	}
+
+	returnedPurchases, err := mod.getReturnedPurchase(ctx)
+	if err != nil {
+		return err
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = mod.internal.DeleteCostLayer(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	isReplayed := make(map[documentKey]bool)
+	for _, stockMovement := range stockMovements {
+		key := documentKey{stockMovement.ReferenceType, stockMovement.ReferenceID}
+		switch stockMovement.ReferenceType {
+		case internal.MovementPurchase, internal.MovementOrder:
+			if isReplayed[key] {
+				continue
+			}
+			isReplayed[key] = true
+			stockMovement = netMovements[key]
+
+		case internal.MovementPurchaseReturn:
+			err = mod.returnCostLayer(ctx, tx, returnedPurchases[stockMovement.ReferenceID], stockMovement)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			continue
+		}
+
+		err = mod.storeCostLayer(ctx, tx, stockMovement)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// storeCostLayer is to apply stock movement into cost layer
+// stock in creates a layer and stock out consumes the oldest layers
+func (mod Module) storeCostLayer(ctx context.Context, tx *sql.Tx, stockMovement internal.StockMovement) error {
+	var err error
+	switch {
+	case stockMovement.ReferenceType == internal.MovementPurchase:
+		err = mod.syncCostLayer(ctx, tx, internal.CostLayer{
+			ProductID:     stockMovement.ProductID,
+			ReferenceType: stockMovement.ReferenceType,
+			ReferenceID:   stockMovement.ReferenceID,
+			Quantity:      stockMovement.Quantity,
+			Cost:          stockMovement.Cost,
+			Date:          stockMovement.Date,
+		})
+
+	case stockMovement.Quantity > 0:
+		_, err = mod.internal.StoreCostLayer(ctx, tx, internal.CostLayer{
+			ProductID:     stockMovement.ProductID,
+			ReferenceType: stockMovement.ReferenceType,
+			ReferenceID:   stockMovement.ReferenceID,
+			Quantity:      stockMovement.Quantity,
+			Remaining:     stockMovement.Quantity,
+			Cost:          stockMovement.Cost,
+			Date:          stockMovement.Date,
+		})
+
+	case stockMovement.Quantity < 0:
+		_, err = mod.consumeCostLayer(ctx, tx,
+			stockMovement.ProductID,
+			-stockMovement.Quantity,
+			stockMovement.ReferenceType,
+			stockMovement.ReferenceID,
+			stockMovement.Cost,
+		)
+	}
+
+	return err
+}
+
+// syncCostLayer is to make layer of document (e.g. purchase) has the same quantity as the document
+// so updating the document only adds or removes the delta of remaining quantity
+func (mod Module) syncCostLayer(ctx context.Context, tx *sql.Tx, costLayer internal.CostLayer) error {
+	costLayers, err := mod.internal.GetCostLayerByReferenceWithTx(ctx, tx, costLayer.ReferenceType, costLayer.ReferenceID)
+	if err != nil {
+		return err
+	}
+
+	costLayer.Remaining = costLayer.Quantity
+	if len(costLayers) > 0 {
+		prevCostLayer := costLayers[0]
+		costLayer.CostLayerID = prevCostLayer.CostLayerID
+		costLayer.Remaining = prevCostLayer.Remaining + costLayer.Quantity - prevCostLayer.Quantity
+
+		// quantity which has been consumed can't be taken back
+		if costLayer.Remaining < 0 {
+			costLayer.Remaining = 0
+		}
+	}
+
+	// nothing to be layered
+	if costLayer.CostLayerID == 0 && costLayer.Quantity <= 0 {
+		return nil
+	}
+
+	_, err = mod.internal.StoreCostLayer(ctx, tx, costLayer)
+	return err
+}
+
+// consumeCostLayer is to consume the oldest layers of product by document (e.g. order line)
+// it returns total cost of consumed quantity
+// fallbackCost is used when product doesn't have any layer
+func (mod Module) consumeCostLayer(ctx context.Context, tx *sql.Tx, productID int64, quantity int, referenceType string, referenceID int64, fallbackCost int64) (int64, error) {
+	var (
+		totalCost int64
+		lastCost  = fallbackCost
+	)
+
+	costLayers, err := mod.internal.GetCostLayerByProductIDWithTx(ctx, tx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, costLayer := range costLayers {
+		lastCost = costLayer.Cost
+		if quantity == 0 || costLayer.Remaining <= 0 {
+			continue
+		}
+
+		consumed := costLayer.Remaining
+		if consumed > quantity {
+			consumed = quantity
+		}
+
+		err = mod.internal.UpdateCostLayerRemaining(ctx, tx, costLayer.CostLayerID, -consumed)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = mod.internal.StoreCostLayerConsumption(ctx, tx, internal.CostLayerConsumption{
+			CostLayerID:   costLayer.CostLayerID,
+			ProductID:     productID,
+			ReferenceType: referenceType,
+			ReferenceID:   referenceID,
+			Quantity:      consumed,
+			Cost:          costLayer.Cost,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		totalCost += int64(consumed) * costLayer.Cost
+		quantity -= consumed
+	}
+
+	// stock out without available layer (e.g. backorder) is valued at the latest cost
+	if quantity > 0 {
+		_, err = mod.internal.StoreCostLayerConsumption(ctx, tx, internal.CostLayerConsumption{
+			ProductID:     productID,
+			ReferenceType: referenceType,
+			ReferenceID:   referenceID,
+			Quantity:      quantity,
+			Cost:          lastCost,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		totalCost += int64(quantity) * lastCost
+	}
+
+	return totalCost, nil
+}
+
+// returnCostLayer is to take back quantity of purchase return from layer of the returned purchase
+// the rest which has been consumed by other document consumes the oldest layers
+func (mod Module) returnCostLayer(ctx context.Context, tx *sql.Tx, purchaseID int64, stockMovement internal.StockMovement) error {
+	quantity := -stockMovement.Quantity
+
+	costLayers, err := mod.internal.GetCostLayerByReferenceWithTx(ctx, tx, internal.MovementPurchase, purchaseID)
+	if err != nil {
+		return err
+	}
+
+	for _, costLayer := range costLayers {
+		if quantity == 0 || costLayer.Remaining <= 0 {
+			continue
+		}
+
+		taken := costLayer.Remaining
+		if taken > quantity {
+			taken = quantity
+		}
+
+		err = mod.internal.UpdateCostLayerRemaining(ctx, tx, costLayer.CostLayerID, -taken)
+		if err != nil {
+			return err
+		}
+
+		_, err = mod.internal.StoreCostLayerConsumption(ctx, tx, internal.CostLayerConsumption{
+			CostLayerID:   costLayer.CostLayerID,
+			ProductID:     stockMovement.ProductID,
+			ReferenceType: stockMovement.ReferenceType,
+			ReferenceID:   stockMovement.ReferenceID,
+			Quantity:      taken,
+			Cost:          costLayer.Cost,
+		})
+		if err != nil {
+			return err
+		}
+
+		quantity -= taken
+	}
+
+	if quantity == 0 {
+		return nil
+	}
+
+	_, err = mod.consumeCostLayer(ctx, tx,
+		stockMovement.ProductID,
+		quantity,
+		stockMovement.ReferenceType,
+		stockMovement.ReferenceID,
+		stockMovement.Cost,
+	)
+	return err
+}
+
+// releaseCostLayer is to give back quantity of layers which has been consumed by document
+func (mod Module) releaseCostLayer(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) error {
+	costLayerConsumptions, err := mod.internal.GetCostLayerConsumptionByReferenceWithTx(ctx, tx, referenceType, referenceID)
+	if err != nil {
+		return err
+	}
+
+	for _, costLayerConsumption := range costLayerConsumptions {
+		if costLayerConsumption.CostLayerID == 0 {
+			continue
+		}
+
+		err = mod.internal.UpdateCostLayerRemaining(ctx, tx, costLayerConsumption.CostLayerID, costLayerConsumption.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return mod.internal.DeleteCostLayerConsumptionByReference(ctx, tx, referenceType, referenceID)
+}
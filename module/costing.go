@@ -0,0 +1,479 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// costing method which is used to calculate cost of product
+const (
+	// CostingAverage is plain average of purchase cost
+	CostingAverage = "average"
+	// CostingMovingAverage is average cost weighted by quantity
+	// which is updated on each receipt
+	CostingMovingAverage = "moving_average"
+	// CostingFIFO is cost of the oldest layers which are consumed first
+	CostingFIFO = "fifo"
+)
+
+// ErrInvalidCostingMethod is error when requested costing method is not supported
+var ErrInvalidCostingMethod = errors.New("invalid costing method")
+
+// movingAverageCost is entity of moving average cost of one product
+type movingAverageCost struct {
+	Quantity int
+	Cost     float64
+}
+
+// orderProduct is key of product which stock is taken by order
+// order of bundle takes stock of each of its components
+type orderProduct struct {
+	OrderID   int64
+	ProductID int64
+}
+
+// fifoLayer is entity of cost layer which is replayed in memory
+type fifoLayer struct {
+	ReferenceType string
+	ReferenceID   int64
+	Remaining     int
+	Cost          int64
+}
+
+// getCostingMethod is to validate requested costing method
+// active costing method is used when no method is requested
+func (mod Module) getCostingMethod(costingMethod string) (string, error) {
+	if costingMethod == "" {
+		costingMethod = mod.costingMethod
+	}
+
+	switch costingMethod {
+	case CostingAverage, CostingMovingAverage, CostingFIFO:
+		return costingMethod, nil
+	}
+
+	return "", ErrInvalidCostingMethod
+}
+
+// getProductCost is to get current unit cost of product by active costing method
+// cost of bundle is sum of cost of its components
+func (mod Module) getProductCost(ctx context.Context, productID int64) (int, error) {
+	productComponents, err := mod.internal.GetProductComponentByBundleID(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(productComponents) > 0 {
+		var cost int
+		for _, productComponent := range productComponents {
+			componentCost, err := mod.getProductCost(ctx, productComponent.ComponentID)
+			if err != nil {
+				return 0, err
+			}
+			cost += componentCost * productComponent.Quantity
+		}
+		return cost, nil
+	}
+
+	if mod.costingMethod == CostingMovingAverage {
+		stockMovements, err := mod.internal.GetNetStockMovementByProductID(ctx, productID)
+		if err != nil {
+			return 0, err
+		}
+
+		movingAverageCosts := calculateMovingAverageCost(stockMovements, time.Time{})
+		if movingAverageCosts[productID] == nil {
+			return 0, nil
+		}
+		return int(math.Round(movingAverageCosts[productID].Cost)), nil
+	}
+
+	if mod.costingMethod == CostingFIFO {
+		fifoCosts, err := mod.getFIFOCost(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return fifoCosts[productID], nil
+	}
+
+	productAvgValue, err := mod.internal.GetProductAvgValueByProductID(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	return productAvgValue.AverageCost, nil
+}
+
+// getMovingAverageCost is to get moving average cost of all product until dateEnd
+// zero dateEnd means all movement is calculated
+func (mod Module) getMovingAverageCost(ctx context.Context, dateEnd time.Time) (map[int64]*movingAverageCost, error) {
+	stockMovements, err := mod.internal.GetNetStockMovement(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculateMovingAverageCost(stockMovements, dateEnd), nil
+}
+
+// getFIFOCost is to get unit cost of remaining layers of all product
+func (mod Module) getFIFOCost(ctx context.Context) (map[int64]int, error) {
+	costLayers, err := mod.internal.GetAvailableCostLayer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		quantities = make(map[int64]int)
+		values     = make(map[int64]int64)
+		fifoCosts  = make(map[int64]int)
+	)
+	for _, costLayer := range costLayers {
+		quantities[costLayer.ProductID] += costLayer.Remaining
+		values[costLayer.ProductID] += int64(costLayer.Remaining) * costLayer.Cost
+	}
+
+	for productID, quantity := range quantities {
+		fifoCosts[productID] = int(math.Round(float64(values[productID]) / float64(quantity)))
+	}
+
+	return fifoCosts, nil
+}
+
+// getFIFOCostByDate is to get unit cost of remaining layers of all product as of dateEnd
+func (mod Module) getFIFOCostByDate(ctx context.Context, dateEnd time.Time) (map[int64]int, error) {
+	stockMovements, err := mod.internal.GetNetStockMovement(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	returnedPurchases, err := mod.getReturnedPurchase(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculateFIFOCost(stockMovements, returnedPurchases, dateEnd), nil
+}
+
+// getOrderFIFOCost is to get unit cost and value of each order from layers which are consumed by the order
+// consumed layers of order of bundle are layers of its components, so its unit cost is per bundle
+func (mod Module) getOrderFIFOCost(ctx context.Context, orders []internal.Order, componentsByBundle map[int64][]internal.ProductComponent) (map[int64]int, map[int64]int64, error) {
+	costLayerConsumptions, err := mod.internal.GetCostLayerConsumptionByReferenceType(ctx, internal.MovementOrder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		quantities = make(map[int64]int)
+		values     = make(map[int64]int64)
+		fifoCosts  = make(map[int64]int)
+	)
+	for _, costLayerConsumption := range costLayerConsumptions {
+		quantities[costLayerConsumption.ReferenceID] += costLayerConsumption.Quantity
+		values[costLayerConsumption.ReferenceID] += int64(costLayerConsumption.Quantity) * costLayerConsumption.Cost
+	}
+
+	for orderID, quantity := range quantities {
+		fifoCosts[orderID] = int(math.Round(float64(values[orderID]) / float64(quantity)))
+	}
+
+	for _, order := range orders {
+		if len(componentsByBundle[order.ProductID]) > 0 && order.Quantity > 0 {
+			fifoCosts[order.OrderID] = int(math.Round(float64(values[order.OrderID]) / float64(order.Quantity)))
+		}
+	}
+
+	return fifoCosts, values, nil
+}
+
+// getReturnedPurchase is to get purchase ID of each purchase return
+func (mod Module) getReturnedPurchase(ctx context.Context) (map[int64]int64, error) {
+	purchaseReturns, err := mod.internal.GetPurchaseReturnWithPurchase(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	returnedPurchases := make(map[int64]int64)
+	for _, purchaseReturn := range purchaseReturns {
+		returnedPurchases[purchaseReturn.PurchaseReturnID] = purchaseReturn.PurchaseID
+	}
+
+	return returnedPurchases, nil
+}
+
+// getOrderCost is to calculate unit cost and cost of goods sold of each order at the time the order is made
+// cost of order of bundle is sum of cost of its components
+func (mod Module) getOrderCost(ctx context.Context, costingMethod string, orders []internal.Order) (map[int64]int, map[int64]int64, error) {
+	productComponents, err := mod.internal.GetProductComponent(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	componentsByBundle := make(map[int64][]internal.ProductComponent)
+	for _, productComponent := range productComponents {
+		componentsByBundle[productComponent.BundleID] = append(componentsByBundle[productComponent.BundleID], productComponent)
+	}
+
+	var (
+		orderCosts  map[int64]int
+		orderValues map[int64]int64
+	)
+	switch costingMethod {
+	case CostingMovingAverage:
+		orderCosts, err = mod.getOrderMovingAverageCost(ctx, orders, componentsByBundle)
+	case CostingFIFO:
+		orderCosts, orderValues, err = mod.getOrderFIFOCost(ctx, orders, componentsByBundle)
+	default:
+		orderCosts, err = mod.getOrderAverageCostByOrder(ctx, orders, componentsByBundle)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// cost of goods sold is unit cost multiplied by quantity,
+	// except fifo which is value of the consumed layers
+	orderCogs := make(map[int64]int64)
+	for _, order := range orders {
+		value, ok := orderValues[order.OrderID]
+		if !ok {
+			value = int64(orderCosts[order.OrderID]) * int64(order.Quantity)
+		}
+		orderCogs[order.OrderID] = value
+	}
+
+	return orderCosts, orderCogs, nil
+}
+
+// getOrderAverageCostByOrder is to get plain average cost of each order at the time the order is made
+func (mod Module) getOrderAverageCostByOrder(ctx context.Context, orders []internal.Order, componentsByBundle map[int64][]internal.ProductComponent) (map[int64]int, error) {
+	var err error
+	orderCosts := make(map[int64]int)
+	for _, order := range orders {
+		productComponents, ok := componentsByBundle[order.ProductID]
+		if !ok {
+			orderCosts[order.OrderID], err = mod.getOrderAverageCost(ctx, order.ProductID, order.Date)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		orderCosts[order.OrderID] = 0
+		for _, productComponent := range productComponents {
+			componentCost, err := mod.getOrderAverageCost(ctx, productComponent.ComponentID, order.Date)
+			if err != nil {
+				return nil, err
+			}
+			orderCosts[order.OrderID] += componentCost * productComponent.Quantity
+		}
+	}
+
+	return orderCosts, nil
+}
+
+// getOrderMovingAverageCost is to get moving average cost of each order at the time the order is made
+func (mod Module) getOrderMovingAverageCost(ctx context.Context, orders []internal.Order, componentsByBundle map[int64][]internal.ProductComponent) (map[int64]int, error) {
+	stockMovements, err := mod.internal.GetNetStockMovement(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orderProductCosts := calculateOrderMovingAverageCost(stockMovements)
+
+	orderCosts := make(map[int64]int)
+	for _, order := range orders {
+		productComponents, ok := componentsByBundle[order.ProductID]
+		if !ok {
+			orderCosts[order.OrderID] = orderProductCosts[orderProduct{order.OrderID, order.ProductID}]
+			continue
+		}
+
+		for _, productComponent := range productComponents {
+			orderCosts[order.OrderID] += orderProductCosts[orderProduct{order.OrderID, productComponent.ComponentID}] * productComponent.Quantity
+		}
+	}
+
+	return orderCosts, nil
+}
+
+// getOrderAverageCost is to get plain average of purchase cost until the order date
+// order made before any purchase is valued at average of all purchase
+func (mod Module) getOrderAverageCost(ctx context.Context, productID int64, date time.Time) (int, error) {
+	productAvgValue, err := mod.internal.GetProductAvgValueByProductIDAndDate(ctx, productID, date)
+	if err != nil {
+		return 0, err
+	}
+
+	if productAvgValue.AverageCost != 0 {
+		return productAvgValue.AverageCost, nil
+	}
+
+	productAvgValue, err = mod.internal.GetProductAvgValueByProductID(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	return productAvgValue.AverageCost, nil
+}
+
+// calculateMovingAverageCost is to replay stock movements ordered by date
+// to calculate moving average cost of each product
+func calculateMovingAverageCost(stockMovements []internal.StockMovement, dateEnd time.Time) map[int64]*movingAverageCost {
+	movingAverageCosts := make(map[int64]*movingAverageCost)
+	for _, stockMovement := range stockMovements {
+		if dateEnd != (time.Time{}) && stockMovement.Date.After(dateEnd) {
+			break
+		}
+
+		productCost, ok := movingAverageCosts[stockMovement.ProductID]
+		if !ok {
+			productCost = &movingAverageCost{}
+			movingAverageCosts[stockMovement.ProductID] = productCost
+		}
+		productCost.apply(stockMovement)
+	}
+
+	return movingAverageCosts
+}
+
+// calculateFIFOCost is to replay stock movements ordered by date until dateEnd
+// to calculate unit cost of remaining layers of each product
+// returnedPurchases is purchase ID of each purchase return
+func calculateFIFOCost(stockMovements []internal.StockMovement, returnedPurchases map[int64]int64, dateEnd time.Time) map[int64]int {
+	fifoLayers := make(map[int64][]*fifoLayer)
+	for _, stockMovement := range stockMovements {
+		if stockMovement.Date.After(dateEnd) {
+			break
+		}
+
+		quantity := stockMovement.Quantity
+		switch {
+		case quantity > 0:
+			fifoLayers[stockMovement.ProductID] = append(fifoLayers[stockMovement.ProductID], &fifoLayer{
+				ReferenceType: stockMovement.ReferenceType,
+				ReferenceID:   stockMovement.ReferenceID,
+				Remaining:     quantity,
+				Cost:          stockMovement.Cost,
+			})
+
+		case stockMovement.ReferenceType == internal.MovementPurchase:
+			// cancelled receipt only takes back its own layer
+			for _, layer := range fifoLayers[stockMovement.ProductID] {
+				if layer.ReferenceType != stockMovement.ReferenceType || layer.ReferenceID != stockMovement.ReferenceID {
+					continue
+				}
+
+				taken := int(math.Min(float64(layer.Remaining), float64(-quantity)))
+				layer.Remaining -= taken
+				quantity += taken
+			}
+
+		case stockMovement.ReferenceType == internal.MovementPurchaseReturn:
+			// returned purchase takes back its own layer first
+			// and the rest consumes the oldest layers
+			for _, layer := range fifoLayers[stockMovement.ProductID] {
+				if layer.ReferenceType != internal.MovementPurchase || layer.ReferenceID != returnedPurchases[stockMovement.ReferenceID] {
+					continue
+				}
+
+				taken := int(math.Min(float64(layer.Remaining), float64(-quantity)))
+				layer.Remaining -= taken
+				quantity += taken
+			}
+			fallthrough
+
+		default:
+			// other stock out consumes the oldest layers first
+			for _, layer := range fifoLayers[stockMovement.ProductID] {
+				if quantity == 0 {
+					break
+				}
+
+				consumed := int(math.Min(float64(layer.Remaining), float64(-quantity)))
+				layer.Remaining -= consumed
+				quantity += consumed
+			}
+		}
+	}
+
+	fifoCosts := make(map[int64]int)
+	for productID, layers := range fifoLayers {
+		var (
+			quantity int
+			value    int64
+		)
+		for _, layer := range layers {
+			quantity += layer.Remaining
+			value += int64(layer.Remaining) * layer.Cost
+		}
+
+		if quantity > 0 {
+			fifoCosts[productID] = int(math.Round(float64(value) / float64(quantity)))
+		}
+	}
+
+	return fifoCosts
+}
+
+// calculateOrderMovingAverageCost is to replay stock movements ordered by date
+// to get moving average cost of each product which stock is taken by order at the time the order is made
+func calculateOrderMovingAverageCost(stockMovements []internal.StockMovement) map[orderProduct]int {
+	var (
+		movingAverageCosts = make(map[int64]*movingAverageCost)
+		orderCosts         = make(map[orderProduct]int)
+	)
+	for _, stockMovement := range stockMovements {
+		productCost, ok := movingAverageCosts[stockMovement.ProductID]
+		if !ok {
+			productCost = &movingAverageCost{}
+			movingAverageCosts[stockMovement.ProductID] = productCost
+		}
+
+		// updated order has more than one movement, cost is taken from the first one
+		key := orderProduct{stockMovement.ReferenceID, stockMovement.ProductID}
+		_, isCalculated := orderCosts[key]
+		if stockMovement.ReferenceType == internal.MovementOrder && !isCalculated {
+			// order without known cost is valued at cost of its movement
+			orderCosts[key] = int(stockMovement.Cost)
+			if productCost.Cost > 0 {
+				orderCosts[key] = int(math.Round(productCost.Cost))
+			}
+		}
+
+		productCost.apply(stockMovement)
+	}
+
+	return orderCosts
+}
+
+// apply is to apply one stock movement into moving average cost
+func (productCost *movingAverageCost) apply(stockMovement internal.StockMovement) {
+	quantity := stockMovement.Quantity
+	cost := float64(stockMovement.Cost)
+	newQuantity := productCost.Quantity + quantity
+
+	switch {
+	case quantity > 0:
+		// movement without cost comes in at current average cost
+		if stockMovement.Cost == 0 {
+			cost = productCost.Cost
+		}
+
+		// previous cost is meaningless when there was no stock
+		if productCost.Quantity <= 0 {
+			productCost.Cost = cost
+		} else {
+			productCost.Cost = (float64(productCost.Quantity)*productCost.Cost + float64(quantity)*cost) / float64(newQuantity)
+		}
+
+	case (stockMovement.ReferenceType == internal.MovementPurchase || stockMovement.ReferenceType == internal.MovementPurchaseReturn) && newQuantity > 0:
+		// cancelled receipt and returned purchase go out at its own cost
+		productCost.Cost = math.Max((float64(productCost.Quantity)*productCost.Cost+float64(quantity)*cost)/float64(newQuantity), 0)
+	}
+
+	// other stock out goes out at current average cost
+	productCost.Quantity = newQuantity
+}
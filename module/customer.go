@@ -0,0 +1,143 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqCustomer is entity of inputed customer
+// use to make request that will be stored into database
+type ReqCustomer struct {
+	internal.Customer
+}
+
+// error of customer request
+var (
+	// ErrEmptyCustomerName is error when customer doesn't have name
+	ErrEmptyCustomerName = errors.New("customer name is required")
+	// ErrCustomerNotFound is error when requested customer doesn't exist
+	ErrCustomerNotFound = errors.New("customer not found")
+	// ErrCustomerHasOrder is error when deleted customer still has order
+	ErrCustomerHasOrder = errors.New("customer still has order")
+)
+
+// GetCustomer is used to get all customer
+func (mod Module) GetCustomer(ctx context.Context) ([]internal.Customer, error) {
+	return mod.internal.GetCustomer(ctx)
+}
+
+// GetCustomerByID is used to get customer by ID
+func (mod Module) GetCustomerByID(ctx context.Context, ID int64) (internal.Customer, error) {
+	return mod.internal.GetCustomerByID(ctx, ID)
+}
+
+// GetCustomerHistory is used to get customer with all of its orders, the latest first
+func (mod Module) GetCustomerHistory(ctx context.Context, ID int64) (internal.CustomerWithOrder, error) {
+	customer, err := mod.internal.GetCustomerByID(ctx, ID)
+	if err != nil {
+		return internal.CustomerWithOrder{}, err
+	}
+
+	if customer.CustomerID == 0 {
+		return internal.CustomerWithOrder{}, ErrCustomerNotFound
+	}
+
+	orders, err := mod.internal.GetOrderWithProductByCustomerID(ctx, ID)
+	if err != nil {
+		return internal.CustomerWithOrder{}, err
+	}
+
+	// calculate total
+	for index, order := range orders {
+		orders[index].Total = unitTotal(order.UnitPrice, order.Quantity, order.UnitFactor)
+	}
+
+	return internal.CustomerWithOrder{
+		Customer: customer,
+		Orders:   orders,
+	}, nil
+}
+
+// StoreCustomer is to store customer into database
+func (mod Module) StoreCustomer(ctx context.Context, reqCustomer ReqCustomer) (ID int64, err error) {
+	customer := internal.Customer{
+		CustomerID: reqCustomer.CustomerID,
+		Name:       strings.Trim(reqCustomer.Name, " "),
+		Phone:      reqCustomer.Phone,
+		Address:    reqCustomer.Address,
+		Channel:    reqCustomer.Channel,
+	}
+
+	if customer.Name == "" {
+		return 0, ErrEmptyCustomerName
+	}
+
+	if customer.CustomerID != 0 {
+		existingCustomer, err := mod.internal.GetCustomerByID(ctx, customer.CustomerID)
+		if err != nil {
+			return 0, err
+		}
+
+		if existingCustomer.CustomerID == 0 {
+			return 0, ErrCustomerNotFound
+		}
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	ID, err = mod.internal.StoreCustomer(ctx, tx, customer)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = mod.internal.UpdateSalesOrderCustomerName(ctx, tx, ID, customer.Name)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
+
+// DeleteCustomer is to delete customer which doesn't have any order from database
+func (mod Module) DeleteCustomer(ctx context.Context, ID int64) error {
+	customer, err := mod.internal.GetCustomerByID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if customer.TotalOrder > 0 {
+		return ErrCustomerHasOrder
+	}
+
+	return mod.internal.DeleteCustomer(ctx, ID)
+}
+
+// applyCustomer is to validate customer of sales order
+// and copy the name of customer into the sales order
+func (mod Module) applyCustomer(ctx context.Context, salesOrder *internal.SalesOrder) error {
+	if salesOrder.CustomerID == 0 {
+		return nil
+	}
+
+	customer, err := mod.internal.GetCustomerByID(ctx, salesOrder.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	if customer.CustomerID == 0 {
+		return ErrCustomerNotFound
+	}
+
+	salesOrder.Customer = customer.Name
+
+	return nil
+}
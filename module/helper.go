@@ -40,13 +40,18 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 	var arrString [][]string
 
 	mapType := map[string]string{
+		"internal.AdjustmentWithProduct":      "report_adjustment",
 		"internal.Product":                    "product",
 		"internal.PurchaseWithProduct":        "purchase",
 		"internal.OrderWithProduct":           "order",
 		"internal.ProductAvgValue":            "report_product",
+		"internal.StockMovement":              "stock_movement",
 		"module.OrderWithProductValue":        "report_order",
+		"module.OutstandingPurchase":          "report_purchase_outstanding",
+		"module.SummaryAdjustment":            "report_adjustment_summary",
 		"module.SummaryAvgValue":              "report_product_summary",
 		"module.SummaryOrderWithProductValue": "report_order_summary",
+		"module.SummaryOutstandingPurchase":   "report_purchase_outstanding_summary",
 	}
 
 	// internal function
@@ -92,6 +97,7 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 					"Name":  "Nama Item",
 					"Sku":   "SKU",
 					"Stock": "Jumlah Sekarang",
+					"Unit":  "Satuan",
 				}
 
 				if i == 0 {
@@ -106,13 +112,17 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 				}
 				e := reflect.ValueOf(&purchase.Purchase).Elem()
 				mapper := map[string]string{
-					"DateStr":       "Waktu",
-					"OrderQuantity": "Jumlah Pemesanan",
-					"OrderAccepted": "Jumlah Diterima",
-					"Cost":          "Harga Beli",
-					"Total":         "Total",
-					"InvoiceNumber": "Nomer Kuitansi",
-					"Description":   "Catatan",
+					"DateStr":              "Waktu",
+					"QuantityOrder":        "Jumlah Pemesanan",
+					"QuantityAccepted":     "Jumlah Diterima",
+					"Cost":                 "Harga Beli",
+					"Total":                "Total",
+					"InvoiceNumber":        "Nomer Kuitansi",
+					"Description":          "Catatan",
+					"Unit":                 "Satuan Beli",
+					"UnitQuantityOrder":    "Jumlah Pemesanan (Satuan Beli)",
+					"UnitQuantityAccepted": "Jumlah Diterima (Satuan Beli)",
+					"UnitCost":             "Harga Beli (Satuan Beli)",
 				}
 				if i == 0 {
 					column = append(column, extractToRow(e, mapper, 0)...)
@@ -123,6 +133,16 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 				mapper = map[string]string{
 					"Name": "Nama Barang",
 					"Sku":  "SKU",
+					"Unit": "Satuan Dasar",
+				}
+				if i == 0 {
+					column = append(column, extractToRow(e, mapper, 0)...)
+				}
+				row = append(row, extractToRow(e, mapper, 1)...)
+
+				e = reflect.ValueOf(&purchase.Supplier).Elem()
+				mapper = map[string]string{
+					"Name": "Pemasok",
 				}
 				if i == 0 {
 					column = append(column, extractToRow(e, mapper, 0)...)
@@ -136,11 +156,14 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 				}
 				e := reflect.ValueOf(&order.Order).Elem()
 				mapper := map[string]string{
-					"Date":        "Waktu",
-					"Quantity":    "Jumlah Keluar",
-					"Price":       "Harga Jual",
-					"Total":       "Total",
-					"Description": "Catatan",
+					"Date":         "Waktu",
+					"Quantity":     "Jumlah Keluar",
+					"Price":        "Harga Jual",
+					"Total":        "Total",
+					"Description":  "Catatan",
+					"Unit":         "Satuan Jual",
+					"UnitQuantity": "Jumlah Keluar (Satuan Jual)",
+					"UnitPrice":    "Harga Jual (Satuan Jual)",
 				}
 
 				if i == 0 {
@@ -152,6 +175,30 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 				mapper = map[string]string{
 					"Name": "Nama Barang",
 					"Sku":  "SKU",
+					"Unit": "Satuan Dasar",
+				}
+
+				if i == 0 {
+					column = append(column, extractToRow(e, mapper, 0)...)
+				}
+				row = append(row, extractToRow(e, mapper, 1)...)
+
+			case "stock_movement":
+				stockMovement, ok := obj.Interface().(internal.StockMovement)
+				if !ok {
+					return nil
+				}
+
+				e := reflect.ValueOf(&stockMovement).Elem()
+				mapper := map[string]string{
+					"DateStr":       "Waktu",
+					"ReferenceType": "Jenis",
+					"ReferenceID":   "No Referensi",
+					"Description":   "Catatan",
+					"Quantity":      "Jumlah",
+					"Cost":          "Harga",
+					"LocationName":  "Lokasi",
+					"Balance":       "Saldo",
 				}
 
 				if i == 0 {
@@ -207,9 +254,12 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 
 				e = reflect.ValueOf(&reportOrder).Elem()
 				mapper = map[string]string{
-					"AverageCost": "Harga Beli",
-					"Total":       "Total",
-					"Profit":      "Laba",
+					"SalesReturnID": "ID Retur",
+					"AverageCost":   "Harga Beli",
+					"Total":         "Total",
+					"Cogs":          "HPP",
+					"Profit":        "Laba",
+					"MarginPercent": "Margin (%)",
 				}
 
 				if i == 0 {
@@ -228,6 +278,90 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 				}
 				row = append(row, extractToRow(e, mapper, 1)...)
 
+			case "report_purchase_outstanding":
+				reportPurchase, ok := obj.Interface().(OutstandingPurchase)
+				if !ok {
+					return nil
+				}
+				e := reflect.ValueOf(&reportPurchase.Purchase).Elem()
+				mapper := map[string]string{
+					"DateStr":          "Waktu",
+					"InvoiceNumber":    "Nomer Kuitansi",
+					"QuantityOrder":    "Jumlah Pemesanan",
+					"QuantityAccepted": "Jumlah Diterima",
+					"Cost":             "Harga Beli",
+				}
+
+				if i == 0 {
+					column = append(column, extractToRow(e, mapper, 0)...)
+				}
+				row = append(row, extractToRow(e, mapper, 1)...)
+
+				e = reflect.ValueOf(&reportPurchase).Elem()
+				mapper = map[string]string{
+					"QuantityOutstanding": "Belum Diterima",
+					"DaysOutstanding":     "Lama (Hari)",
+					"ValueOutstanding":    "Nilai Belum Diterima",
+				}
+
+				if i == 0 {
+					column = append(column, extractToRow(e, mapper, 0)...)
+				}
+				row = append(row, extractToRow(e, mapper, 1)...)
+
+				e = reflect.ValueOf(&reportPurchase.Product).Elem()
+				mapper = map[string]string{
+					"Name": "Nama Barang",
+					"Sku":  "SKU",
+				}
+
+				if i == 0 {
+					column = append(column, extractToRow(e, mapper, 0)...)
+				}
+				row = append(row, extractToRow(e, mapper, 1)...)
+
+				e = reflect.ValueOf(&reportPurchase.Supplier).Elem()
+				mapper = map[string]string{
+					"Name": "Pemasok",
+				}
+
+				if i == 0 {
+					column = append(column, extractToRow(e, mapper, 0)...)
+				}
+				row = append(row, extractToRow(e, mapper, 1)...)
+
+			case "report_adjustment":
+				reportAdjustment, ok := obj.Interface().(internal.AdjustmentWithProduct)
+				if !ok {
+					return nil
+				}
+				e := reflect.ValueOf(&reportAdjustment.Adjustment).Elem()
+				mapper := map[string]string{
+					"DateStr":  "Waktu",
+					"Reason":   "Alasan",
+					"Quantity": "Jumlah",
+					"Cost":     "Harga Beli",
+					"Total":    "Nilai",
+					"Note":     "Catatan",
+					"User":     "Petugas",
+				}
+
+				if i == 0 {
+					column = append(column, extractToRow(e, mapper, 0)...)
+				}
+				row = append(row, extractToRow(e, mapper, 1)...)
+
+				e = reflect.ValueOf(&reportAdjustment.Product).Elem()
+				mapper = map[string]string{
+					"Name": "Nama Barang",
+					"Sku":  "SKU",
+				}
+
+				if i == 0 {
+					column = append(column, extractToRow(e, mapper, 0)...)
+				}
+				row = append(row, extractToRow(e, mapper, 1)...)
+
 			default:
 				return nil
 			}
@@ -248,9 +382,15 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 
 			var rows [][]string
 			rows = append(rows, []string{"Tanggal Cetak : " + summary.DatePrint})
+			rows = append(rows, []string{"Per Tanggal : " + summary.AsOf})
+			rows = append(rows, []string{"Metode Harga Beli : " + summary.CostingMethod})
 			rows = append(rows, []string{fmt.Sprintf("Jumlah SKU : %d", summary.TotalSku)})
 			rows = append(rows, []string{fmt.Sprintf("Jumlah Total Barang : %d", summary.TotalProduct)})
 			rows = append(rows, []string{fmt.Sprintf("Total Nilai : %d", summary.TotalValue)})
+			for _, location := range summary.Locations {
+				rows = append(rows, []string{fmt.Sprintf("Jumlah Barang %s : %d", location.Name, location.TotalProduct)})
+				rows = append(rows, []string{fmt.Sprintf("Nilai %s : %d", location.Name, location.TotalValue)})
+			}
 
 			arrString = append(arrString, rows...)
 		case "report_order_summary":
@@ -262,10 +402,51 @@ func (mod Module) entityIntoArrayString(entity interface{}) [][]string {
 			var rows [][]string
 			rows = append(rows, []string{"Tanggal Cetak : " + summary.DatePrint})
 			rows = append(rows, []string{"Tanggal : " + summary.Date})
+			rows = append(rows, []string{"Metode Harga Beli : " + summary.CostingMethod})
 			rows = append(rows, []string{fmt.Sprintf("Total Omzet : %d", summary.TotalPrice)})
+			rows = append(rows, []string{fmt.Sprintf("Total Retur : %d", summary.TotalReturn)})
+			rows = append(rows, []string{fmt.Sprintf("Total HPP : %d", summary.TotalCogs)})
 			rows = append(rows, []string{fmt.Sprintf("Laba Kotor : %d", summary.TotalProfit)})
+			rows = append(rows, []string{fmt.Sprintf("Margin (%%) : %.2f", summary.MarginPercent)})
 			rows = append(rows, []string{fmt.Sprintf("Total Penjualan : %d", summary.TotalSold)})
 			rows = append(rows, []string{fmt.Sprintf("Total Barang : %d", summary.TotalItem)})
+			rows = append(rows, []string{fmt.Sprintf("Total Barang Retur : %d", summary.TotalReturnItem)})
+			for _, parent := range summary.Parents {
+				rows = append(rows, []string{fmt.Sprintf("Total Barang %s (%s) : %d", parent.Name, parent.Sku, parent.TotalItem)})
+				rows = append(rows, []string{fmt.Sprintf("Omzet %s (%s) : %d", parent.Name, parent.Sku, parent.TotalPrice)})
+				rows = append(rows, []string{fmt.Sprintf("Laba Kotor %s (%s) : %d", parent.Name, parent.Sku, parent.TotalProfit)})
+			}
+
+			arrString = append(arrString, rows...)
+		case "report_purchase_outstanding_summary":
+			summary, ok := object.Interface().(SummaryOutstandingPurchase)
+			if !ok {
+				return nil
+			}
+
+			var rows [][]string
+			rows = append(rows, []string{"Tanggal Cetak : " + summary.DatePrint})
+			rows = append(rows, []string{fmt.Sprintf("Jumlah Pembelian : %d", summary.TotalPurchase)})
+			rows = append(rows, []string{fmt.Sprintf("Jumlah Barang Belum Diterima : %d", summary.TotalQuantity)})
+			rows = append(rows, []string{fmt.Sprintf("Total Nilai Belum Diterima : %d", summary.TotalValue)})
+
+			arrString = append(arrString, rows...)
+		case "report_adjustment_summary":
+			summary, ok := object.Interface().(SummaryAdjustment)
+			if !ok {
+				return nil
+			}
+
+			var rows [][]string
+			rows = append(rows, []string{"Tanggal Cetak : " + summary.DatePrint})
+			rows = append(rows, []string{"Tanggal : " + summary.Date})
+			rows = append(rows, []string{fmt.Sprintf("Jumlah Penyesuaian : %d", summary.TotalAdjustment)})
+			rows = append(rows, []string{fmt.Sprintf("Jumlah Barang : %d", summary.TotalQuantity)})
+			rows = append(rows, []string{fmt.Sprintf("Nilai Hilang : %d", summary.TotalLost)})
+			rows = append(rows, []string{fmt.Sprintf("Nilai Rusak : %d", summary.TotalDamaged)})
+			rows = append(rows, []string{fmt.Sprintf("Nilai Sampel : %d", summary.TotalSample)})
+			rows = append(rows, []string{fmt.Sprintf("Nilai Koreksi : %d", summary.TotalCorrection)})
+			rows = append(rows, []string{fmt.Sprintf("Total Nilai : %d", summary.TotalValue)})
 
 			arrString = append(arrString, rows...)
 		}
@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// reason of adjustment
+const (
+	AdjustmentLost       = "lost"
+	AdjustmentDamaged    = "damaged"
+	AdjustmentSample     = "sample"
+	AdjustmentCorrection = "correction"
+)
+
+// Adjustment is entity that represent schema on table adjustment
+// Quantity is signed, positive for stock in and negative for stock out
+// StockTakeID is stock take which variance is posted as the adjustment
+// SerialNumbers is unit of serialized product which is taken in or out of stock
+type Adjustment struct {
+	AdjustmentID  int64     `db:"adjustment_id" json:"adjustment_id"`
+	ProductID     int64     `db:"product_id" json:"product_id"`
+	LocationID    int64     `db:"location_id" json:"location_id"`
+	LocationName  string    `db:"location_name" json:"location_name"`
+	Quantity      int       `db:"quantity" json:"quantity"`
+	Reason        string    `db:"reason" json:"reason"`
+	Note          string    `db:"note" json:"note"`
+	User          string    `db:"user_name" json:"user"`
+	Cost          int64     `db:"cost" json:"cost"`
+	StockTakeID   int64     `db:"stock_take_id" json:"stock_take_id"`
+	Date          time.Time `db:"date" json:"-"`
+	DateStr       string    `db:"date_str" json:"date"`
+	Total         int64     `json:"total"`
+	SerialNumbers []string  `json:"serial_numbers,omitempty"`
+}
+
+// AdjustmentWithProduct is entity of adjustment with product
+type AdjustmentWithProduct struct {
+	Adjustment
+	Product Product `json:"product"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectAdjustment = `
+	SELECT
+		adjustment.adjustment_id,
+		adjustment.product_id,
+		adjustment.location_id,
+		COALESCE(location.name, '') as location_name,
+		adjustment.quantity,
+		adjustment.reason,
+		COALESCE(adjustment.note, ''),
+		adjustment.user_name,
+		adjustment.cost,
+		adjustment.stock_take_id,
+		adjustment.date as date_str,
+		product.name,
+		product.sku,
+		product.unit,
+		` + qProductStock + ` as stock
+	FROM adjustment
+	JOIN product ON adjustment.product_id = product.product_id
+	LEFT JOIN location ON adjustment.location_id = location.location_id
+`
+
+// GetAdjustmentWithProduct is used to get all adjustment with product
+func (intr Internal) GetAdjustmentWithProduct(ctx context.Context) ([]AdjustmentWithProduct, error) {
+	query := qSelectAdjustment
+	query += `ORDER BY adjustment.date, adjustment.adjustment_id
+	`
+
+	return intr.selectAdjustmentWithProduct(ctx, query)
+}
+
+// GetAdjustmentWithProductByDate is used to get all adjustment with product by filter date
+func (intr Internal) GetAdjustmentWithProductByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]AdjustmentWithProduct, error) {
+	query := qSelectAdjustment
+	query += `WHERE
+		adjustment.date > ? AND adjustment.date <= ?
+	ORDER BY adjustment.date, adjustment.adjustment_id
+	`
+
+	dateStartMidnight := time.Date(
+		dateStart.Year(),
+		time.Month(dateStart.Month()),
+		dateStart.Day(),
+		0, 0, 0, 0, time.UTC,
+	)
+
+	dateEndMidnight := time.Date(
+		dateEnd.Year(),
+		time.Month(dateEnd.Month()),
+		dateEnd.Day(),
+		23, 59, 59, 0, time.UTC,
+	)
+
+	return intr.selectAdjustmentWithProduct(ctx, query, dateStartMidnight, dateEndMidnight)
+}
+
+// GetAdjustmentWithProductByID is used to get adjustment with product by ID
+func (intr Internal) GetAdjustmentWithProductByID(ctx context.Context, ID int64) (AdjustmentWithProduct, error) {
+	query := qSelectAdjustment
+	query += `WHERE
+		adjustment.adjustment_id = ?
+	`
+
+	adjustments, err := intr.selectAdjustmentWithProduct(ctx, query, ID)
+	if err != nil || len(adjustments) == 0 {
+		return AdjustmentWithProduct{}, err
+	}
+
+	return adjustments[0], nil
+}
+
+func (intr Internal) selectAdjustmentWithProduct(ctx context.Context, query string, args ...interface{}) ([]AdjustmentWithProduct, error) {
+	var adjustments []AdjustmentWithProduct
+
+	db := intr.Storage.DB
+	row, err := db.QueryxContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for row.Next() {
+		adjustment := AdjustmentWithProduct{}
+		err = row.Scan(
+			&adjustment.AdjustmentID,
+			&adjustment.ProductID,
+			&adjustment.LocationID,
+			&adjustment.LocationName,
+			&adjustment.Quantity,
+			&adjustment.Reason,
+			&adjustment.Note,
+			&adjustment.User,
+			&adjustment.Cost,
+			&adjustment.StockTakeID,
+			&adjustment.DateStr,
+			&adjustment.Product.Name,
+			&adjustment.Product.Sku,
+			&adjustment.Product.Unit,
+			&adjustment.Product.Stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		adjustment.Product.ProductID = adjustment.ProductID
+
+		// convert date string into date time.Time
+		// spit date string to remove character +00:00
+		// date format: yyyy-MM-dd HH:mm:ss
+		splitDateStr := strings.Split(adjustment.DateStr, "+")
+		DateStr := strings.Trim(splitDateStr[0], " ")
+		adjustment.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		// using date format: yyyy-MM-dd HH:mm:ss
+		// to standarize date convenient
+		adjustment.DateStr = adjustment.Date.Format("2006-01-02 15:04:05")
+
+		adjustments = append(adjustments, adjustment)
+	}
+
+	return adjustments, nil
+}
+
+// StoreAdjustment is to store adjustment into database
+func (intr Internal) StoreAdjustment(ctx context.Context, tx *sql.Tx, adjustment Adjustment) (ID int64, err error) {
+	query := `INSERT INTO adjustment
+					(
+						product_id,
+						location_id,
+						quantity,
+						reason,
+						note,
+						user_name,
+						cost,
+						stock_take_id,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		adjustment.ProductID,
+		adjustment.LocationID,
+		adjustment.Quantity,
+		adjustment.Reason,
+		adjustment.Note,
+		adjustment.User,
+		adjustment.Cost,
+		adjustment.StockTakeID,
+		adjustment.Date,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
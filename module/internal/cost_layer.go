@@ -0,0 +1,329 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// CostLayer is entity that represent schema on table cost_layer
+type CostLayer struct {
+	CostLayerID   int64     `db:"cost_layer_id" json:"cost_layer_id"`
+	ProductID     int64     `db:"product_id" json:"product_id"`
+	ReferenceType string    `db:"reference_type" json:"reference_type"`
+	ReferenceID   int64     `db:"reference_id" json:"reference_id"`
+	Quantity      int       `db:"quantity" json:"quantity"`
+	Remaining     int       `db:"remaining" json:"remaining"`
+	Cost          int64     `db:"cost" json:"cost"`
+	Date          time.Time `db:"date" json:"-"`
+	DateStr       string    `db:"date_str" json:"date"`
+}
+
+// CostLayerConsumption is entity that represent schema on table cost_layer_consumption
+type CostLayerConsumption struct {
+	CostLayerConsumptionID int64  `db:"cost_layer_consumption_id" json:"cost_layer_consumption_id"`
+	CostLayerID            int64  `db:"cost_layer_id" json:"cost_layer_id"`
+	ProductID              int64  `db:"product_id" json:"product_id"`
+	ReferenceType          string `db:"reference_type" json:"reference_type"`
+	ReferenceID            int64  `db:"reference_id" json:"reference_id"`
+	Quantity               int    `db:"quantity" json:"quantity"`
+	Cost                   int64  `db:"cost" json:"cost"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectCostLayer = `
+	SELECT
+		cost_layer_id,
+		product_id,
+		reference_type,
+		reference_id,
+		quantity,
+		remaining,
+		cost,
+		date as date_str
+	FROM cost_layer
+`
+
+var qSelectCostLayerConsumption = `
+	SELECT
+		cost_layer_consumption_id,
+		cost_layer_id,
+		product_id,
+		reference_type,
+		reference_id,
+		quantity,
+		cost
+	FROM cost_layer_consumption
+`
+
+// GetAvailableCostLayer is used to get all cost layer which still has remaining quantity
+func (intr Internal) GetAvailableCostLayer(ctx context.Context) ([]CostLayer, error) {
+	var (
+		costLayers []CostLayer
+		query      string
+	)
+
+	query = qSelectCostLayer
+	query += `WHERE
+				remaining > 0
+			ORDER BY date, cost_layer_id
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &costLayers, query)
+	return costLayers, err
+}
+
+// GetCostLayerByProductIDWithTx is used to get all cost layer of product within transaction
+// ordered from the oldest layer
+func (intr Internal) GetCostLayerByProductIDWithTx(ctx context.Context, tx *sql.Tx, productID int64) ([]CostLayer, error) {
+	query := qSelectCostLayer
+	query += `WHERE
+				product_id = ?
+			ORDER BY date, cost_layer_id
+			`
+
+	return intr.selectCostLayerWithTx(ctx, tx, query, productID)
+}
+
+// GetCostLayerByReferenceWithTx is used to get cost layer which is created by document within transaction
+func (intr Internal) GetCostLayerByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]CostLayer, error) {
+	query := qSelectCostLayer
+	query += `WHERE
+				reference_type = ? AND reference_id = ?
+			ORDER BY date, cost_layer_id
+			`
+
+	return intr.selectCostLayerWithTx(ctx, tx, query, referenceType, referenceID)
+}
+
+func (intr Internal) selectCostLayerWithTx(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]CostLayer, error) {
+	var costLayers []CostLayer
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		costLayer := CostLayer{}
+		err = rows.Scan(
+			&costLayer.CostLayerID,
+			&costLayer.ProductID,
+			&costLayer.ReferenceType,
+			&costLayer.ReferenceID,
+			&costLayer.Quantity,
+			&costLayer.Remaining,
+			&costLayer.Cost,
+			&costLayer.DateStr,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		costLayers = append(costLayers, costLayer)
+	}
+
+	return costLayers, rows.Err()
+}
+
+// CountCostLayer is used to count all cost layer
+func (intr Internal) CountCostLayer(ctx context.Context) (int, error) {
+	var total int
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &total, "SELECT COUNT(*) FROM cost_layer")
+	return total, err
+}
+
+// StoreCostLayer is to store cost layer into database
+func (intr Internal) StoreCostLayer(ctx context.Context, tx *sql.Tx, costLayer CostLayer) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO cost_layer
+					(
+						product_id,
+						reference_type,
+						reference_id,
+						quantity,
+						remaining,
+						cost,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+	args = append(args,
+		costLayer.ProductID,
+		costLayer.ReferenceType,
+		costLayer.ReferenceID,
+		costLayer.Quantity,
+		costLayer.Remaining,
+		costLayer.Cost,
+		costLayer.Date,
+	)
+	if costLayer.CostLayerID != 0 {
+		query = `UPDATE cost_layer
+				 SET
+						product_id = ?,
+						reference_type = ?,
+						reference_id = ?,
+						quantity = ?,
+						remaining = ?,
+						cost = ?,
+						date = ?
+				WHERE
+						cost_layer_id = ?
+		`
+		args = append(args, costLayer.CostLayerID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if costLayer.CostLayerID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		costLayer.CostLayerID, _ = result.LastInsertId()
+	}
+
+	return costLayer.CostLayerID, nil
+}
+
+// UpdateCostLayerRemaining is to add quantity into remaining of cost layer
+// use negative quantity to consume the layer
+func (intr Internal) UpdateCostLayerRemaining(ctx context.Context, tx *sql.Tx, ID int64, quantity int) error {
+	query := `UPDATE cost_layer
+			  SET
+					remaining = remaining + ?
+			  WHERE
+					cost_layer_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, quantity, ID)
+	return err
+}
+
+// DeleteCostLayer is to delete all cost layer and its consumption
+// so the layer can be rebuilt from stock movement
+func (intr Internal) DeleteCostLayer(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM cost_layer_consumption")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM cost_layer")
+	return err
+}
+
+// GetCostLayerConsumptionByReferenceType is used to get all consumption of cost layer by type of document
+func (intr Internal) GetCostLayerConsumptionByReferenceType(ctx context.Context, referenceType string) ([]CostLayerConsumption, error) {
+	var (
+		costLayerConsumptions []CostLayerConsumption
+		query                 string
+	)
+
+	query = qSelectCostLayerConsumption
+	query += `WHERE
+				reference_type = ?
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &costLayerConsumptions, db.Rebind(query), referenceType)
+	return costLayerConsumptions, err
+}
+
+// GetCostLayerConsumptionByReferenceWithTx is used to get consumption of cost layer by document within transaction
+func (intr Internal) GetCostLayerConsumptionByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]CostLayerConsumption, error) {
+	var costLayerConsumptions []CostLayerConsumption
+
+	query := qSelectCostLayerConsumption
+	query += `WHERE
+				reference_type = ? AND reference_id = ?
+			`
+
+	rows, err := tx.QueryContext(ctx, query, referenceType, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		costLayerConsumption := CostLayerConsumption{}
+		err = rows.Scan(
+			&costLayerConsumption.CostLayerConsumptionID,
+			&costLayerConsumption.CostLayerID,
+			&costLayerConsumption.ProductID,
+			&costLayerConsumption.ReferenceType,
+			&costLayerConsumption.ReferenceID,
+			&costLayerConsumption.Quantity,
+			&costLayerConsumption.Cost,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		costLayerConsumptions = append(costLayerConsumptions, costLayerConsumption)
+	}
+
+	return costLayerConsumptions, rows.Err()
+}
+
+// StoreCostLayerConsumption is to store consumption of cost layer into database
+func (intr Internal) StoreCostLayerConsumption(ctx context.Context, tx *sql.Tx, costLayerConsumption CostLayerConsumption) (ID int64, err error) {
+	query := `INSERT INTO cost_layer_consumption
+					(
+						cost_layer_id,
+						product_id,
+						reference_type,
+						reference_id,
+						quantity,
+						cost
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		costLayerConsumption.CostLayerID,
+		costLayerConsumption.ProductID,
+		costLayerConsumption.ReferenceType,
+		costLayerConsumption.ReferenceID,
+		costLayerConsumption.Quantity,
+		costLayerConsumption.Cost,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
+
+// DeleteCostLayerConsumptionByReference is to delete consumption of cost layer by document
+func (intr Internal) DeleteCostLayerConsumptionByReference(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) error {
+	query := `DELETE FROM cost_layer_consumption
+			  WHERE
+				  reference_type = ? AND reference_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, referenceType, referenceID)
+	return err
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Customer is entity that represent schema on table customer
+// TotalOrder, TotalItem and Revenue is lifetime summary of its orders
+type Customer struct {
+	CustomerID int64  `db:"customer_id" json:"customer_id"`
+	Name       string `db:"name" json:"customer_name"`
+	Phone      string `db:"phone" json:"phone"`
+	Address    string `db:"address" json:"address"`
+	Channel    string `db:"channel" json:"channel"`
+	TotalOrder int    `db:"total_order" json:"total_order"`
+	TotalItem  int    `db:"total_item" json:"total_item"`
+	Revenue    int64  `db:"revenue" json:"revenue"`
+}
+
+// CustomerWithOrder is entity of customer with history of its orders
+type CustomerWithOrder struct {
+	Customer
+	Orders []OrderWithProduct `json:"orders"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectCustomer = `
+	SELECT
+		customer.customer_id,
+		customer.name,
+		customer.phone,
+		COALESCE(customer.address, '') as address,
+		customer.channel,
+		COUNT(DISTINCT orders.sales_order_id) as total_order,
+		COALESCE(SUM(orders.quantity), 0) as total_item,
+		COALESCE(SUM(COALESCE(orders.unit_price * orders.quantity / orders.unit_factor, orders.price * orders.quantity)), 0) as revenue
+	FROM customer
+	LEFT JOIN orders ON customer.customer_id = orders.customer_id
+`
+
+// GetCustomer is used to get all customer
+func (intr Internal) GetCustomer(ctx context.Context) ([]Customer, error) {
+	var (
+		customers []Customer
+		query     string
+	)
+
+	query = qSelectCustomer
+	query += `GROUP BY customer.customer_id
+			ORDER BY customer.name
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &customers, query)
+	return customers, err
+}
+
+// GetCustomerByID is used to get customer by ID
+func (intr Internal) GetCustomerByID(ctx context.Context, ID int64) (Customer, error) {
+	var customer Customer
+
+	query := qSelectCustomer
+	query += `WHERE
+				customer.customer_id = ?
+			GROUP BY customer.customer_id
+			`
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &customer, db.Rebind(query), ID)
+
+	// keep returning value but with empty struct
+	// since no rows is not error in a system
+	if err == sql.ErrNoRows {
+		return Customer{}, nil
+	}
+
+	return customer, err
+}
+
+// StoreCustomer is to store customer into database
+func (intr Internal) StoreCustomer(ctx context.Context, tx *sql.Tx, customer Customer) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO customer
+					(
+						name,
+						phone,
+						address,
+						channel
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?
+					)
+			`
+	args = append(args,
+		customer.Name,
+		customer.Phone,
+		customer.Address,
+		customer.Channel,
+	)
+	if customer.CustomerID != 0 {
+		query = `UPDATE customer
+				 SET
+						name = ?,
+						phone = ?,
+						address = ?,
+						channel = ?
+				WHERE
+						customer_id = ?
+		`
+		args = append(args, customer.CustomerID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if customer.CustomerID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		customer.CustomerID, _ = result.LastInsertId()
+	}
+
+	return customer.CustomerID, nil
+}
+
+// UpdateSalesOrderCustomerName is to copy name of customer into its sales order
+// so sales order shows the latest name of customer
+func (intr Internal) UpdateSalesOrderCustomerName(ctx context.Context, tx *sql.Tx, customerID int64, name string) error {
+	query := `UPDATE sales_order
+			  SET
+					customer = ?
+			  WHERE
+					customer_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, name, customerID)
+	return err
+}
+
+// DeleteCustomer is to delete customer from database by single ID
+func (intr Internal) DeleteCustomer(ctx context.Context, ID int64) error {
+	query := `DELETE FROM customer
+			  WHERE
+				  customer_id = ?
+			 `
+	db := intr.Storage.DB
+
+	_, err := db.ExecContext(ctx, query, ID)
+	return err
+}
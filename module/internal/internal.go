@@ -16,25 +16,183 @@ type Iinternal interface {
 	// Product function
 	GetProduct(ctx context.Context) ([]Product, error)
 	GetProductByID(ctx context.Context, ID int64) (Product, error)
+	GetProductByIDWithTx(ctx context.Context, tx *sql.Tx, ID int64) (Product, error)
+	GetProductBySkuWithTx(ctx context.Context, tx *sql.Tx, sku string) (Product, error)
+	GetProductByParentID(ctx context.Context, parentID int64) ([]Product, error)
 	StoreProduct(ctx context.Context, tx *sql.Tx, product Product) (ID int64, err error)
+	UpdateProductParentID(ctx context.Context, tx *sql.Tx, ID, parentID int64) error
+	UnlinkProductByParentID(ctx context.Context, tx *sql.Tx, parentID int64) error
 	DeleteProduct(ctx context.Context, ID int64) error
 
+	// Product Component Function
+	GetProductComponent(ctx context.Context) ([]ProductComponent, error)
+	GetProductComponentByBundleID(ctx context.Context, bundleID int64) ([]ProductComponent, error)
+	StoreProductComponent(ctx context.Context, tx *sql.Tx, productComponent ProductComponent) error
+	DeleteProductComponentByBundleID(ctx context.Context, tx *sql.Tx, bundleID int64) error
+	CountProductComponentByComponentID(ctx context.Context, componentID int64) (int, error)
+	GetProductUnitByProductID(ctx context.Context, productID int64) ([]ProductUnit, error)
+	StoreProductUnit(ctx context.Context, tx *sql.Tx, productUnit ProductUnit) error
+	DeleteProductUnitByProductID(ctx context.Context, tx *sql.Tx, productID int64) error
+
+	// Product Parent Function
+	GetProductParent(ctx context.Context) ([]ProductParent, error)
+	GetProductParentByID(ctx context.Context, ID int64) (ProductParent, error)
+	GetProductParentBySku(ctx context.Context, sku string) (ProductParent, error)
+	StoreProductParent(ctx context.Context, tx *sql.Tx, productParent ProductParent) (ID int64, err error)
+	DeleteProductParent(ctx context.Context, tx *sql.Tx, ID int64) error
+	GetProductAttributeByProductID(ctx context.Context, productID int64) ([]ProductAttribute, error)
+	GetProductAttributeByParentID(ctx context.Context, parentID int64) ([]ProductAttribute, error)
+	StoreProductAttribute(ctx context.Context, tx *sql.Tx, productAttribute ProductAttribute) error
+
 	// Purchase Function
 	GetPurchaseWithProduct(ctx context.Context) ([]PurchaseWithProduct, error)
 	GetPurchaseWithProductByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]PurchaseWithProduct, error)
+	GetPurchaseWithProductBySupplierID(ctx context.Context, supplierID int64, dateStart, dateEnd time.Time) ([]PurchaseWithProduct, error)
 	GetPurchaseWithProductByID(ctx context.Context, ID int64) (PurchaseWithProduct, error)
+	GetOutstandingPurchaseWithProduct(ctx context.Context) ([]PurchaseWithProduct, error)
 	StorePurchase(ctx context.Context, tx *sql.Tx, purchase Purchase) (ID int64, err error)
 	StorePurchaseDtl(ctx context.Context, tx *sql.Tx, purchaseDtl PurchaseDtl) (ID int64, err error)
+	GetPurchaseDtlByPurchaseID(ctx context.Context, purchaseID int64) ([]PurchaseDtl, error)
+	GetPurchaseDtlByIDWithTx(ctx context.Context, tx *sql.Tx, ID int64) (PurchaseDtl, error)
+	SumPurchaseDtlQuantityWithTx(ctx context.Context, tx *sql.Tx, purchaseID int64) (int, error)
+	SumPurchaseDtlQuantityByLocationWithTx(ctx context.Context, tx *sql.Tx, purchaseID int64) (map[int64]int, error)
+	UpdatePurchaseAccepted(ctx context.Context, tx *sql.Tx, ID int64, quantityAccepted int, isFinish bool) error
+
+	// Supplier Function
+	GetSupplier(ctx context.Context) ([]Supplier, error)
+	GetSupplierByID(ctx context.Context, ID int64) (Supplier, error)
+	GetSupplierByName(ctx context.Context, name string) (Supplier, error)
+	StoreSupplier(ctx context.Context, tx *sql.Tx, supplier Supplier) (ID int64, err error)
+	DeleteSupplier(ctx context.Context, ID int64) error
 
 	// Order Function
 	GetOrderWithProduct(ctx context.Context) ([]OrderWithProduct, error)
 	GetOrderWithProductByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]OrderWithProduct, error)
 	GetOrderWithProductByID(ctx context.Context, ID int64) (OrderWithProduct, error)
+	GetOrderWithProductBySalesOrderID(ctx context.Context, salesOrderID int64) ([]OrderWithProduct, error)
+	GetOrderWithProductByCustomerID(ctx context.Context, customerID int64) ([]OrderWithProduct, error)
+	CountOrderByProductID(ctx context.Context, productID int64) (int, error)
+	GetOrderWithoutCost(ctx context.Context) ([]Order, error)
 	StoreOrder(ctx context.Context, tx *sql.Tx, order Order) (ID int64, err error)
+	UpdateOrderCost(ctx context.Context, tx *sql.Tx, ID int64, cost int64, cogs int64) error
+	UpdateOrderCustomerBySalesOrderID(ctx context.Context, tx *sql.Tx, salesOrderID int64, customerID int64) error
+	DeleteOrder(ctx context.Context, tx *sql.Tx, ID int64) error
+
+	// Sales Order Function
+	GetSalesOrder(ctx context.Context) ([]SalesOrder, error)
+	GetSalesOrderByID(ctx context.Context, ID int64) (SalesOrder, error)
+	GetSalesOrderByOrderNumber(ctx context.Context, orderNumber string) (SalesOrder, error)
+	StoreSalesOrder(ctx context.Context, tx *sql.Tx, salesOrder SalesOrder) (ID int64, err error)
+	DeleteSalesOrderWithoutLine(ctx context.Context, tx *sql.Tx, ID int64) error
+
+	// Sales Return Function
+	GetSalesReturnWithOrder(ctx context.Context) ([]SalesReturnWithOrder, error)
+	GetSalesReturnWithOrderByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]SalesReturnWithOrder, error)
+	GetSalesReturnWithOrderByID(ctx context.Context, ID int64) (SalesReturnWithOrder, error)
+	SumSalesReturnQuantityByOrderIDWithTx(ctx context.Context, tx *sql.Tx, orderID int64) (int, error)
+	StoreSalesReturn(ctx context.Context, tx *sql.Tx, salesReturn SalesReturn) (ID int64, err error)
+
+	// Purchase Return Function
+	GetPurchaseReturnWithPurchase(ctx context.Context) ([]PurchaseReturnWithPurchase, error)
+	GetPurchaseReturnWithPurchaseByPurchaseID(ctx context.Context, purchaseID int64) ([]PurchaseReturnWithPurchase, error)
+	GetPurchaseReturnWithPurchaseByID(ctx context.Context, ID int64) (PurchaseReturnWithPurchase, error)
+	SumPurchaseReturnQuantityWithTx(ctx context.Context, tx *sql.Tx, purchaseID, purchaseDtlID int64) (int, error)
+	StorePurchaseReturn(ctx context.Context, tx *sql.Tx, purchaseReturn PurchaseReturn) (ID int64, err error)
+
+	// Adjustment Function
+	GetAdjustmentWithProduct(ctx context.Context) ([]AdjustmentWithProduct, error)
+	GetAdjustmentWithProductByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]AdjustmentWithProduct, error)
+	GetAdjustmentWithProductByID(ctx context.Context, ID int64) (AdjustmentWithProduct, error)
+	StoreAdjustment(ctx context.Context, tx *sql.Tx, adjustment Adjustment) (ID int64, err error)
+
+	// Stock Take Function
+	GetStockTake(ctx context.Context) ([]StockTake, error)
+	GetStockTakeByStatus(ctx context.Context, status string) ([]StockTake, error)
+	GetStockTakeByStatusAndLocationID(ctx context.Context, status string, locationID int64) ([]StockTake, error)
+	GetStockTakeByID(ctx context.Context, ID int64) (StockTake, error)
+	GetStockTakeDetailByStockTakeID(ctx context.Context, stockTakeID int64) ([]StockTakeDetail, error)
+	StoreStockTake(ctx context.Context, tx *sql.Tx, stockTake StockTake) (ID int64, err error)
+	SnapshotStockTakeDetail(ctx context.Context, tx *sql.Tx, stockTakeID, locationID int64) error
+	UpdateStockTakeDetailCount(ctx context.Context, tx *sql.Tx, stockTakeID, productID int64, quantity int) error
+	DeleteStockTake(ctx context.Context, tx *sql.Tx, ID int64) error
+
+	// Location Function
+	GetLocation(ctx context.Context) ([]Location, error)
+	GetLocationByID(ctx context.Context, ID int64) (Location, error)
+	GetLocationByName(ctx context.Context, name string) (Location, error)
+	GetDamagedLocation(ctx context.Context) (Location, error)
+	CountStockMovementByLocationID(ctx context.Context, locationID int64) (int, error)
+	GetProductLocationStock(ctx context.Context, dateEnd time.Time) ([]ProductLocationStock, error)
+	GetProductLocationStockByProductID(ctx context.Context, productID int64) ([]ProductLocationStock, error)
+	GetProductLocationStockByLocationID(ctx context.Context, locationID int64) ([]ProductLocationStock, error)
+	GetProductLocationStockWithTx(ctx context.Context, tx *sql.Tx, productID, locationID int64) (int, error)
+	StoreLocation(ctx context.Context, tx *sql.Tx, location Location) (ID int64, err error)
+	DeleteLocation(ctx context.Context, ID int64) error
+
+	// Transfer Function
+	GetTransfer(ctx context.Context) ([]Transfer, error)
+	GetTransferByID(ctx context.Context, ID int64) (Transfer, error)
+	GetTransferDetailByTransferID(ctx context.Context, transferID int64) ([]TransferDetail, error)
+	StoreTransfer(ctx context.Context, tx *sql.Tx, transfer Transfer) (ID int64, err error)
+	StoreTransferDetail(ctx context.Context, tx *sql.Tx, transferDetail TransferDetail) (ID int64, err error)
+
+	// Customer Function
+	GetCustomer(ctx context.Context) ([]Customer, error)
+	GetCustomerByID(ctx context.Context, ID int64) (Customer, error)
+	StoreCustomer(ctx context.Context, tx *sql.Tx, customer Customer) (ID int64, err error)
+	UpdateSalesOrderCustomerName(ctx context.Context, tx *sql.Tx, customerID int64, name string) error
+	DeleteCustomer(ctx context.Context, ID int64) error
+
+	// Stock movement function
+	GetStockMovement(ctx context.Context) ([]StockMovement, error)
+	GetStockMovementByProductID(ctx context.Context, productID int64) ([]StockMovement, error)
+	GetNetStockMovement(ctx context.Context) ([]StockMovement, error)
+	GetNetStockMovementByProductID(ctx context.Context, productID int64) ([]StockMovement, error)
+	GetStockMovementBalanceByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]StockMovement, error)
+	StoreStockMovement(ctx context.Context, tx *sql.Tx, stockMovement StockMovement) (ID int64, err error)
+
+	// Cost layer function
+	GetAvailableCostLayer(ctx context.Context) ([]CostLayer, error)
+	GetCostLayerByProductIDWithTx(ctx context.Context, tx *sql.Tx, productID int64) ([]CostLayer, error)
+	GetCostLayerByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]CostLayer, error)
+	CountCostLayer(ctx context.Context) (int, error)
+	StoreCostLayer(ctx context.Context, tx *sql.Tx, costLayer CostLayer) (ID int64, err error)
+	UpdateCostLayerRemaining(ctx context.Context, tx *sql.Tx, ID int64, quantity int) error
+	DeleteCostLayer(ctx context.Context, tx *sql.Tx) error
+	GetCostLayerConsumptionByReferenceType(ctx context.Context, referenceType string) ([]CostLayerConsumption, error)
+	GetCostLayerConsumptionByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]CostLayerConsumption, error)
+	StoreCostLayerConsumption(ctx context.Context, tx *sql.Tx, costLayerConsumption CostLayerConsumption) (ID int64, err error)
+	DeleteCostLayerConsumptionByReference(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) error
+
+	// Lot function
+	GetAvailableLotWithProduct(ctx context.Context) ([]LotWithProduct, error)
+	GetAvailableLotWithProductByProductID(ctx context.Context, productID int64) ([]LotWithProduct, error)
+	GetExpiringLotWithProduct(ctx context.Context, date string) ([]LotWithProduct, error)
+	GetAvailableLotByLocationWithTx(ctx context.Context, tx *sql.Tx, productID, locationID int64) ([]Lot, error)
+	GetLotByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]Lot, error)
+	StoreLot(ctx context.Context, tx *sql.Tx, lot Lot) (ID int64, err error)
+	UpdateLotRemaining(ctx context.Context, tx *sql.Tx, ID int64, quantity int) error
+	GetLotAllocationByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]LotAllocation, error)
+	StoreLotAllocation(ctx context.Context, tx *sql.Tx, lotAllocation LotAllocation) (ID int64, err error)
+	DeleteLotAllocationByReference(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) error
+
+	// Serial function
+	GetSerialWithProductBySerialNumber(ctx context.Context, serialNumber string) (SerialWithProduct, error)
+	GetAvailableSerialByProductID(ctx context.Context, productID int64) ([]Serial, error)
+	GetSerialBySerialNumberWithTx(ctx context.Context, tx *sql.Tx, serialNumber string) (Serial, error)
+	GetSerialByPurchaseDtlIDWithTx(ctx context.Context, tx *sql.Tx, purchaseDtlID int64) ([]Serial, error)
+	StoreSerial(ctx context.Context, tx *sql.Tx, serial Serial) (ID int64, err error)
+	DeleteSerial(ctx context.Context, tx *sql.Tx, ID int64) error
+	GetSerialHistoryBySerialID(ctx context.Context, serialID int64) ([]SerialHistory, error)
+	GetSerialHistoryByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]SerialHistory, error)
+	StoreSerialHistory(ctx context.Context, tx *sql.Tx, serialHistory SerialHistory) (ID int64, err error)
+	DeleteSerialHistory(ctx context.Context, tx *sql.Tx, ID int64) error
 
 	// Report function
 	GetProductAvgValue(ctx context.Context) ([]ProductAvgValue, error)
+	GetProductAvgValueByDate(ctx context.Context, dateEnd time.Time) ([]ProductAvgValue, error)
 	GetProductAvgValueByProductID(ctx context.Context, productID int64) (ProductAvgValue, error)
+	GetProductAvgValueByProductIDAndDate(ctx context.Context, productID int64, dateEnd time.Time) (ProductAvgValue, error)
 }
 
 // Internal is entity of package internal
@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DefaultLocationID is location which keeps stock recorded before location is introduced
+// and stock of document which doesn't mention its location
+const DefaultLocationID int64 = 1
+
+// Location is entity that represent schema on table location
+// TotalStock is stock of all product which is kept on the location
+// IsDamaged marks location which keeps returned item in damaged condition
+type Location struct {
+	LocationID  int64  `db:"location_id" json:"location_id"`
+	Name        string `db:"name" json:"location_name"`
+	Description string `db:"description" json:"description"`
+	IsDamaged   bool   `db:"is_damaged" json:"is_damaged"`
+	TotalStock  int    `db:"total_stock" json:"total_stock"`
+}
+
+// ProductLocationStock is entity of stock of product on one location
+// Value is stock valued at unit cost of product
+type ProductLocationStock struct {
+	ProductID  int64  `db:"product_id" json:"-"`
+	LocationID int64  `db:"location_id" json:"location_id"`
+	Name       string `db:"name" json:"location_name"`
+	Stock      int    `db:"stock" json:"stock"`
+	Value      int64  `db:"value" json:"value,omitempty"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectLocation = `
+	SELECT
+		location.location_id,
+		location.name,
+		COALESCE(location.description, '') as description,
+		location.is_damaged,
+		COALESCE((
+			SELECT SUM(stock_movement.quantity)
+			FROM stock_movement
+			WHERE stock_movement.location_id = location.location_id
+		), 0) as total_stock
+	FROM location
+`
+
+// GetLocation is used to get all location
+func (intr Internal) GetLocation(ctx context.Context) ([]Location, error) {
+	var locations []Location
+
+	query := qSelectLocation
+	query += `ORDER BY location.location_id
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &locations, query)
+	return locations, err
+}
+
+// GetLocationByID is used to get location by ID
+func (intr Internal) GetLocationByID(ctx context.Context, ID int64) (Location, error) {
+	query := qSelectLocation
+	query += `WHERE
+				location.location_id = ?
+			`
+
+	return intr.getLocation(ctx, query, ID)
+}
+
+// GetLocationByName is used to get location by its name
+func (intr Internal) GetLocationByName(ctx context.Context, name string) (Location, error) {
+	query := qSelectLocation
+	query += `WHERE
+				location.name = ?
+			`
+
+	return intr.getLocation(ctx, query, name)
+}
+
+// GetDamagedLocation is used to get location which keeps returned item in damaged condition
+func (intr Internal) GetDamagedLocation(ctx context.Context) (Location, error) {
+	query := qSelectLocation
+	query += `WHERE
+				location.is_damaged = 1
+			ORDER BY location.location_id
+			LIMIT 1
+			`
+
+	return intr.getLocation(ctx, query)
+}
+
+func (intr Internal) getLocation(ctx context.Context, query string, args ...interface{}) (Location, error) {
+	var location Location
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &location, db.Rebind(query), args...)
+
+	// keep returning value but with empty struct
+	// since no rows is not error in a system
+	if err == sql.ErrNoRows {
+		return Location{}, nil
+	}
+
+	return location, err
+}
+
+// CountStockMovementByLocationID is used to get number of stock movement on location
+func (intr Internal) CountStockMovementByLocationID(ctx context.Context, locationID int64) (int, error) {
+	var total int
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &total, "SELECT COUNT(*) FROM stock_movement WHERE location_id = ?", locationID)
+	return total, err
+}
+
+// GetProductLocationStock is used to get stock of each product on each location until dateEnd
+// zero dateEnd means current stock
+func (intr Internal) GetProductLocationStock(ctx context.Context, dateEnd time.Time) ([]ProductLocationStock, error) {
+	var (
+		productLocationStocks []ProductLocationStock
+		args                  []interface{}
+	)
+
+	query := `
+	SELECT
+		stock_movement.product_id,
+		stock_movement.location_id,
+		location.name,
+		SUM(stock_movement.quantity) as stock
+	FROM stock_movement
+	JOIN location ON stock_movement.location_id = location.location_id
+	`
+	if dateEnd != (time.Time{}) {
+		query += `WHERE
+		stock_movement.date <= ?
+	`
+		args = append(args, dateEnd)
+	}
+	query += `GROUP BY stock_movement.product_id, stock_movement.location_id
+	ORDER BY stock_movement.product_id, stock_movement.location_id
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &productLocationStocks, db.Rebind(query), args...)
+	return productLocationStocks, err
+}
+
+// GetProductLocationStockByProductID is used to get current stock of product on each location
+func (intr Internal) GetProductLocationStockByProductID(ctx context.Context, productID int64) ([]ProductLocationStock, error) {
+	var productLocationStocks []ProductLocationStock
+
+	query := `
+	SELECT
+		? as product_id,
+		location.location_id,
+		location.name,
+		COALESCE(SUM(stock_movement.quantity), 0) as stock
+	FROM location
+	LEFT JOIN stock_movement ON location.location_id = stock_movement.location_id AND stock_movement.product_id = ?
+	GROUP BY location.location_id
+	ORDER BY location.location_id
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &productLocationStocks, db.Rebind(query), productID, productID)
+	return productLocationStocks, err
+}
+
+// GetProductLocationStockByLocationID is used to get current stock of each product on location
+// Value is stock valued at cost of stock movement which brings it in and out of the location
+func (intr Internal) GetProductLocationStockByLocationID(ctx context.Context, locationID int64) ([]ProductLocationStock, error) {
+	var productLocationStocks []ProductLocationStock
+
+	query := `
+	SELECT
+		stock_movement.product_id,
+		location.location_id,
+		location.name,
+		SUM(stock_movement.quantity) as stock,
+		CAST(COALESCE(ROUND(SUM(stock_movement.quantity * COALESCE(stock_movement.cost, 0))), 0) AS INTEGER) as value
+	FROM stock_movement
+	JOIN location ON stock_movement.location_id = location.location_id
+	WHERE
+		stock_movement.location_id = ?
+	GROUP BY stock_movement.product_id
+	ORDER BY stock_movement.product_id
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &productLocationStocks, db.Rebind(query), locationID)
+	return productLocationStocks, err
+}
+
+// GetProductLocationStockWithTx is used to get stock of product on location within transaction
+// so the stock includes movement which has not been committed yet
+func (intr Internal) GetProductLocationStockWithTx(ctx context.Context, tx *sql.Tx, productID, locationID int64) (int, error) {
+	var stock int
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM stock_movement WHERE product_id = ? AND location_id = ?`
+
+	err := tx.QueryRowContext(ctx, query, productID, locationID).Scan(&stock)
+	return stock, err
+}
+
+// StoreLocation is to store location into database
+func (intr Internal) StoreLocation(ctx context.Context, tx *sql.Tx, location Location) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO location
+					(
+						name,
+						description
+					)
+			VALUES (
+						?,
+						?
+					)
+			`
+	args = append(args,
+		location.Name,
+		location.Description,
+	)
+	if location.LocationID != 0 {
+		query = `UPDATE location
+				 SET
+						name = ?,
+						description = ?
+				WHERE
+						location_id = ?
+		`
+		args = append(args, location.LocationID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if location.LocationID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		location.LocationID, _ = result.LastInsertId()
+	}
+
+	return location.LocationID, nil
+}
+
+// DeleteLocation is to delete location from database by single ID
+func (intr Internal) DeleteLocation(ctx context.Context, ID int64) error {
+	query := `DELETE FROM location
+			  WHERE
+				  location_id = ?
+			 `
+
+	db := intr.Storage.DB
+	_, err := db.ExecContext(ctx, query, ID)
+	return err
+}
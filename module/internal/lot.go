@@ -0,0 +1,387 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// LotReceipt is reference type of lot which is created by receipt of purchase (purchase detail)
+const LotReceipt = "purchase_detail"
+
+// Lot is entity that represent schema on table lot
+// ExpiryDate has format yyyy-MM-dd, empty expiry date means lot doesn't expire
+type Lot struct {
+	LotID         int64     `db:"lot_id" json:"lot_id"`
+	ProductID     int64     `db:"product_id" json:"product_id"`
+	LocationID    int64     `db:"location_id" json:"location_id"`
+	LocationName  string    `db:"location_name" json:"location_name"`
+	LotNumber     string    `db:"lot_number" json:"lot_number"`
+	ExpiryDate    string    `db:"expiry_date" json:"expiry_date"`
+	ReferenceType string    `db:"reference_type" json:"reference_type"`
+	ReferenceID   int64     `db:"reference_id" json:"reference_id"`
+	Quantity      int       `db:"quantity" json:"quantity"`
+	Remaining     int       `db:"remaining" json:"remaining"`
+	Date          time.Time `db:"date" json:"-"`
+	DateStr       string    `db:"date_str" json:"date"`
+}
+
+// LotWithProduct is entity of lot with product
+type LotWithProduct struct {
+	Lot
+	Product Product `json:"product"`
+}
+
+// LotAllocation is entity that represent schema on table lot_allocation
+type LotAllocation struct {
+	LotAllocationID int64  `db:"lot_allocation_id" json:"lot_allocation_id"`
+	LotID           int64  `db:"lot_id" json:"lot_id"`
+	ProductID       int64  `db:"product_id" json:"product_id"`
+	ReferenceType   string `db:"reference_type" json:"reference_type"`
+	ReferenceID     int64  `db:"reference_id" json:"reference_id"`
+	Quantity        int    `db:"quantity" json:"quantity"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectLot = `
+	SELECT
+		lot.lot_id,
+		lot.product_id,
+		lot.location_id,
+		COALESCE(location.name, '') as location_name,
+		lot.lot_number,
+		lot.expiry_date,
+		lot.reference_type,
+		lot.reference_id,
+		lot.quantity,
+		lot.remaining,
+		lot.date as date_str,
+		product.name,
+		product.sku,
+		product.unit
+	FROM lot
+	JOIN product ON lot.product_id = product.product_id
+	LEFT JOIN location ON lot.location_id = location.location_id
+`
+
+// lot which expires first is consumed first, and lot without expiry date is consumed last
+var qOrderLotByExpiry = `
+	ORDER BY lot.expiry_date = '', lot.expiry_date, lot.date, lot.lot_id
+`
+
+// GetAvailableLotWithProduct is used to get all lot which still has remaining quantity
+// ordered from the lot which expires first
+func (intr Internal) GetAvailableLotWithProduct(ctx context.Context) ([]LotWithProduct, error) {
+	query := qSelectLot
+	query += `WHERE
+				lot.remaining > 0
+			`
+	query += qOrderLotByExpiry
+
+	return intr.selectLotWithProduct(ctx, query)
+}
+
+// GetAvailableLotWithProductByProductID is used to get all lot of product which still has remaining quantity
+func (intr Internal) GetAvailableLotWithProductByProductID(ctx context.Context, productID int64) ([]LotWithProduct, error) {
+	query := qSelectLot
+	query += `WHERE
+				lot.remaining > 0 AND lot.product_id = ?
+			`
+	query += qOrderLotByExpiry
+
+	return intr.selectLotWithProduct(ctx, query, productID)
+}
+
+// GetExpiringLotWithProduct is used to get lot which still has remaining quantity
+// and expires at or before given date (yyyy-MM-dd)
+func (intr Internal) GetExpiringLotWithProduct(ctx context.Context, date string) ([]LotWithProduct, error) {
+	query := qSelectLot
+	query += `WHERE
+				lot.remaining > 0 AND lot.expiry_date != '' AND lot.expiry_date <= ?
+			`
+	query += qOrderLotByExpiry
+
+	return intr.selectLotWithProduct(ctx, query, date)
+}
+
+func (intr Internal) selectLotWithProduct(ctx context.Context, query string, args ...interface{}) ([]LotWithProduct, error) {
+	lotsWithProduct := []LotWithProduct{}
+
+	db := intr.Storage.DB
+	rows, err := db.QueryContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lotWithProduct, err := scanLotWithProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		lotsWithProduct = append(lotsWithProduct, lotWithProduct)
+	}
+
+	return lotsWithProduct, rows.Err()
+}
+
+// GetAvailableLotByLocationWithTx is used to get lot of product on location which still has remaining quantity
+// within transaction, ordered from the lot which expires first
+func (intr Internal) GetAvailableLotByLocationWithTx(ctx context.Context, tx *sql.Tx, productID, locationID int64) ([]Lot, error) {
+	query := qSelectLot
+	query += `WHERE
+				lot.remaining > 0 AND lot.product_id = ? AND lot.location_id = ?
+			`
+	query += qOrderLotByExpiry
+
+	return intr.selectLotWithTx(ctx, tx, query, productID, locationID)
+}
+
+// GetLotByReferenceWithTx is used to get lot which is created by document within transaction
+func (intr Internal) GetLotByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]Lot, error) {
+	query := qSelectLot
+	query += `WHERE
+				lot.reference_type = ? AND lot.reference_id = ?
+			`
+	query += qOrderLotByExpiry
+
+	return intr.selectLotWithTx(ctx, tx, query, referenceType, referenceID)
+}
+
+func (intr Internal) selectLotWithTx(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]Lot, error) {
+	var lots []Lot
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lotWithProduct, err := scanLotWithProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		lots = append(lots, lotWithProduct.Lot)
+	}
+
+	return lots, rows.Err()
+}
+
+func scanLotWithProduct(rows *sql.Rows) (LotWithProduct, error) {
+	lotWithProduct := LotWithProduct{}
+	err := rows.Scan(
+		&lotWithProduct.LotID,
+		&lotWithProduct.ProductID,
+		&lotWithProduct.LocationID,
+		&lotWithProduct.LocationName,
+		&lotWithProduct.LotNumber,
+		&lotWithProduct.ExpiryDate,
+		&lotWithProduct.ReferenceType,
+		&lotWithProduct.ReferenceID,
+		&lotWithProduct.Quantity,
+		&lotWithProduct.Remaining,
+		&lotWithProduct.DateStr,
+		&lotWithProduct.Product.Name,
+		&lotWithProduct.Product.Sku,
+		&lotWithProduct.Product.Unit,
+	)
+	if err != nil {
+		return LotWithProduct{}, err
+	}
+
+	lotWithProduct.Product.ProductID = lotWithProduct.ProductID
+
+	// convert date string into date time.Time
+	// spit date string to remove character +00:00
+	// date format: yyyy-MM-dd HH:mm:ss
+	splitDateStr := strings.Split(lotWithProduct.DateStr, "+")
+	DateStr := strings.Trim(splitDateStr[0], " ")
+	lotWithProduct.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+	if err != nil {
+		return LotWithProduct{}, err
+	}
+
+	// using date format: yyyy-MM-dd HH:mm:ss
+	// to standarize date convenient
+	lotWithProduct.DateStr = lotWithProduct.Date.Format("2006-01-02 15:04:05")
+
+	return lotWithProduct, nil
+}
+
+// StoreLot is to store lot into database
+func (intr Internal) StoreLot(ctx context.Context, tx *sql.Tx, lot Lot) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO lot
+					(
+						product_id,
+						location_id,
+						lot_number,
+						expiry_date,
+						reference_type,
+						reference_id,
+						quantity,
+						remaining,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+	args = append(args,
+		lot.ProductID,
+		lot.LocationID,
+		lot.LotNumber,
+		lot.ExpiryDate,
+		lot.ReferenceType,
+		lot.ReferenceID,
+		lot.Quantity,
+		lot.Remaining,
+		lot.Date,
+	)
+	if lot.LotID != 0 {
+		query = `UPDATE lot
+				 SET
+						product_id = ?,
+						location_id = ?,
+						lot_number = ?,
+						expiry_date = ?,
+						reference_type = ?,
+						reference_id = ?,
+						quantity = ?,
+						remaining = ?,
+						date = ?
+				WHERE
+						lot_id = ?
+		`
+		args = append(args, lot.LotID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if lot.LotID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		lot.LotID, _ = result.LastInsertId()
+	}
+
+	return lot.LotID, nil
+}
+
+// UpdateLotRemaining is to add quantity into remaining of lot
+// use negative quantity to consume the lot
+func (intr Internal) UpdateLotRemaining(ctx context.Context, tx *sql.Tx, ID int64, quantity int) error {
+	query := `UPDATE lot
+			  SET
+					remaining = remaining + ?
+			  WHERE
+					lot_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, quantity, ID)
+	return err
+}
+
+// GetLotAllocationByReferenceWithTx is used to get allocation of lot by document within transaction
+func (intr Internal) GetLotAllocationByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]LotAllocation, error) {
+	var lotAllocations []LotAllocation
+
+	query := `
+	SELECT
+		lot_allocation_id,
+		lot_id,
+		product_id,
+		reference_type,
+		reference_id,
+		quantity
+	FROM lot_allocation
+	WHERE
+		reference_type = ? AND reference_id = ?
+	`
+
+	rows, err := tx.QueryContext(ctx, query, referenceType, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lotAllocation := LotAllocation{}
+		err = rows.Scan(
+			&lotAllocation.LotAllocationID,
+			&lotAllocation.LotID,
+			&lotAllocation.ProductID,
+			&lotAllocation.ReferenceType,
+			&lotAllocation.ReferenceID,
+			&lotAllocation.Quantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		lotAllocations = append(lotAllocations, lotAllocation)
+	}
+
+	return lotAllocations, rows.Err()
+}
+
+// StoreLotAllocation is to store allocation of lot into database
+func (intr Internal) StoreLotAllocation(ctx context.Context, tx *sql.Tx, lotAllocation LotAllocation) (ID int64, err error) {
+	query := `INSERT INTO lot_allocation
+					(
+						lot_id,
+						product_id,
+						reference_type,
+						reference_id,
+						quantity
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		lotAllocation.LotID,
+		lotAllocation.ProductID,
+		lotAllocation.ReferenceType,
+		lotAllocation.ReferenceID,
+		lotAllocation.Quantity,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
+
+// DeleteLotAllocationByReference is to delete allocation of lot by document
+func (intr Internal) DeleteLotAllocationByReference(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) error {
+	query := `DELETE FROM lot_allocation
+			  WHERE
+				  reference_type = ? AND reference_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, referenceType, referenceID)
+	return err
+}
@@ -8,8 +8,15 @@ import (
 )
 
 // Order is entity that represent schema on table order
+// SerialNumbers is unit of serialized product which is picked by the order line
+// Quantity and Price are kept in base unit of product, Unit is unit which the order is requested in
+// and UnitFactor converts it into base unit, UnitQuantity shows quantity in the unit of order
+// and UnitPrice is price as it is inputed in the unit of order (Price of base unit is rounded)
+// Cost is snapshot of unit cost and Cogs is snapshot of total cost of goods sold of the order
 type Order struct {
 	OrderID       int64     `db:"order_id" json:"order_id"`
+	SalesOrderID  int64     `db:"sales_order_id" json:"sales_order_id"`
+	CustomerID    int64     `db:"customer_id" json:"customer_id"`
 	OrderIDFormat string    `db:"order_id_format" json:"order_id_format"`
 	ProductID     int64     `db:"product_id" json:"product_id"`
 	Quantity      int       `db:"quantity" json:"quantity"`
@@ -17,7 +24,16 @@ type Order struct {
 	Date          time.Time `db:"date" json:"-"`
 	DateStr       string    `db:"date_str" json:"date"`
 	Price         int64     `db:"price" json:"price"`
+	Cost          int64     `db:"cost" json:"cost"`
+	Cogs          int64     `db:"cogs" json:"cogs"`
+	LocationID    int64     `db:"location_id" json:"location_id"`
+	LocationName  string    `db:"location_name" json:"location_name"`
 	Total         int64     `json:"total"`
+	Unit          string    `db:"unit" json:"unit"`
+	UnitFactor    int       `db:"unit_factor" json:"unit_factor"`
+	UnitQuantity  float64   `json:"unit_quantity"`
+	UnitPrice     int64     `db:"unit_price" json:"unit_price"`
+	SerialNumbers []string  `json:"serial_numbers,omitempty"`
 }
 
 // OrderWithProduct is entity that represent schema on table order
@@ -32,81 +48,40 @@ type OrderWithProduct struct {
 var qSelectOrder = `
 	SELECT 
 		orders.order_id,
+		orders.sales_order_id,
+		orders.customer_id,
 		orders.order_id_format,
 		orders.product_id,
 		orders.quantity,
 		orders.description,
 		orders.date as date_str,
 		orders.price,
+		COALESCE(orders.cost, 0),
+		COALESCE(orders.cogs, COALESCE(orders.cost, 0) * orders.quantity),
+		orders.location_id,
+		COALESCE(location.name, '') as location_name,
+		orders.unit,
+		orders.unit_factor,
+		COALESCE(orders.unit_price, orders.price * orders.unit_factor),
 		product.name,
 		product.sku,
-		product.stock
+		product.unit,
+		` + qProductStock + ` as stock
 	FROM orders
 	JOIN product ON orders.product_id = product.product_id
+	LEFT JOIN location ON orders.location_id = location.location_id
 `
 
 // GetOrderWithProduct is used to get all order with product
 func (intr Internal) GetOrderWithProduct(ctx context.Context) ([]OrderWithProduct, error) {
-	var (
-		ordersWithProduct []OrderWithProduct
-		query             string
-	)
-
-	query = qSelectOrder
-	db := intr.Storage.DB
-	row, err := db.QueryxContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-
-	for row.Next() {
-		orderWithProduct := OrderWithProduct{}
-		err = row.Scan(
-			&orderWithProduct.OrderID,
-			&orderWithProduct.OrderIDFormat,
-			&orderWithProduct.ProductID,
-			&orderWithProduct.Quantity,
-			&orderWithProduct.Description,
-			&orderWithProduct.DateStr,
-			&orderWithProduct.Price,
-			&orderWithProduct.Product.Name,
-			&orderWithProduct.Product.Sku,
-			&orderWithProduct.Product.Stock,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		orderWithProduct.Product.ProductID = orderWithProduct.ProductID
-
-		// convert date string into date time.Time
-		// spit date string to remove character +00:00
-		splitDateStr := strings.Split(orderWithProduct.DateStr, "+")
-		DateStr := strings.Trim(splitDateStr[0], " ")
-		orderWithProduct.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
-		if err != nil {
-			return nil, err
-		}
-
-		// using date format: yyyy-MM-dd HH:mm:ss
-		// to standarize date convenient
-		orderWithProduct.DateStr = orderWithProduct.Date.Format("2006-01-02 15:04:05")
-
-		ordersWithProduct = append(ordersWithProduct, orderWithProduct)
-
-	}
+	query := qSelectOrder
 
-	return ordersWithProduct, err
+	return intr.selectOrderWithProduct(ctx, query)
 }
 
 // GetOrderWithProductByDate is used to get all order with product by filter date
 func (intr Internal) GetOrderWithProductByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]OrderWithProduct, error) {
-	var (
-		ordersWithProduct []OrderWithProduct
-		query             string
-	)
-
-	query = qSelectOrder
+	query := qSelectOrder
 	query += `WHERE
 		orders.date > ? AND orders.date <= ?
 	`
@@ -125,90 +100,116 @@ func (intr Internal) GetOrderWithProductByDate(ctx context.Context, dateStart, d
 		23, 59, 59, 0, time.UTC,
 	)
 
+	return intr.selectOrderWithProduct(ctx, query, dateStartMidnight, dateEndMidnight)
+}
+
+// GetOrderWithProductBySalesOrderID is used to get all line of sales order with product
+func (intr Internal) GetOrderWithProductBySalesOrderID(ctx context.Context, salesOrderID int64) ([]OrderWithProduct, error) {
+	query := qSelectOrder
+	query += `WHERE
+		orders.sales_order_id = ?
+	ORDER BY orders.order_id
+	`
+
+	return intr.selectOrderWithProduct(ctx, query, salesOrderID)
+}
+
+// GetOrderWithProductByCustomerID is used to get all order of customer with product, the latest first
+func (intr Internal) GetOrderWithProductByCustomerID(ctx context.Context, customerID int64) ([]OrderWithProduct, error) {
+	query := qSelectOrder
+	query += `WHERE
+		orders.customer_id = ?
+	ORDER BY orders.date DESC, orders.order_id DESC
+	`
+
+	return intr.selectOrderWithProduct(ctx, query, customerID)
+}
+
+// GetOrderWithProductByID is used to get order with product by ID
+func (intr Internal) GetOrderWithProductByID(ctx context.Context, ID int64) (OrderWithProduct, error) {
+	query := qSelectOrder
+	query += `WHERE
+				order_id = ?
+			`
+
 	db := intr.Storage.DB
-	row, err := db.QueryxContext(ctx, query, dateStartMidnight, dateEndMidnight)
+	row := db.QueryRowxContext(ctx, query, ID)
+	orderWithProduct, err := scanOrderWithProduct(row)
+
+	// keep returning value but with empty struct
+	// since no rows is not error in a system
+	if err == sql.ErrNoRows {
+		return OrderWithProduct{}, nil
+	}
+
 	if err != nil {
-		return nil, err
+		return OrderWithProduct{}, err
 	}
 
-	for row.Next() {
-		orderWithProduct := OrderWithProduct{}
-		err = row.Scan(
-			&orderWithProduct.OrderID,
-			&orderWithProduct.OrderIDFormat,
-			&orderWithProduct.ProductID,
-			&orderWithProduct.Quantity,
-			&orderWithProduct.Description,
-			&orderWithProduct.DateStr,
-			&orderWithProduct.Price,
-			&orderWithProduct.Product.Name,
-			&orderWithProduct.Product.Sku,
-			&orderWithProduct.Product.Stock,
-		)
-		if err != nil {
-			return nil, err
-		}
+	return orderWithProduct, nil
+}
 
-		orderWithProduct.Product.ProductID = orderWithProduct.ProductID
+func (intr Internal) selectOrderWithProduct(ctx context.Context, query string, args ...interface{}) ([]OrderWithProduct, error) {
+	var ordersWithProduct []OrderWithProduct
 
-		// convert date string into date time.Time
-		// spit date string to remove character +00:00
-		// date format: yyyy-MM-dd HH:mm:ss
-		splitDateStr := strings.Split(orderWithProduct.DateStr, "+")
-		DateStr := strings.Trim(splitDateStr[0], " ")
-		orderWithProduct.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+	db := intr.Storage.DB
+	row, err := db.QueryxContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for row.Next() {
+		orderWithProduct, err := scanOrderWithProduct(row)
 		if err != nil {
 			return nil, err
 		}
 
-		// using date format: yyyy-MM-dd HH:mm:ss
-		// to standarize date convenient
-		orderWithProduct.DateStr = orderWithProduct.Date.Format("2006-01-02 15:04:05")
-
 		ordersWithProduct = append(ordersWithProduct, orderWithProduct)
-
 	}
 
-	return ordersWithProduct, err
+	return ordersWithProduct, nil
 }
 
-// GetOrderWithProductByID is used to get order with product by ID
-func (intr Internal) GetOrderWithProductByID(ctx context.Context, ID int64) (OrderWithProduct, error) {
-	var (
-		orderWithProduct OrderWithProduct
-		query            string
-	)
-	query = qSelectOrder
-	query += `WHERE
-				order_id = ?
-			`
-	db := intr.Storage.DB
-	row := db.QueryRowxContext(ctx, query, ID)
+// scanOrderWithProduct is to scan one row of qSelectOrder
+func scanOrderWithProduct(row interface {
+	Scan(dest ...interface{}) error
+}) (OrderWithProduct, error) {
+	orderWithProduct := OrderWithProduct{}
 	err := row.Scan(
 		&orderWithProduct.OrderID,
+		&orderWithProduct.SalesOrderID,
+		&orderWithProduct.CustomerID,
 		&orderWithProduct.OrderIDFormat,
 		&orderWithProduct.ProductID,
 		&orderWithProduct.Quantity,
 		&orderWithProduct.Description,
 		&orderWithProduct.DateStr,
 		&orderWithProduct.Price,
+		&orderWithProduct.Cost,
+		&orderWithProduct.Cogs,
+		&orderWithProduct.LocationID,
+		&orderWithProduct.LocationName,
+		&orderWithProduct.Unit,
+		&orderWithProduct.UnitFactor,
+		&orderWithProduct.UnitPrice,
 		&orderWithProduct.Product.Name,
 		&orderWithProduct.Product.Sku,
+		&orderWithProduct.Product.Unit,
 		&orderWithProduct.Product.Stock,
 	)
-
-	// keep returning value but with empty struct
-	// since no rows is not error in a system
-	if err == sql.ErrNoRows {
-		return OrderWithProduct{}, nil
-	}
-
 	if err != nil {
 		return OrderWithProduct{}, err
 	}
 
 	orderWithProduct.Product.ProductID = orderWithProduct.ProductID
 
+	// order which was recorded before unit of measure is introduced is in base unit
+	if orderWithProduct.Unit == "" || orderWithProduct.UnitFactor <= 0 {
+		orderWithProduct.Unit = orderWithProduct.Product.Unit
+		orderWithProduct.UnitFactor = 1
+	}
+	orderWithProduct.UnitQuantity = unitQuantity(orderWithProduct.Quantity, orderWithProduct.UnitFactor)
+
 	// convert date string into date time.Time
 	// spit date string to remove character +00:00
 	// date format: yyyy-MM-dd HH:mm:ss
@@ -223,7 +224,55 @@ func (intr Internal) GetOrderWithProductByID(ctx context.Context, ID int64) (Ord
 	// to standarize date convenient
 	orderWithProduct.DateStr = orderWithProduct.Date.Format("2006-01-02 15:04:05")
 
-	return orderWithProduct, err
+	return orderWithProduct, nil
+}
+
+// CountOrderByProductID is used to get number of order line of product
+func (intr Internal) CountOrderByProductID(ctx context.Context, productID int64) (int, error) {
+	var total int
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &total, "SELECT COUNT(*) FROM orders WHERE product_id = ?", productID)
+	return total, err
+}
+
+// GetOrderWithoutCost is used to get all order which cost has not been snapshotted yet
+// (e.g. stored before the snapshot is introduced or imported from excel)
+func (intr Internal) GetOrderWithoutCost(ctx context.Context) ([]Order, error) {
+	var (
+		orders []Order
+		query  string
+	)
+
+	query = `
+	SELECT
+		order_id,
+		product_id,
+		quantity,
+		date as date_str
+	FROM orders
+	WHERE
+		cost IS NULL
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &orders, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, order := range orders {
+		// spit date string to remove character +00:00
+		// date format: yyyy-MM-dd HH:mm:ss
+		splitDateStr := strings.Split(order.DateStr, "+")
+		DateStr := strings.Trim(splitDateStr[0], " ")
+		orders[index].Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return orders, nil
 }
 
 // StoreOrder is to store product into database
@@ -231,39 +280,63 @@ func (intr Internal) StoreOrder(ctx context.Context, tx *sql.Tx, order Order) (I
 	var args []interface{}
 	query := `INSERT INTO orders  
 					(
+						sales_order_id,
+						customer_id,
 						order_id_format,
 						product_id,
 						quantity,
 						description,
 						date,
-						price 
+						price,
+						location_id,
+						unit,
+						unit_factor,
+						unit_price
 					)
 			VALUES (
+						?,
+						?,
 						?, 
 						?, 
 						?,
 						?, 
 						?, 
-						?						
+						?,
+						?,
+						?,
+						?,
+						?
 					)
 			`
 	args = append(args,
+		order.SalesOrderID,
+		order.CustomerID,
 		order.OrderIDFormat,
 		order.ProductID,
 		order.Quantity,
 		order.Description,
 		order.Date,
 		order.Price,
+		order.LocationID,
+		order.Unit,
+		order.UnitFactor,
+		order.UnitPrice,
 	)
 	if order.OrderID != 0 {
 		query = `UPDATE orders 
 				 SET 
+						sales_order_id = ?,
+						customer_id = ?,
 						order_id_format = ?,
 						product_id = ?,
 						quantity = ?,
 						description = ?,
 						date = ?,
-						price = ? 
+						price = ?,
+						location_id = ?,
+						unit = ?,
+						unit_factor = ?,
+						unit_price = ?
 				WHERE 
 						order_id = ?
 						 
@@ -283,3 +356,41 @@ func (intr Internal) StoreOrder(ctx context.Context, tx *sql.Tx, order Order) (I
 
 	return order.OrderID, err
 }
+
+// UpdateOrderCost is to store snapshot of unit cost and cost of goods sold of order
+func (intr Internal) UpdateOrderCost(ctx context.Context, tx *sql.Tx, ID int64, cost int64, cogs int64) error {
+	query := `UPDATE orders
+			  SET
+					cost = ?,
+					cogs = ?
+			  WHERE
+					order_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, cost, cogs, ID)
+	return err
+}
+
+// UpdateOrderCustomerBySalesOrderID is to copy customer of sales order into all of its lines
+func (intr Internal) UpdateOrderCustomerBySalesOrderID(ctx context.Context, tx *sql.Tx, salesOrderID int64, customerID int64) error {
+	query := `UPDATE orders
+			  SET
+					customer_id = ?
+			  WHERE
+					sales_order_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, customerID, salesOrderID)
+	return err
+}
+
+// DeleteOrder is to delete order (line of sales order) from database
+func (intr Internal) DeleteOrder(ctx context.Context, tx *sql.Tx, ID int64) error {
+	query := `DELETE FROM orders
+			  WHERE
+					order_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, ID)
+	return err
+}
@@ -6,13 +6,42 @@ import (
 )
 
 // Product is entity that represent schema on table product
+// ParentID is product parent which has the product as its variant, zero means product without variant
+// bundle doesn't keep its own stock, it consumes stock of its Components when it is sold
+// product need to be reordered when its stock is at or below ReorderPoint, zero means it is never reordered
+// each unit of serialized product is tracked by its serial number
+// stock is counted in Unit (base unit), while Units is pack of product (e.g. dozen, carton)
+// which can be used to purchase and sell the product
+// Locations is breakdown of stock on each location, Attributes is value of its variant
+// Components is bill of materials of bundle, Serials is unit which is still in stock
+// and Units is conversion of its packs, all of them are only filled on detail of product
 type Product struct {
-	ProductID int64  `db:"product_id" json:"product_id"`
-	Name      string `db:"name" json:"product_name"`
-	Sku       string `db:"sku" json:"product_sku"`
-	Stock     int    `db:"stock" json:"product_stock"`
+	ProductID       int64                  `db:"product_id" json:"product_id"`
+	Name            string                 `db:"name" json:"product_name"`
+	Sku             string                 `db:"sku" json:"product_sku"`
+	Stock           int                    `db:"stock" json:"product_stock"`
+	ParentID        int64                  `db:"parent_id" json:"parent_id"`
+	IsBundle        bool                   `db:"is_bundle" json:"is_bundle"`
+	ReorderPoint    int                    `db:"reorder_point" json:"reorder_point"`
+	ReorderQuantity int                    `db:"reorder_quantity" json:"reorder_quantity"`
+	IsSerialized    bool                   `db:"is_serialized" json:"is_serialized"`
+	Unit            string                 `db:"unit" json:"unit"`
+	Locations       []ProductLocationStock `json:"locations,omitempty"`
+	Attributes      []ProductAttribute     `json:"attributes,omitempty"`
+	Components      []ProductComponent     `json:"components,omitempty"`
+	Lots            []Lot                  `json:"lots,omitempty"`
+	Serials         []Serial               `json:"serials,omitempty"`
+	Units           []ProductUnit          `json:"units,omitempty"`
 }
 
+// stock of product is derived from stock movement
+// so this subquery need to be declared as a global variable to be used on many query
+var qProductStock = `(
+			SELECT COALESCE(SUM(stock_movement.quantity), 0) 
+			FROM stock_movement 
+			WHERE stock_movement.product_id = product.product_id
+		)`
+
 // this is a main query. it will be used on many place
 // so to reduce redudancy, this query need to be declared as a global variable
 var qSelectProduct = `
@@ -20,7 +49,13 @@ var qSelectProduct = `
 					product_id,
 					name,
 					sku,
-					stock
+					` + qProductStock + ` as stock,
+					parent_id,
+					is_bundle,
+					reorder_point,
+					reorder_quantity,
+					is_serialized,
+					unit
 			FROM product
 			`
 
@@ -59,6 +94,88 @@ func (intr Internal) GetProductByID(ctx context.Context, ID int64) (Product, err
 	return product, err
 }
 
+// GetProductBySkuWithTx is used to get product by its sku within transaction
+func (intr Internal) GetProductBySkuWithTx(ctx context.Context, tx *sql.Tx, sku string) (Product, error) {
+	var (
+		product Product
+		query   string
+	)
+
+	query = qSelectProduct
+	query += `WHERE
+				sku = ?
+			`
+	row := tx.QueryRowContext(ctx, query, sku)
+	err := row.Scan(
+		&product.ProductID,
+		&product.Name,
+		&product.Sku,
+		&product.Stock,
+		&product.ParentID,
+		&product.IsBundle,
+		&product.ReorderPoint,
+		&product.ReorderQuantity,
+		&product.IsSerialized,
+		&product.Unit,
+	)
+
+	// pass if sql no rows error
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return product, err
+}
+
+// GetProductByParentID is used to get all variant of product parent
+func (intr Internal) GetProductByParentID(ctx context.Context, parentID int64) ([]Product, error) {
+	var (
+		products []Product
+		query    string
+	)
+
+	query = qSelectProduct
+	query += `WHERE
+				parent_id = ?
+			ORDER BY sku
+			`
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &products, db.Rebind(query), parentID)
+	return products, err
+}
+
+// GetProductByIDWithTx is used to get product by ID within transaction
+// so stock of product includes movement which has not been committed yet
+func (intr Internal) GetProductByIDWithTx(ctx context.Context, tx *sql.Tx, ID int64) (Product, error) {
+	var (
+		product Product
+		query   string
+	)
+
+	query = qSelectProduct
+	query += `WHERE
+				product_id = ?
+			`
+	row := tx.QueryRowContext(ctx, query, ID)
+	err := row.Scan(
+		&product.ProductID,
+		&product.Name,
+		&product.Sku,
+		&product.Stock,
+		&product.ParentID,
+		&product.IsBundle,
+		&product.ReorderPoint,
+		&product.ReorderQuantity,
+		&product.IsSerialized,
+		&product.Unit,
+	)
+
+	// pass if sql no rows error
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return product, err
+}
+
 // StoreProduct is to store product into database
 func (intr Internal) StoreProduct(ctx context.Context, tx *sql.Tx, product Product) (ID int64, err error) {
 	var args []interface{}
@@ -66,26 +183,48 @@ func (intr Internal) StoreProduct(ctx context.Context, tx *sql.Tx, product Produ
 					(
 						name,
 						sku,
-						stock 
+						stock,
+						parent_id,
+						is_bundle,
+						reorder_point,
+						reorder_quantity,
+						is_serialized,
+						unit
 					)
 			VALUES (
 						?, 
 						?, 
-						?						
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
 					)
 			`
-	args = append(args, product.Name, product.Sku, product.Stock)
+	if product.Unit == "" {
+		product.Unit = DefaultUnit
+	}
+
+	args = append(args, product.Name, product.Sku, product.Stock, product.ParentID, product.IsBundle, product.ReorderPoint, product.ReorderQuantity, product.IsSerialized, product.Unit)
 	if product.ProductID != 0 {
+		// stock of existing product can only be changed by stock movement,
+		// its parent can only be changed by variant of product parent
+		// and it can't be changed into bundle or vice versa
 		query = `UPDATE product 
 				 SET 
 						name = ?,
 						sku = ?,
-						stock = ?
+						reorder_point = ?,
+						reorder_quantity = ?,
+						is_serialized = ?,
+						unit = ?
 				WHERE 
 						product_id = ?
 						 
 		`
-		args = append(args, product.ProductID)
+		args = []interface{}{product.Name, product.Sku, product.ReorderPoint, product.ReorderQuantity, product.IsSerialized, product.Unit, product.ProductID}
 	}
 
 	result, err := tx.ExecContext(ctx, query, args...)
@@ -101,6 +240,32 @@ func (intr Internal) StoreProduct(ctx context.Context, tx *sql.Tx, product Produ
 	return product.ProductID, err
 }
 
+// UpdateProductParentID is to link product as variant of product parent
+// zero parentID means product doesn't have parent anymore
+func (intr Internal) UpdateProductParentID(ctx context.Context, tx *sql.Tx, ID, parentID int64) error {
+	query := `UPDATE product
+			  SET
+					parent_id = ?
+			  WHERE
+					product_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, parentID, ID)
+	return err
+}
+
+// UnlinkProductByParentID is to remove all variant from product parent
+// so the variants become product without parent and without attribute
+func (intr Internal) UnlinkProductByParentID(ctx context.Context, tx *sql.Tx, parentID int64) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM product_attribute WHERE product_id IN (SELECT product_id FROM product WHERE parent_id = ?)", parentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE product SET parent_id = 0 WHERE parent_id = ?", parentID)
+	return err
+}
+
 // DeleteProduct is to delete product from database by single ID
 func (intr Internal) DeleteProduct(ctx context.Context, ID int64) error {
 	query := `DELETE FROM product 
@@ -114,5 +279,17 @@ func (intr Internal) DeleteProduct(ctx context.Context, ID int64) error {
 		return err
 	}
 
+	// attribute, unit and component are only meaningful for its product
+	_, err = db.ExecContext(ctx, "DELETE FROM product_attribute WHERE product_id = ?", ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, "DELETE FROM product_unit WHERE product_id = ?", ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, "DELETE FROM product_component WHERE bundle_id = ?", ID)
 	return err
 }
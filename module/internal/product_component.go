@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ProductComponent is entity that represent schema on table product_component
+// Quantity is quantity of component which is consumed by one bundle
+type ProductComponent struct {
+	BundleID    int64   `db:"bundle_id" json:"-"`
+	ComponentID int64   `db:"component_id" json:"product_id"`
+	Quantity    int     `db:"quantity" json:"quantity"`
+	Product     Product `json:"product"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectProductComponent = `
+	SELECT
+		product_component.bundle_id,
+		product_component.component_id,
+		product_component.quantity,
+		product.name,
+		product.sku,
+		product.unit,
+		` + qProductStock + ` as stock
+	FROM product_component
+	JOIN product ON product_component.component_id = product.product_id
+`
+
+// GetProductComponent is used to get component of all bundle
+func (intr Internal) GetProductComponent(ctx context.Context) ([]ProductComponent, error) {
+	query := qSelectProductComponent
+	query += `ORDER BY product_component.product_component_id
+			`
+
+	return intr.selectProductComponent(ctx, query)
+}
+
+// GetProductComponentByBundleID is used to get component of bundle
+func (intr Internal) GetProductComponentByBundleID(ctx context.Context, bundleID int64) ([]ProductComponent, error) {
+	query := qSelectProductComponent
+	query += `WHERE
+				product_component.bundle_id = ?
+			ORDER BY product_component.product_component_id
+			`
+
+	return intr.selectProductComponent(ctx, query, bundleID)
+}
+
+func (intr Internal) selectProductComponent(ctx context.Context, query string, args ...interface{}) ([]ProductComponent, error) {
+	var productComponents []ProductComponent
+
+	db := intr.Storage.DB
+	row, err := db.QueryxContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	for row.Next() {
+		productComponent := ProductComponent{}
+		err = row.Scan(
+			&productComponent.BundleID,
+			&productComponent.ComponentID,
+			&productComponent.Quantity,
+			&productComponent.Product.Name,
+			&productComponent.Product.Sku,
+			&productComponent.Product.Unit,
+			&productComponent.Product.Stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		productComponent.Product.ProductID = productComponent.ComponentID
+		productComponents = append(productComponents, productComponent)
+	}
+
+	return productComponents, nil
+}
+
+// StoreProductComponent is to store component of bundle into database
+func (intr Internal) StoreProductComponent(ctx context.Context, tx *sql.Tx, productComponent ProductComponent) error {
+	query := `INSERT INTO product_component
+					(
+						bundle_id,
+						component_id,
+						quantity
+					)
+			VALUES (
+						?,
+						?,
+						?
+					)
+			`
+
+	_, err := tx.ExecContext(ctx, query,
+		productComponent.BundleID,
+		productComponent.ComponentID,
+		productComponent.Quantity,
+	)
+	return err
+}
+
+// CountProductComponentByComponentID is used to count bundle which is made from the product
+func (intr Internal) CountProductComponentByComponentID(ctx context.Context, componentID int64) (int, error) {
+	var total int
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &total, "SELECT COUNT(*) FROM product_component WHERE component_id = ?", componentID)
+	return total, err
+}
+
+// DeleteProductComponentByBundleID is to delete all component of bundle from database
+func (intr Internal) DeleteProductComponentByBundleID(ctx context.Context, tx *sql.Tx, bundleID int64) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM product_component WHERE bundle_id = ?", bundleID)
+	return err
+}
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ProductParent is entity that represent schema on table product_parent
+// Sku is base sku which sku of its variant is generated from
+type ProductParent struct {
+	ParentID     int64  `db:"parent_id" json:"parent_id"`
+	Name         string `db:"name" json:"parent_name"`
+	Sku          string `db:"sku" json:"parent_sku"`
+	Description  string `db:"description" json:"description"`
+	TotalVariant int    `db:"total_variant" json:"total_variant"`
+	TotalStock   int    `db:"total_stock" json:"total_stock"`
+}
+
+// ProductAttribute is entity that represent schema on table product_attribute
+// it is value of variant of product (e.g. size L)
+type ProductAttribute struct {
+	ProductID int64  `db:"product_id" json:"-"`
+	Name      string `db:"name" json:"name"`
+	Value     string `db:"value" json:"value"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectProductParent = `
+	SELECT
+		product_parent.parent_id,
+		product_parent.name,
+		product_parent.sku,
+		COALESCE(product_parent.description, '') as description,
+		(
+			SELECT COUNT(*)
+			FROM product
+			WHERE product.parent_id = product_parent.parent_id
+		) as total_variant,
+		COALESCE((
+			SELECT SUM(stock_movement.quantity)
+			FROM stock_movement
+			JOIN product ON stock_movement.product_id = product.product_id
+			WHERE product.parent_id = product_parent.parent_id
+		), 0) as total_stock
+	FROM product_parent
+`
+
+// GetProductParent is used to get all product parent
+func (intr Internal) GetProductParent(ctx context.Context) ([]ProductParent, error) {
+	var productParents []ProductParent
+
+	query := qSelectProductParent
+	query += `ORDER BY product_parent.sku
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &productParents, query)
+	return productParents, err
+}
+
+// GetProductParentByID is used to get product parent by ID
+func (intr Internal) GetProductParentByID(ctx context.Context, ID int64) (ProductParent, error) {
+	query := qSelectProductParent
+	query += `WHERE
+				product_parent.parent_id = ?
+			`
+
+	return intr.getProductParent(ctx, query, ID)
+}
+
+// GetProductParentBySku is used to get product parent by its sku
+func (intr Internal) GetProductParentBySku(ctx context.Context, sku string) (ProductParent, error) {
+	query := qSelectProductParent
+	query += `WHERE
+				product_parent.sku = ?
+			`
+
+	return intr.getProductParent(ctx, query, sku)
+}
+
+func (intr Internal) getProductParent(ctx context.Context, query string, args ...interface{}) (ProductParent, error) {
+	var productParent ProductParent
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &productParent, db.Rebind(query), args...)
+
+	// keep returning value but with empty struct
+	// since no rows is not error in a system
+	if err == sql.ErrNoRows {
+		return ProductParent{}, nil
+	}
+
+	return productParent, err
+}
+
+// StoreProductParent is to store product parent into database
+func (intr Internal) StoreProductParent(ctx context.Context, tx *sql.Tx, productParent ProductParent) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO product_parent
+					(
+						name,
+						sku,
+						description
+					)
+			VALUES (
+						?,
+						?,
+						?
+					)
+			`
+	args = append(args,
+		productParent.Name,
+		productParent.Sku,
+		productParent.Description,
+	)
+	if productParent.ParentID != 0 {
+		query = `UPDATE product_parent
+				 SET
+						name = ?,
+						sku = ?,
+						description = ?
+				WHERE
+						parent_id = ?
+		`
+		args = append(args, productParent.ParentID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if productParent.ParentID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		productParent.ParentID, _ = result.LastInsertId()
+	}
+
+	return productParent.ParentID, nil
+}
+
+// DeleteProductParent is to delete product parent from database by single ID
+func (intr Internal) DeleteProductParent(ctx context.Context, tx *sql.Tx, ID int64) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM product_parent WHERE parent_id = ?", ID)
+	return err
+}
+
+// GetProductAttributeByProductID is used to get all attribute of product
+func (intr Internal) GetProductAttributeByProductID(ctx context.Context, productID int64) ([]ProductAttribute, error) {
+	var productAttributes []ProductAttribute
+
+	query := `
+	SELECT
+		product_id,
+		name,
+		value
+	FROM product_attribute
+	WHERE
+		product_id = ?
+	ORDER BY product_attribute_id
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &productAttributes, db.Rebind(query), productID)
+	return productAttributes, err
+}
+
+// GetProductAttributeByParentID is used to get all attribute of variant of product parent
+func (intr Internal) GetProductAttributeByParentID(ctx context.Context, parentID int64) ([]ProductAttribute, error) {
+	var productAttributes []ProductAttribute
+
+	query := `
+	SELECT
+		product_attribute.product_id,
+		product_attribute.name,
+		product_attribute.value
+	FROM product_attribute
+	JOIN product ON product_attribute.product_id = product.product_id
+	WHERE
+		product.parent_id = ?
+	ORDER BY product_attribute.product_attribute_id
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &productAttributes, db.Rebind(query), parentID)
+	return productAttributes, err
+}
+
+// StoreProductAttribute is to store attribute of product into database
+// attribute which has the same name replaces the previous value
+func (intr Internal) StoreProductAttribute(ctx context.Context, tx *sql.Tx, productAttribute ProductAttribute) error {
+	query := `INSERT OR REPLACE INTO product_attribute
+					(
+						product_id,
+						name,
+						value
+					)
+			VALUES (
+						?,
+						?,
+						?
+					)
+			`
+
+	_, err := tx.ExecContext(ctx, query,
+		productAttribute.ProductID,
+		productAttribute.Name,
+		productAttribute.Value,
+	)
+	return err
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"math"
+)
+
+// DefaultUnit is base unit of product which doesn't specify its unit
+const DefaultUnit = "pcs"
+
+// ProductUnit is entity that represent schema on table product_unit
+// Factor is quantity of base unit of product which is contained by one unit (e.g. dozen is 12 pcs)
+type ProductUnit struct {
+	ProductUnitID int64  `db:"product_unit_id" json:"product_unit_id"`
+	ProductID     int64  `db:"product_id" json:"product_id"`
+	Name          string `db:"name" json:"name"`
+	Factor        int    `db:"factor" json:"factor"`
+}
+
+// GetProductUnitByProductID is used to get all unit of product, the smallest first
+func (intr Internal) GetProductUnitByProductID(ctx context.Context, productID int64) ([]ProductUnit, error) {
+	var productUnits []ProductUnit
+
+	query := `
+	SELECT
+		product_unit_id,
+		product_id,
+		name,
+		factor
+	FROM product_unit
+	WHERE
+		product_id = ?
+	ORDER BY factor, product_unit_id
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &productUnits, db.Rebind(query), productID)
+	return productUnits, err
+}
+
+// StoreProductUnit is to store unit of product into database
+func (intr Internal) StoreProductUnit(ctx context.Context, tx *sql.Tx, productUnit ProductUnit) error {
+	query := `INSERT INTO product_unit
+					(
+						product_id,
+						name,
+						factor
+					)
+			VALUES (
+						?,
+						?,
+						?
+					)
+			`
+
+	_, err := tx.ExecContext(ctx, query,
+		productUnit.ProductID,
+		productUnit.Name,
+		productUnit.Factor,
+	)
+	return err
+}
+
+// DeleteProductUnitByProductID is to delete all unit of product from database
+func (intr Internal) DeleteProductUnitByProductID(ctx context.Context, tx *sql.Tx, productID int64) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM product_unit WHERE product_id = ?", productID)
+	return err
+}
+
+// unitQuantity is used to convert quantity of base unit into quantity of unit which contains factor of base unit
+// rounded into 2 decimal places, since the quantity may not be a whole unit (e.g. 18 pcs is 1.5 dozen)
+func unitQuantity(quantity, factor int) float64 {
+	if factor <= 1 {
+		return float64(quantity)
+	}
+
+	return math.Round(float64(quantity)/float64(factor)*100) / 100
+}
@@ -8,33 +8,55 @@ import (
 )
 
 // Purchase is entity that represent schema on table purchase
+// QuantityOrder, QuantityAccepted and Cost are kept in base unit of product,
+// Unit is unit which the purchase is requested in and UnitFactor converts it into base unit,
+// UnitQuantityOrder and UnitQuantityAccepted show quantity in the unit of purchase
+// and UnitCost is cost as it is inputed in the unit of purchase (Cost of base unit is rounded)
 type Purchase struct {
-	PurchaseID       int64     `db:"purchase_id" json:"purchase_id"`
-	ProductID        int64     `db:"product_id" json:"product_id"`
-	QuantityOrder    int       `db:"quantity_order" json:"quantity_order"`
-	QuantityAccepted int       `db:"quantity_accepted" json:"quantity_accepted"`
-	Description      string    `db:"description" json:"description"`
-	InvoiceNumber    string    `db:"invoice_number" json:"invoice_number"`
-	Cost             int64     `db:"cost" json:"cost"`
-	Date             time.Time `db:"date" json:"-"`
-	DateStr          string    `db:"date_str" json:"date"`
-	IsFinish         bool      `db:"is_finish" json:"is_finish"`
-	Total            int64     `json:"total"`
+	PurchaseID           int64     `db:"purchase_id" json:"purchase_id"`
+	ProductID            int64     `db:"product_id" json:"product_id"`
+	QuantityOrder        int       `db:"quantity_order" json:"quantity_order"`
+	QuantityAccepted     int       `db:"quantity_accepted" json:"quantity_accepted"`
+	Description          string    `db:"description" json:"description"`
+	InvoiceNumber        string    `db:"invoice_number" json:"invoice_number"`
+	Cost                 int64     `db:"cost" json:"cost"`
+	Date                 time.Time `db:"date" json:"-"`
+	DateStr              string    `db:"date_str" json:"date"`
+	IsFinish             bool      `db:"is_finish" json:"is_finish"`
+	SupplierID           int64     `db:"supplier_id" json:"supplier_id"`
+	Total                int64     `json:"total"`
+	Unit                 string    `db:"unit" json:"unit"`
+	UnitFactor           int       `db:"unit_factor" json:"unit_factor"`
+	UnitQuantityOrder    float64   `json:"unit_quantity_order"`
+	UnitQuantityAccepted float64   `json:"unit_quantity_accepted"`
+	UnitCost             int64     `db:"unit_cost" json:"unit_cost"`
 }
 
 // PurchaseDtl is entity that represent schema on table purchase_detail
+// each purchase detail is a receipt of purchase into its location
+// receipt which has LotNumber or ExpiryDate (yyyy-MM-dd) is received as a lot
+// and receipt of serialized product registers its unit by SerialNumbers
 type PurchaseDtl struct {
 	PurchaseDtlID int64     `db:"purchase_detail_id" json:"purchase_detail_id"`
 	PurchaseID    int64     `db:"purchase_id" json:"purchase_id"`
 	Quantity      int       `db:"quantity" json:"quantity"`
 	Description   string    `db:"description" json:"description"`
-	Date          time.Time `db:"date" json:"date"`
+	LocationID    int64     `db:"location_id" json:"location_id"`
+	LocationName  string    `db:"location_name" json:"location_name"`
+	LotNumber     string    `db:"lot_number" json:"lot_number"`
+	ExpiryDate    string    `db:"expiry_date" json:"expiry_date"`
+	Date          time.Time `db:"date" json:"-"`
+	DateStr       string    `db:"date_str" json:"date"`
+	SerialNumbers []string  `json:"serial_numbers,omitempty"`
 }
 
 // PurchaseWithProduct is entity of purchase with product
 type PurchaseWithProduct struct {
 	Purchase
-	Product Product `json:"product"`
+	Product  Product                      `json:"product"`
+	Supplier Supplier                     `json:"supplier"`
+	Receipts []PurchaseDtl                `json:"receipts,omitempty"`
+	Returns  []PurchaseReturnWithPurchase `json:"returns,omitempty"`
 }
 
 // this is a main query. it will be used on many place
@@ -50,158 +72,119 @@ var qSelectPurchase = `
 		purchase.cost,
 		purchase.date,
 		purchase.is_finish,
+		purchase.supplier_id,
+		purchase.unit,
+		purchase.unit_factor,
+		COALESCE(purchase.unit_cost, purchase.cost * purchase.unit_factor),
 		product.name,
 		product.sku,
-		product.stock
+		product.unit,
+		` + qProductStock + ` as stock,
+		COALESCE(supplier.name, '') as supplier_name
 	FROM purchase
 	JOIN product ON purchase.product_id = product.product_id
+	LEFT JOIN supplier ON purchase.supplier_id = supplier.supplier_id
 `
 
 // GetPurchaseWithProduct is used to get purchased with product
 func (intr Internal) GetPurchaseWithProduct(ctx context.Context) ([]PurchaseWithProduct, error) {
-	var (
-		purchaseWithProducts []PurchaseWithProduct
-		query                string
-	)
+	query := qSelectPurchase
 
-	query = qSelectPurchase
+	return intr.selectPurchaseWithProduct(ctx, query)
+}
 
-	db := intr.Storage.DB
-	row, err := db.QueryxContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	for row.Next() {
-		purchaseWithProduct := PurchaseWithProduct{}
-		err := row.Scan(
-			&purchaseWithProduct.PurchaseID,
-			&purchaseWithProduct.ProductID,
-			&purchaseWithProduct.QuantityOrder,
-			&purchaseWithProduct.QuantityAccepted,
-			&purchaseWithProduct.Description,
-			&purchaseWithProduct.InvoiceNumber,
-			&purchaseWithProduct.Cost,
-			&purchaseWithProduct.DateStr,
-			&purchaseWithProduct.IsFinish,
-			&purchaseWithProduct.Product.Name,
-			&purchaseWithProduct.Product.Sku,
-			&purchaseWithProduct.Product.Stock,
-		)
+// GetOutstandingPurchaseWithProduct is used to get purchase with product
+// which received quantity is still below ordered quantity, the oldest first
+func (intr Internal) GetOutstandingPurchaseWithProduct(ctx context.Context) ([]PurchaseWithProduct, error) {
+	query := qSelectPurchase
+	query += `WHERE
+				purchase.quantity_accepted < purchase.quantity_order
+			ORDER BY purchase.date, purchase.purchase_id
+			`
 
-		if err != nil {
-			return nil, err
-		}
+	return intr.selectPurchaseWithProduct(ctx, query)
+}
 
-		purchaseWithProduct.Product.ProductID = purchaseWithProduct.ProductID
+// GetPurchaseWithProductByDate is used to get purchased with product by date
+func (intr Internal) GetPurchaseWithProductByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]PurchaseWithProduct, error) {
+	dateStartMidnight, dateEndMidnight := purchaseDateRange(dateStart, dateEnd)
 
-		// convert date string into date time.Time
-		// spit date string to remove character +00:00
-		splitDateStr := strings.Split(purchaseWithProduct.DateStr, "+")
-		DateStr := strings.Trim(splitDateStr[0], " ")
-		purchaseWithProduct.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
-		if err != nil {
-			return nil, err
-		}
+	query := qSelectPurchase
+	query += `WHERE
+		purchase.date > ? AND purchase.date <= ?
+	`
 
-		// using date format: yyyy-MM-dd HH:mm:ss
-		// to standarize date convenient
-		purchaseWithProduct.DateStr = purchaseWithProduct.Date.Format("2006-01-02 15:04:05")
+	return intr.selectPurchaseWithProduct(ctx, query, dateStartMidnight, dateEndMidnight)
+}
 
-		purchaseWithProducts = append(purchaseWithProducts, purchaseWithProduct)
+// GetPurchaseWithProductBySupplierID is used to get purchased with product of one supplier
+// zero dateStart and dateEnd means purchase of all date
+func (intr Internal) GetPurchaseWithProductBySupplierID(ctx context.Context, supplierID int64, dateStart, dateEnd time.Time) ([]PurchaseWithProduct, error) {
+	args := []interface{}{supplierID}
 
+	query := qSelectPurchase
+	query += `WHERE
+		purchase.supplier_id = ?
+	`
+	if dateStart != (time.Time{}) && dateEnd != (time.Time{}) {
+		dateStartMidnight, dateEndMidnight := purchaseDateRange(dateStart, dateEnd)
+		query += `AND purchase.date > ? AND purchase.date <= ?
+		`
+		args = append(args, dateStartMidnight, dateEndMidnight)
 	}
 
-	return purchaseWithProducts, nil
+	return intr.selectPurchaseWithProduct(ctx, query, args...)
 }
 
-// GetPurchaseWithProductByDate is used to get purchased with product by date
-func (intr Internal) GetPurchaseWithProductByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]PurchaseWithProduct, error) {
-	var (
-		purchaseWithProducts []PurchaseWithProduct
-		query                string
-	)
+// GetPurchaseWithProductByID is used to get purchased with product by ID
+func (intr Internal) GetPurchaseWithProductByID(ctx context.Context, ID int64) (PurchaseWithProduct, error) {
+	query := qSelectPurchase
+	query += `WHERE
+				purchase.purchase_id = ?
+			`
 
-	dateStartMidnight := time.Date(
-		dateStart.Year(),
-		time.Month(dateStart.Month()),
-		dateStart.Day(),
-		0, 0, 0, 0, time.UTC,
-	)
+	db := intr.Storage.DB
+	row := db.QueryRowxContext(ctx, query, ID)
+	purchaseWithProduct, err := scanPurchaseWithProduct(row)
 
-	dateEndMidnight := time.Date(
-		dateEnd.Year(),
-		time.Month(dateEnd.Month()),
-		dateEnd.Day(),
-		23, 59, 59, 0, time.UTC,
-	)
+	// keep returning value but with empty struct
+	// since no rows is not error in a system
+	if err == sql.ErrNoRows {
+		return PurchaseWithProduct{}, nil
+	}
 
-	query = qSelectPurchase
-	query += `WHERE
-		purchase.date > ? AND purchase.date <= ?
-	`
+	if err != nil {
+		return PurchaseWithProduct{}, err
+	}
+
+	return purchaseWithProduct, nil
+}
+
+func (intr Internal) selectPurchaseWithProduct(ctx context.Context, query string, args ...interface{}) ([]PurchaseWithProduct, error) {
+	var purchaseWithProducts []PurchaseWithProduct
 
 	db := intr.Storage.DB
-	row, err := db.QueryxContext(ctx, query, dateStartMidnight, dateEndMidnight)
+	row, err := db.QueryxContext(ctx, db.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
 	for row.Next() {
-		purchaseWithProduct := PurchaseWithProduct{}
-		err := row.Scan(
-			&purchaseWithProduct.PurchaseID,
-			&purchaseWithProduct.ProductID,
-			&purchaseWithProduct.QuantityOrder,
-			&purchaseWithProduct.QuantityAccepted,
-			&purchaseWithProduct.Description,
-			&purchaseWithProduct.InvoiceNumber,
-			&purchaseWithProduct.Cost,
-			&purchaseWithProduct.DateStr,
-			&purchaseWithProduct.IsFinish,
-			&purchaseWithProduct.Product.Name,
-			&purchaseWithProduct.Product.Sku,
-			&purchaseWithProduct.Product.Stock,
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		purchaseWithProduct.Product.ProductID = purchaseWithProduct.ProductID
-
-		// convert date string into date time.Time
-		// spit date string to remove character +00:00
-		// date format: yyyy-MM-dd HH:mm:ss
-		splitDateStr := strings.Split(purchaseWithProduct.DateStr, "+")
-		DateStr := strings.Trim(splitDateStr[0], " ")
-		purchaseWithProduct.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		purchaseWithProduct, err := scanPurchaseWithProduct(row)
 		if err != nil {
 			return nil, err
 		}
 
-		// using date format: yyyy-MM-dd HH:mm:ss
-		// to standarize date convenient
-		purchaseWithProduct.DateStr = purchaseWithProduct.Date.Format("2006-01-02 15:04:05")
-
 		purchaseWithProducts = append(purchaseWithProducts, purchaseWithProduct)
-
 	}
 
 	return purchaseWithProducts, nil
 }
 
-// GetPurchaseWithProductByID is used to get purchased with product by ID
-func (intr Internal) GetPurchaseWithProductByID(ctx context.Context, ID int64) (PurchaseWithProduct, error) {
-	var (
-		purchaseWithProduct PurchaseWithProduct
-		query               string
-	)
-	query = qSelectPurchase
-	query += `WHERE
-				purchase.purchase_id = ?
-			`
-
-	db := intr.Storage.DB
-	row := db.QueryRowxContext(ctx, query, ID)
+// scanPurchaseWithProduct is to scan one row of qSelectPurchase
+func scanPurchaseWithProduct(row interface {
+	Scan(dest ...interface{}) error
+}) (PurchaseWithProduct, error) {
+	purchaseWithProduct := PurchaseWithProduct{}
 	err := row.Scan(
 		&purchaseWithProduct.PurchaseID,
 		&purchaseWithProduct.ProductID,
@@ -212,22 +195,30 @@ func (intr Internal) GetPurchaseWithProductByID(ctx context.Context, ID int64) (
 		&purchaseWithProduct.Cost,
 		&purchaseWithProduct.DateStr,
 		&purchaseWithProduct.IsFinish,
+		&purchaseWithProduct.SupplierID,
+		&purchaseWithProduct.Unit,
+		&purchaseWithProduct.UnitFactor,
+		&purchaseWithProduct.UnitCost,
 		&purchaseWithProduct.Product.Name,
 		&purchaseWithProduct.Product.Sku,
+		&purchaseWithProduct.Product.Unit,
 		&purchaseWithProduct.Product.Stock,
+		&purchaseWithProduct.Supplier.Name,
 	)
-
-	// keep returning value but with empty struct
-	// since no rows is not error in a system
-	if err == sql.ErrNoRows {
-		return PurchaseWithProduct{}, nil
-	}
-
 	if err != nil {
 		return PurchaseWithProduct{}, err
 	}
 
 	purchaseWithProduct.Product.ProductID = purchaseWithProduct.ProductID
+	purchaseWithProduct.Supplier.SupplierID = purchaseWithProduct.SupplierID
+
+	// purchase which was recorded before unit of measure is introduced is in base unit
+	if purchaseWithProduct.Unit == "" || purchaseWithProduct.UnitFactor <= 0 {
+		purchaseWithProduct.Unit = purchaseWithProduct.Product.Unit
+		purchaseWithProduct.UnitFactor = 1
+	}
+	purchaseWithProduct.UnitQuantityOrder = unitQuantity(purchaseWithProduct.QuantityOrder, purchaseWithProduct.UnitFactor)
+	purchaseWithProduct.UnitQuantityAccepted = unitQuantity(purchaseWithProduct.QuantityAccepted, purchaseWithProduct.UnitFactor)
 
 	// convert date string into date time.Time
 	// spit date string to remove character +00:00
@@ -246,6 +237,25 @@ func (intr Internal) GetPurchaseWithProductByID(ctx context.Context, ID int64) (
 	return purchaseWithProduct, nil
 }
 
+// purchaseDateRange is to make date range from midnight of dateStart until the end of dateEnd
+func purchaseDateRange(dateStart, dateEnd time.Time) (time.Time, time.Time) {
+	dateStartMidnight := time.Date(
+		dateStart.Year(),
+		time.Month(dateStart.Month()),
+		dateStart.Day(),
+		0, 0, 0, 0, time.UTC,
+	)
+
+	dateEndMidnight := time.Date(
+		dateEnd.Year(),
+		time.Month(dateEnd.Month()),
+		dateEnd.Day(),
+		23, 59, 59, 0, time.UTC,
+	)
+
+	return dateStartMidnight, dateEndMidnight
+}
+
 // StorePurchase is to store purchase into database
 func (intr Internal) StorePurchase(ctx context.Context, tx *sql.Tx, purchase Purchase) (ID int64, err error) {
 	var args []interface{}
@@ -258,7 +268,11 @@ func (intr Internal) StorePurchase(ctx context.Context, tx *sql.Tx, purchase Pur
 						invoice_number,
 						cost,
 						date,
-						is_finish 
+						is_finish,
+						supplier_id,
+						unit,
+						unit_factor,
+						unit_cost
 					)
 			VALUES (
 						?, 
@@ -268,7 +282,11 @@ func (intr Internal) StorePurchase(ctx context.Context, tx *sql.Tx, purchase Pur
 						?,
 						?,
 						?,
-						?						
+						?,
+						?,
+						?,
+						?,
+						?
 					)
 			`
 	args = append(args,
@@ -280,6 +298,10 @@ func (intr Internal) StorePurchase(ctx context.Context, tx *sql.Tx, purchase Pur
 		purchase.Cost,
 		purchase.Date,
 		purchase.IsFinish,
+		purchase.SupplierID,
+		purchase.Unit,
+		purchase.UnitFactor,
+		purchase.UnitCost,
 	)
 
 	if purchase.PurchaseID != 0 {
@@ -292,7 +314,11 @@ func (intr Internal) StorePurchase(ctx context.Context, tx *sql.Tx, purchase Pur
 						invoice_number = ?,
 						cost = ?,
 						date = ?,
-						is_finish = ?
+						is_finish = ?,
+						supplier_id = ?,
+						unit = ?,
+						unit_factor = ?,
+						unit_cost = ?
 				WHERE 
 						purchase_id = ?
 						 
@@ -313,6 +339,141 @@ func (intr Internal) StorePurchase(ctx context.Context, tx *sql.Tx, purchase Pur
 	return purchase.PurchaseID, err
 }
 
+// GetPurchaseDtlByPurchaseID is used to get all purchase detail (receipt) of purchase
+// ordered by date of receipt
+func (intr Internal) GetPurchaseDtlByPurchaseID(ctx context.Context, purchaseID int64) ([]PurchaseDtl, error) {
+	var purchaseDtls []PurchaseDtl
+
+	query := `
+	SELECT
+		purchase_detail.purchase_detail_id,
+		purchase_detail.purchase_id,
+		purchase_detail.quantity,
+		COALESCE(purchase_detail.description, '') as description,
+		purchase_detail.location_id,
+		COALESCE(location.name, '') as location_name,
+		purchase_detail.lot_number,
+		purchase_detail.expiry_date,
+		purchase_detail.date as date_str
+	FROM purchase_detail
+	LEFT JOIN location ON purchase_detail.location_id = location.location_id
+	WHERE
+		purchase_detail.purchase_id = ?
+	ORDER BY purchase_detail.date, purchase_detail.purchase_detail_id
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &purchaseDtls, db.Rebind(query), purchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, purchaseDtl := range purchaseDtls {
+		// spit date string to remove character +00:00
+		// date format: yyyy-MM-dd HH:mm:ss
+		splitDateStr := strings.Split(purchaseDtl.DateStr, "+")
+		DateStr := strings.Trim(splitDateStr[0], " ")
+		purchaseDtls[index].Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		// using date format: yyyy-MM-dd HH:mm:ss
+		// to standarize date convenient
+		purchaseDtls[index].DateStr = purchaseDtls[index].Date.Format("2006-01-02 15:04:05")
+	}
+
+	return purchaseDtls, nil
+}
+
+// GetPurchaseDtlByIDWithTx is used to get receipt of purchase by ID within transaction
+func (intr Internal) GetPurchaseDtlByIDWithTx(ctx context.Context, tx *sql.Tx, ID int64) (PurchaseDtl, error) {
+	var purchaseDtl PurchaseDtl
+
+	query := `
+	SELECT
+		purchase_detail_id,
+		purchase_id,
+		quantity,
+		COALESCE(description, ''),
+		location_id,
+		lot_number,
+		expiry_date
+	FROM purchase_detail
+	WHERE
+		purchase_detail_id = ?
+	`
+
+	err := tx.QueryRowContext(ctx, query, ID).Scan(
+		&purchaseDtl.PurchaseDtlID,
+		&purchaseDtl.PurchaseID,
+		&purchaseDtl.Quantity,
+		&purchaseDtl.Description,
+		&purchaseDtl.LocationID,
+		&purchaseDtl.LotNumber,
+		&purchaseDtl.ExpiryDate,
+	)
+
+	// keep returning value but with empty struct
+	// since no rows is not error in a system
+	if err == sql.ErrNoRows {
+		return PurchaseDtl{}, nil
+	}
+
+	return purchaseDtl, err
+}
+
+// SumPurchaseDtlQuantityWithTx is used to sum received quantity of purchase within transaction
+func (intr Internal) SumPurchaseDtlQuantityWithTx(ctx context.Context, tx *sql.Tx, purchaseID int64) (int, error) {
+	var quantity int
+
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM purchase_detail WHERE purchase_id = ?`
+	err := tx.QueryRowContext(ctx, query, purchaseID).Scan(&quantity)
+	return quantity, err
+}
+
+// SumPurchaseDtlQuantityByLocationWithTx is used to sum received quantity of purchase
+// on each location within transaction
+func (intr Internal) SumPurchaseDtlQuantityByLocationWithTx(ctx context.Context, tx *sql.Tx, purchaseID int64) (map[int64]int, error) {
+	quantities := make(map[int64]int)
+
+	query := `SELECT location_id, SUM(quantity) FROM purchase_detail WHERE purchase_id = ? GROUP BY location_id`
+	row, err := tx.QueryContext(ctx, query, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	for row.Next() {
+		var (
+			locationID int64
+			quantity   int
+		)
+		err = row.Scan(&locationID, &quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		quantities[locationID] = quantity
+	}
+
+	return quantities, row.Err()
+}
+
+// UpdatePurchaseAccepted is to store accepted quantity and finish status of purchase
+func (intr Internal) UpdatePurchaseAccepted(ctx context.Context, tx *sql.Tx, ID int64, quantityAccepted int, isFinish bool) error {
+	query := `UPDATE purchase
+			  SET
+					quantity_accepted = ?,
+					is_finish = ?
+			  WHERE
+					purchase_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, quantityAccepted, isFinish, ID)
+	return err
+}
+
 // StorePurchaseDtl is to store purchase detail into database
 func (intr Internal) StorePurchaseDtl(ctx context.Context, tx *sql.Tx, purchaseDtl PurchaseDtl) (ID int64, err error) {
 	var args []interface{}
@@ -321,13 +482,19 @@ func (intr Internal) StorePurchaseDtl(ctx context.Context, tx *sql.Tx, purchaseD
 						purchase_id,
 						quantity,
 						description,												
-						date						
+						date,
+						location_id,
+						lot_number,
+						expiry_date
 					)
 			VALUES (
 						?, 
 						?, 
 						?,
-						?												
+						?,
+						?,
+						?,
+						?
 					)
 			`
 	args = append(args,
@@ -335,6 +502,9 @@ func (intr Internal) StorePurchaseDtl(ctx context.Context, tx *sql.Tx, purchaseD
 		purchaseDtl.Quantity,
 		purchaseDtl.Description,
 		purchaseDtl.Date,
+		purchaseDtl.LocationID,
+		purchaseDtl.LotNumber,
+		purchaseDtl.ExpiryDate,
 	)
 	if purchaseDtl.PurchaseDtlID != 0 {
 		query = `UPDATE purchase_detail 
@@ -342,7 +512,10 @@ func (intr Internal) StorePurchaseDtl(ctx context.Context, tx *sql.Tx, purchaseD
 						purchase_id = ?,
 						quantity = ?,
 						description = ?,												
-						date = ?
+						date = ?,
+						location_id = ?,
+						lot_number = ?,
+						expiry_date = ?
 				WHERE 
 						purchase_detail_id = ?
 						 
@@ -355,10 +528,10 @@ func (intr Internal) StorePurchaseDtl(ctx context.Context, tx *sql.Tx, purchaseD
 		return 0, err
 	}
 
-	if purchaseDtl.PurchaseID == 0 {
+	if purchaseDtl.PurchaseDtlID == 0 {
 		// no need to check error, since it will be occurred by database incompatibility
-		purchaseDtl.PurchaseID, _ = result.LastInsertId()
+		purchaseDtl.PurchaseDtlID, _ = result.LastInsertId()
 	}
 
-	return purchaseDtl.PurchaseID, err
+	return purchaseDtl.PurchaseDtlID, err
 }
@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// PurchaseReturn is entity that represent schema on table purchase_return
+// PurchaseDtlID is the receipt which returned goods come from, zero means unspecified receipt
+// Cost is unit cost of the returned purchase
+// SerialNumbers is unit of serialized product which is sent back to supplier
+type PurchaseReturn struct {
+	PurchaseReturnID int64     `db:"purchase_return_id" json:"purchase_return_id"`
+	PurchaseID       int64     `db:"purchase_id" json:"purchase_id"`
+	PurchaseDtlID    int64     `db:"purchase_detail_id" json:"purchase_detail_id"`
+	ProductID        int64     `db:"product_id" json:"product_id"`
+	Quantity         int       `db:"quantity" json:"quantity"`
+	Cost             int64     `db:"cost" json:"cost"`
+	Description      string    `db:"description" json:"description"`
+	Date             time.Time `db:"date" json:"-"`
+	DateStr          string    `db:"date_str" json:"date"`
+	Total            int64     `json:"total"`
+	SerialNumbers    []string  `json:"serial_numbers,omitempty"`
+}
+
+// PurchaseReturnWithPurchase is entity of purchase return with its purchase, product and supplier
+type PurchaseReturnWithPurchase struct {
+	PurchaseReturn
+	Purchase Purchase `json:"purchase"`
+	Product  Product  `json:"product"`
+	Supplier Supplier `json:"supplier"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectPurchaseReturn = `
+	SELECT
+		purchase_return.purchase_return_id,
+		purchase_return.purchase_id,
+		purchase_return.purchase_detail_id,
+		purchase_return.product_id,
+		purchase_return.quantity,
+		purchase_return.cost,
+		COALESCE(purchase_return.description, ''),
+		purchase_return.date as date_str,
+		purchase.quantity_order,
+		purchase.quantity_accepted,
+		purchase.invoice_number,
+		purchase.cost,
+		purchase.date,
+		purchase.supplier_id,
+		product.name,
+		product.sku,
+		product.unit,
+		` + qProductStock + ` as stock,
+		COALESCE(supplier.name, '') as supplier_name
+	FROM purchase_return
+	JOIN purchase ON purchase_return.purchase_id = purchase.purchase_id
+	JOIN product ON purchase_return.product_id = product.product_id
+	LEFT JOIN supplier ON purchase.supplier_id = supplier.supplier_id
+`
+
+// GetPurchaseReturnWithPurchase is used to get all purchase return with its purchase
+func (intr Internal) GetPurchaseReturnWithPurchase(ctx context.Context) ([]PurchaseReturnWithPurchase, error) {
+	query := qSelectPurchaseReturn
+	query += `ORDER BY purchase_return.date, purchase_return.purchase_return_id
+	`
+
+	return intr.selectPurchaseReturnWithPurchase(ctx, query)
+}
+
+// GetPurchaseReturnWithPurchaseByPurchaseID is used to get all return of purchase
+func (intr Internal) GetPurchaseReturnWithPurchaseByPurchaseID(ctx context.Context, purchaseID int64) ([]PurchaseReturnWithPurchase, error) {
+	query := qSelectPurchaseReturn
+	query += `WHERE
+		purchase_return.purchase_id = ?
+	ORDER BY purchase_return.date, purchase_return.purchase_return_id
+	`
+
+	return intr.selectPurchaseReturnWithPurchase(ctx, query, purchaseID)
+}
+
+// GetPurchaseReturnWithPurchaseByID is used to get purchase return with its purchase by ID
+func (intr Internal) GetPurchaseReturnWithPurchaseByID(ctx context.Context, ID int64) (PurchaseReturnWithPurchase, error) {
+	query := qSelectPurchaseReturn
+	query += `WHERE
+		purchase_return.purchase_return_id = ?
+	`
+
+	purchaseReturns, err := intr.selectPurchaseReturnWithPurchase(ctx, query, ID)
+	if err != nil || len(purchaseReturns) == 0 {
+		return PurchaseReturnWithPurchase{}, err
+	}
+
+	return purchaseReturns[0], nil
+}
+
+func (intr Internal) selectPurchaseReturnWithPurchase(ctx context.Context, query string, args ...interface{}) ([]PurchaseReturnWithPurchase, error) {
+	var purchaseReturns []PurchaseReturnWithPurchase
+
+	db := intr.Storage.DB
+	row, err := db.QueryxContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for row.Next() {
+		purchaseReturn := PurchaseReturnWithPurchase{}
+		err = row.Scan(
+			&purchaseReturn.PurchaseReturnID,
+			&purchaseReturn.PurchaseID,
+			&purchaseReturn.PurchaseDtlID,
+			&purchaseReturn.ProductID,
+			&purchaseReturn.Quantity,
+			&purchaseReturn.Cost,
+			&purchaseReturn.Description,
+			&purchaseReturn.DateStr,
+			&purchaseReturn.Purchase.QuantityOrder,
+			&purchaseReturn.Purchase.QuantityAccepted,
+			&purchaseReturn.Purchase.InvoiceNumber,
+			&purchaseReturn.Purchase.Cost,
+			&purchaseReturn.Purchase.DateStr,
+			&purchaseReturn.Purchase.SupplierID,
+			&purchaseReturn.Product.Name,
+			&purchaseReturn.Product.Sku,
+			&purchaseReturn.Product.Unit,
+			&purchaseReturn.Product.Stock,
+			&purchaseReturn.Supplier.Name,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		purchaseReturn.Purchase.PurchaseID = purchaseReturn.PurchaseID
+		purchaseReturn.Purchase.ProductID = purchaseReturn.ProductID
+		purchaseReturn.Product.ProductID = purchaseReturn.ProductID
+		purchaseReturn.Supplier.SupplierID = purchaseReturn.Purchase.SupplierID
+
+		// convert date string into date time.Time
+		// spit date string to remove character +00:00
+		// date format: yyyy-MM-dd HH:mm:ss
+		splitDateStr := strings.Split(purchaseReturn.DateStr, "+")
+		DateStr := strings.Trim(splitDateStr[0], " ")
+		purchaseReturn.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		splitDateStr = strings.Split(purchaseReturn.Purchase.DateStr, "+")
+		DateStr = strings.Trim(splitDateStr[0], " ")
+		purchaseReturn.Purchase.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		// using date format: yyyy-MM-dd HH:mm:ss
+		// to standarize date convenient
+		purchaseReturn.DateStr = purchaseReturn.Date.Format("2006-01-02 15:04:05")
+		purchaseReturn.Purchase.DateStr = purchaseReturn.Purchase.Date.Format("2006-01-02 15:04:05")
+
+		purchaseReturns = append(purchaseReturns, purchaseReturn)
+	}
+
+	return purchaseReturns, nil
+}
+
+// SumPurchaseReturnQuantityWithTx is used to get returned quantity of purchase within transaction
+// zero purchaseDtlID means returned quantity of all receipt
+func (intr Internal) SumPurchaseReturnQuantityWithTx(ctx context.Context, tx *sql.Tx, purchaseID, purchaseDtlID int64) (int, error) {
+	var quantity int
+	args := []interface{}{purchaseID}
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM purchase_return WHERE purchase_id = ?`
+	if purchaseDtlID != 0 {
+		query += ` AND purchase_detail_id = ?`
+		args = append(args, purchaseDtlID)
+	}
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&quantity)
+	return quantity, err
+}
+
+// StorePurchaseReturn is to store purchase return into database
+func (intr Internal) StorePurchaseReturn(ctx context.Context, tx *sql.Tx, purchaseReturn PurchaseReturn) (ID int64, err error) {
+	query := `INSERT INTO purchase_return
+					(
+						purchase_id,
+						purchase_detail_id,
+						product_id,
+						quantity,
+						cost,
+						description,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		purchaseReturn.PurchaseID,
+		purchaseReturn.PurchaseDtlID,
+		purchaseReturn.ProductID,
+		purchaseReturn.Quantity,
+		purchaseReturn.Cost,
+		purchaseReturn.Description,
+		purchaseReturn.Date,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 // ProductAvgValue is entity of product with average value
@@ -12,6 +13,44 @@ type ProductAvgValue struct {
 	Total       int `json:"total"`
 }
 
+// each purchase is weighted by its accepted quantity which has not been returned to supplier
+// relative to its accepted quantity, so purchase without return keeps weight one (plain average),
+// partially returned purchase is counted by its remaining portion and fully returned purchase is not counted
+var (
+	qPurchaseWeight = `(
+		SELECT
+			purchase.product_id,
+			purchase.cost,
+			CASE
+				WHEN purchase.quantity_accepted > 0 THEN (purchase.quantity_accepted - COALESCE((
+					SELECT SUM(purchase_return.quantity)
+					FROM purchase_return
+					WHERE purchase_return.purchase_id = purchase.purchase_id
+				), 0)) * 1.0 / purchase.quantity_accepted
+				ELSE 1
+			END as weight
+		FROM purchase
+	) as purchase_weight`
+
+	qPurchaseWeightByDate = `(
+		SELECT
+			purchase.product_id,
+			purchase.cost,
+			CASE
+				WHEN purchase.quantity_accepted > 0 THEN (purchase.quantity_accepted - COALESCE((
+					SELECT SUM(purchase_return.quantity)
+					FROM purchase_return
+					WHERE purchase_return.purchase_id = purchase.purchase_id AND purchase_return.date <= ?
+				), 0)) * 1.0 / purchase.quantity_accepted
+				ELSE 1
+			END as weight
+		FROM purchase
+		WHERE purchase.date <= ?
+	) as purchase_weight`
+
+	qAverageCost = `COALESCE(ROUND(SUM(purchase_weight.cost * purchase_weight.weight) / NULLIF(SUM(purchase_weight.weight), 0)), 0)`
+)
+
 // GetProductAvgValue is to get report of product with average value
 func (intr Internal) GetProductAvgValue(ctx context.Context) ([]ProductAvgValue, error) {
 	var (
@@ -24,10 +63,14 @@ func (intr Internal) GetProductAvgValue(ctx context.Context) ([]ProductAvgValue,
 		product.product_id as product_id,
 		product.sku as sku,
 		product.name as name,
-		product.stock as stock,				
-		COALESCE(ROUND(AVG(purchase.cost)), 0) as average_cost		
+		product.unit as unit,
+		product.parent_id as parent_id,
+		` + qProductStock + ` as stock,				
+		` + qAverageCost + ` as average_cost		
 	FROM product
-	LEFT JOIN purchase ON product.product_id = purchase.product_id	
+	LEFT JOIN ` + qPurchaseWeight + ` ON product.product_id = purchase_weight.product_id
+	WHERE
+		product.is_bundle = 0
 	GROUP BY product.product_id
 	`
 
@@ -41,6 +84,43 @@ func (intr Internal) GetProductAvgValue(ctx context.Context) ([]ProductAvgValue,
 
 }
 
+// GetProductAvgValueByDate is to get report of product with average value as of dateEnd
+// stock is taken from stock movement and average value from purchase until dateEnd
+func (intr Internal) GetProductAvgValueByDate(ctx context.Context, dateEnd time.Time) ([]ProductAvgValue, error) {
+	var (
+		productsWithAvgValue []ProductAvgValue
+		query                string
+	)
+
+	query = `
+	SELECT 
+		product.product_id as product_id,
+		product.sku as sku,
+		product.name as name,
+		product.unit as unit,
+		product.parent_id as parent_id,
+		COALESCE((
+			SELECT SUM(stock_movement.quantity) 
+			FROM stock_movement 
+			WHERE stock_movement.product_id = product.product_id AND stock_movement.date <= ?
+		), 0) as stock,
+		` + qAverageCost + ` as average_cost
+	FROM product
+	LEFT JOIN ` + qPurchaseWeightByDate + ` ON product.product_id = purchase_weight.product_id
+	WHERE
+		product.is_bundle = 0
+	GROUP BY product.product_id
+	`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &productsWithAvgValue, db.Rebind(query), dateEnd, dateEnd, dateEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return productsWithAvgValue, nil
+}
+
 // GetProductAvgValueByProductID is to get report of product with average value by productID
 func (intr Internal) GetProductAvgValueByProductID(ctx context.Context, productID int64) (ProductAvgValue, error) {
 	var (
@@ -53,12 +133,15 @@ func (intr Internal) GetProductAvgValueByProductID(ctx context.Context, productI
 		product.product_id as product_id,
 		product.sku as sku,
 		product.name as name,
-		product.stock as stock,				
-		COALESCE(ROUND(AVG(purchase.cost)), 0) as average_cost		
+		product.unit as unit,
+		product.parent_id as parent_id,
+		` + qProductStock + ` as stock,				
+		` + qAverageCost + ` as average_cost		
 	FROM product	
-	LEFT JOIN purchase ON product.product_id = purchase.product_id	
+	LEFT JOIN ` + qPurchaseWeight + ` ON product.product_id = purchase_weight.product_id
 	WHERE 
-		product.product_id = ?
+		product.product_id = ? AND
+		product.is_bundle = 0
 	GROUP BY product.product_id
 	`
 
@@ -74,3 +157,41 @@ func (intr Internal) GetProductAvgValueByProductID(ctx context.Context, productI
 	return productWithAvgValue, err
 
 }
+
+// GetProductAvgValueByProductIDAndDate is to get report of product with average value by productID
+// which only calculates purchase until dateEnd
+func (intr Internal) GetProductAvgValueByProductIDAndDate(ctx context.Context, productID int64, dateEnd time.Time) (ProductAvgValue, error) {
+	var (
+		productWithAvgValue ProductAvgValue
+		query               string
+	)
+
+	query = `
+	SELECT 
+		product.product_id as product_id,
+		product.sku as sku,
+		product.name as name,
+		product.unit as unit,
+		product.parent_id as parent_id,
+		` + qProductStock + ` as stock,
+		` + qAverageCost + ` as average_cost
+	FROM product
+	LEFT JOIN ` + qPurchaseWeightByDate + ` ON product.product_id = purchase_weight.product_id
+	WHERE 
+		product.product_id = ? AND
+		product.is_bundle = 0
+	GROUP BY product.product_id
+	`
+
+	db := intr.Storage.DB
+	row := db.QueryRowxContext(ctx, db.Rebind(query), dateEnd, dateEnd, productID)
+	err := row.StructScan(&productWithAvgValue)
+
+	// pass if sql no rows error
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+
+	return productWithAvgValue, err
+
+}
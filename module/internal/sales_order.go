@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// status of sales order
+const (
+	SalesOrderConfirmed = "confirmed"
+	SalesOrderCompleted = "completed"
+)
+
+// SalesOrder is entity that represent schema on table sales_order
+type SalesOrder struct {
+	SalesOrderID int64     `db:"sales_order_id" json:"sales_order_id"`
+	OrderNumber  string    `db:"order_number" json:"order_number"`
+	CustomerID   int64     `db:"customer_id" json:"customer_id"`
+	Customer     string    `db:"customer" json:"customer"`
+	Status       string    `db:"status" json:"status"`
+	Notes        string    `db:"notes" json:"notes"`
+	Date         time.Time `db:"date" json:"-"`
+	DateStr      string    `db:"date_str" json:"date"`
+	TotalLine    int       `db:"total_line" json:"total_line"`
+	Total        int64     `db:"total" json:"total"`
+}
+
+// SalesOrderWithLine is entity of sales order with its lines
+type SalesOrderWithLine struct {
+	SalesOrder
+	Lines []OrderWithProduct `json:"lines"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectSalesOrder = `
+	SELECT
+		sales_order.sales_order_id,
+		sales_order.order_number,
+		sales_order.customer_id,
+		sales_order.customer,
+		sales_order.status,
+		COALESCE(sales_order.notes, '') as notes,
+		sales_order.date as date_str,
+		COUNT(orders.order_id) as total_line,
+		COALESCE(SUM(COALESCE(orders.unit_price * orders.quantity / orders.unit_factor, orders.price * orders.quantity)), 0) as total
+	FROM sales_order
+	LEFT JOIN orders ON sales_order.sales_order_id = orders.sales_order_id
+`
+
+// GetSalesOrder is used to get all sales order
+func (intr Internal) GetSalesOrder(ctx context.Context) ([]SalesOrder, error) {
+	var (
+		salesOrders []SalesOrder
+		query       string
+	)
+
+	query = qSelectSalesOrder
+	query += `GROUP BY sales_order.sales_order_id
+			ORDER BY sales_order.date, sales_order.sales_order_id
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &salesOrders, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range salesOrders {
+		err = parseSalesOrderDate(&salesOrders[index])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return salesOrders, nil
+}
+
+// GetSalesOrderByID is used to get sales order by ID
+func (intr Internal) GetSalesOrderByID(ctx context.Context, ID int64) (SalesOrder, error) {
+	query := qSelectSalesOrder
+	query += `WHERE
+				sales_order.sales_order_id = ?
+			GROUP BY sales_order.sales_order_id
+			`
+
+	return intr.getSalesOrder(ctx, query, ID)
+}
+
+// GetSalesOrderByOrderNumber is used to get sales order by its order number
+func (intr Internal) GetSalesOrderByOrderNumber(ctx context.Context, orderNumber string) (SalesOrder, error) {
+	query := qSelectSalesOrder
+	query += `WHERE
+				sales_order.order_number = ?
+			GROUP BY sales_order.sales_order_id
+			`
+
+	return intr.getSalesOrder(ctx, query, orderNumber)
+}
+
+func (intr Internal) getSalesOrder(ctx context.Context, query string, args ...interface{}) (SalesOrder, error) {
+	var salesOrder SalesOrder
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &salesOrder, db.Rebind(query), args...)
+
+	// keep returning value but with empty struct
+	// since no rows is not error in a system
+	if err == sql.ErrNoRows {
+		return SalesOrder{}, nil
+	}
+
+	if err != nil {
+		return SalesOrder{}, err
+	}
+
+	return salesOrder, parseSalesOrderDate(&salesOrder)
+}
+
+// parseSalesOrderDate is to convert date string of sales order into date time.Time
+func parseSalesOrderDate(salesOrder *SalesOrder) error {
+	var err error
+
+	// spit date string to remove character +00:00
+	// date format: yyyy-MM-dd HH:mm:ss
+	splitDateStr := strings.Split(salesOrder.DateStr, "+")
+	DateStr := strings.Trim(splitDateStr[0], " ")
+	salesOrder.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+	if err != nil {
+		return err
+	}
+
+	// using date format: yyyy-MM-dd HH:mm:ss
+	// to standarize date convenient
+	salesOrder.DateStr = salesOrder.Date.Format("2006-01-02 15:04:05")
+
+	return nil
+}
+
+// StoreSalesOrder is to store sales order into database
+func (intr Internal) StoreSalesOrder(ctx context.Context, tx *sql.Tx, salesOrder SalesOrder) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO sales_order
+					(
+						order_number,
+						customer_id,
+						customer,
+						status,
+						notes,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+	args = append(args,
+		salesOrder.OrderNumber,
+		salesOrder.CustomerID,
+		salesOrder.Customer,
+		salesOrder.Status,
+		salesOrder.Notes,
+		salesOrder.Date,
+	)
+	if salesOrder.SalesOrderID != 0 {
+		query = `UPDATE sales_order
+				 SET
+						order_number = ?,
+						customer_id = ?,
+						customer = ?,
+						status = ?,
+						notes = ?,
+						date = ?
+				WHERE
+						sales_order_id = ?
+		`
+		args = append(args, salesOrder.SalesOrderID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if salesOrder.SalesOrderID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		salesOrder.SalesOrderID, _ = result.LastInsertId()
+	}
+
+	return salesOrder.SalesOrderID, nil
+}
+
+// DeleteSalesOrderWithoutLine is to delete sales order which doesn't have any line anymore
+func (intr Internal) DeleteSalesOrderWithoutLine(ctx context.Context, tx *sql.Tx, ID int64) error {
+	query := `DELETE FROM sales_order
+			  WHERE
+					sales_order_id = ?
+					AND NOT EXISTS (SELECT 1 FROM orders WHERE orders.sales_order_id = sales_order.sales_order_id)
+			 `
+
+	_, err := tx.ExecContext(ctx, query, ID)
+	return err
+}
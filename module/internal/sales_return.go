@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// condition of returned item
+// damaged item goes to damaged location, it never goes back to sellable stock
+const (
+	ReturnGood    = "good"
+	ReturnDamaged = "damaged"
+)
+
+// SalesReturn is entity that represent schema on table sales_return
+// Quantity, Price and Cost are kept in base unit of product like its order line,
+// UnitPrice is refund as it is inputed in the unit of the order line (Price of base unit is rounded)
+// Cost is unit cost of the returned order line at the time it was sold
+// SerialNumbers is unit of serialized product which is returned by customer
+type SalesReturn struct {
+	SalesReturnID int64     `db:"sales_return_id" json:"sales_return_id"`
+	OrderID       int64     `db:"order_id" json:"order_id"`
+	ProductID     int64     `db:"product_id" json:"product_id"`
+	Quantity      int       `db:"quantity" json:"quantity"`
+	Price         int64     `db:"price" json:"price"`
+	Cost          int64     `db:"cost" json:"cost"`
+	UnitPrice     int64     `db:"unit_price" json:"unit_price"`
+	Condition     string    `db:"condition" json:"condition"`
+	Description   string    `db:"description" json:"description"`
+	Date          time.Time `db:"date" json:"-"`
+	DateStr       string    `db:"date_str" json:"date"`
+	Total         int64     `json:"total"`
+	SerialNumbers []string  `json:"serial_numbers,omitempty"`
+}
+
+// SalesReturnWithOrder is entity of sales return with its order line and product
+type SalesReturnWithOrder struct {
+	SalesReturn
+	Order   Order   `json:"order"`
+	Product Product `json:"product"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectSalesReturn = `
+	SELECT
+		sales_return.sales_return_id,
+		sales_return.order_id,
+		sales_return.product_id,
+		sales_return.quantity,
+		sales_return.price,
+		sales_return.cost,
+		COALESCE(sales_return.unit_price, sales_return.price * orders.unit_factor),
+		sales_return.condition,
+		COALESCE(sales_return.description, ''),
+		sales_return.date as date_str,
+		orders.sales_order_id,
+		orders.customer_id,
+		orders.order_id_format,
+		orders.quantity,
+		orders.date,
+		orders.price,
+		COALESCE(orders.cost, 0),
+		COALESCE(orders.cogs, COALESCE(orders.cost, 0) * orders.quantity),
+		orders.location_id,
+		orders.unit,
+		orders.unit_factor,
+		COALESCE(orders.unit_price, orders.price * orders.unit_factor),
+		product.name,
+		product.sku,
+		product.unit,
+		` + qProductStock + ` as stock
+	FROM sales_return
+	JOIN orders ON sales_return.order_id = orders.order_id
+	JOIN product ON sales_return.product_id = product.product_id
+`
+
+// GetSalesReturnWithOrder is used to get all sales return with its order line
+func (intr Internal) GetSalesReturnWithOrder(ctx context.Context) ([]SalesReturnWithOrder, error) {
+	query := qSelectSalesReturn
+	query += `ORDER BY sales_return.date, sales_return.sales_return_id
+	`
+
+	return intr.selectSalesReturnWithOrder(ctx, query)
+}
+
+// GetSalesReturnWithOrderByDate is used to get all sales return with its order line by filter date
+func (intr Internal) GetSalesReturnWithOrderByDate(ctx context.Context, dateStart, dateEnd time.Time) ([]SalesReturnWithOrder, error) {
+	query := qSelectSalesReturn
+	query += `WHERE
+		sales_return.date > ? AND sales_return.date <= ?
+	ORDER BY sales_return.date, sales_return.sales_return_id
+	`
+
+	dateStartMidnight := time.Date(
+		dateStart.Year(),
+		time.Month(dateStart.Month()),
+		dateStart.Day(),
+		0, 0, 0, 0, time.UTC,
+	)
+
+	dateEndMidnight := time.Date(
+		dateEnd.Year(),
+		time.Month(dateEnd.Month()),
+		dateEnd.Day(),
+		23, 59, 59, 0, time.UTC,
+	)
+
+	return intr.selectSalesReturnWithOrder(ctx, query, dateStartMidnight, dateEndMidnight)
+}
+
+// GetSalesReturnWithOrderByID is used to get sales return with its order line by ID
+func (intr Internal) GetSalesReturnWithOrderByID(ctx context.Context, ID int64) (SalesReturnWithOrder, error) {
+	query := qSelectSalesReturn
+	query += `WHERE
+		sales_return.sales_return_id = ?
+	`
+
+	salesReturns, err := intr.selectSalesReturnWithOrder(ctx, query, ID)
+	if err != nil || len(salesReturns) == 0 {
+		return SalesReturnWithOrder{}, err
+	}
+
+	return salesReturns[0], nil
+}
+
+func (intr Internal) selectSalesReturnWithOrder(ctx context.Context, query string, args ...interface{}) ([]SalesReturnWithOrder, error) {
+	var salesReturns []SalesReturnWithOrder
+
+	db := intr.Storage.DB
+	row, err := db.QueryxContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for row.Next() {
+		salesReturn := SalesReturnWithOrder{}
+		err = row.Scan(
+			&salesReturn.SalesReturnID,
+			&salesReturn.OrderID,
+			&salesReturn.ProductID,
+			&salesReturn.Quantity,
+			&salesReturn.Price,
+			&salesReturn.Cost,
+			&salesReturn.UnitPrice,
+			&salesReturn.Condition,
+			&salesReturn.Description,
+			&salesReturn.DateStr,
+			&salesReturn.Order.SalesOrderID,
+			&salesReturn.Order.CustomerID,
+			&salesReturn.Order.OrderIDFormat,
+			&salesReturn.Order.Quantity,
+			&salesReturn.Order.DateStr,
+			&salesReturn.Order.Price,
+			&salesReturn.Order.Cost,
+			&salesReturn.Order.Cogs,
+			&salesReturn.Order.LocationID,
+			&salesReturn.Order.Unit,
+			&salesReturn.Order.UnitFactor,
+			&salesReturn.Order.UnitPrice,
+			&salesReturn.Product.Name,
+			&salesReturn.Product.Sku,
+			&salesReturn.Product.Unit,
+			&salesReturn.Product.Stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		salesReturn.Order.OrderID = salesReturn.OrderID
+		salesReturn.Order.ProductID = salesReturn.ProductID
+		salesReturn.Product.ProductID = salesReturn.ProductID
+
+		// order which was recorded before unit of measure is introduced is in base unit
+		if salesReturn.Order.Unit == "" || salesReturn.Order.UnitFactor <= 0 {
+			salesReturn.Order.Unit = salesReturn.Product.Unit
+			salesReturn.Order.UnitFactor = 1
+		}
+		salesReturn.Order.UnitQuantity = unitQuantity(salesReturn.Order.Quantity, salesReturn.Order.UnitFactor)
+
+		// convert date string into date time.Time
+		// spit date string to remove character +00:00
+		// date format: yyyy-MM-dd HH:mm:ss
+		splitDateStr := strings.Split(salesReturn.DateStr, "+")
+		DateStr := strings.Trim(splitDateStr[0], " ")
+		salesReturn.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		splitDateStr = strings.Split(salesReturn.Order.DateStr, "+")
+		DateStr = strings.Trim(splitDateStr[0], " ")
+		salesReturn.Order.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		// using date format: yyyy-MM-dd HH:mm:ss
+		// to standarize date convenient
+		salesReturn.DateStr = salesReturn.Date.Format("2006-01-02 15:04:05")
+		salesReturn.Order.DateStr = salesReturn.Order.Date.Format("2006-01-02 15:04:05")
+
+		salesReturns = append(salesReturns, salesReturn)
+	}
+
+	return salesReturns, nil
+}
+
+// SumSalesReturnQuantityByOrderIDWithTx is used to get returned quantity of order line within transaction
+func (intr Internal) SumSalesReturnQuantityByOrderIDWithTx(ctx context.Context, tx *sql.Tx, orderID int64) (int, error) {
+	var quantity int
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM sales_return WHERE order_id = ?`
+
+	err := tx.QueryRowContext(ctx, query, orderID).Scan(&quantity)
+	return quantity, err
+}
+
+// StoreSalesReturn is to store sales return into database
+func (intr Internal) StoreSalesReturn(ctx context.Context, tx *sql.Tx, salesReturn SalesReturn) (ID int64, err error) {
+	query := `INSERT INTO sales_return
+					(
+						order_id,
+						product_id,
+						quantity,
+						price,
+						cost,
+						condition,
+						description,
+						date,
+						unit_price
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		salesReturn.OrderID,
+		salesReturn.ProductID,
+		salesReturn.Quantity,
+		salesReturn.Price,
+		salesReturn.Cost,
+		salesReturn.Condition,
+		salesReturn.Description,
+		salesReturn.Date,
+		salesReturn.UnitPrice,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
@@ -0,0 +1,439 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// status of serial
+// unit which is in stock can be picked by order line, returned to supplier, transferred or adjusted out
+const (
+	SerialInStock  = "in_stock"
+	SerialSold     = "sold"
+	SerialReturned = "returned"
+	SerialRemoved  = "removed"
+)
+
+// Serial is entity that represent schema on table serial
+// PurchaseDtlID is receipt which registers the unit, zero means it is registered by adjustment
+type Serial struct {
+	SerialID      int64     `db:"serial_id" json:"serial_id"`
+	ProductID     int64     `db:"product_id" json:"product_id"`
+	SerialNumber  string    `db:"serial_number" json:"serial_number"`
+	LocationID    int64     `db:"location_id" json:"location_id"`
+	LocationName  string    `db:"location_name" json:"location_name"`
+	Status        string    `db:"status" json:"status"`
+	PurchaseDtlID int64     `db:"purchase_detail_id" json:"purchase_detail_id"`
+	Date          time.Time `db:"date" json:"-"`
+	DateStr       string    `db:"date_str" json:"date"`
+}
+
+// SerialWithProduct is entity of serial with product
+type SerialWithProduct struct {
+	Serial
+	Product Product `json:"product"`
+}
+
+// SerialHistory is entity that represent schema on table serial_history
+// Status and LocationID are status and location of the unit after it is moved by the document
+type SerialHistory struct {
+	SerialHistoryID int64     `db:"serial_history_id" json:"serial_history_id"`
+	SerialID        int64     `db:"serial_id" json:"serial_id"`
+	SerialNumber    string    `db:"serial_number" json:"serial_number"`
+	ReferenceType   string    `db:"reference_type" json:"reference_type"`
+	ReferenceID     int64     `db:"reference_id" json:"reference_id"`
+	LocationID      int64     `db:"location_id" json:"location_id"`
+	LocationName    string    `db:"location_name" json:"location_name"`
+	Status          string    `db:"status" json:"status"`
+	Description     string    `db:"description" json:"description"`
+	Date            time.Time `db:"date" json:"-"`
+	DateStr         string    `db:"date_str" json:"date"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectSerial = `
+	SELECT
+		serial.serial_id,
+		serial.product_id,
+		serial.serial_number,
+		serial.location_id,
+		COALESCE(location.name, '') as location_name,
+		serial.status,
+		serial.purchase_detail_id,
+		serial.date as date_str,
+		product.name,
+		product.sku,
+		product.unit
+	FROM serial
+	JOIN product ON serial.product_id = product.product_id
+	LEFT JOIN location ON serial.location_id = location.location_id
+`
+
+var qSelectSerialHistory = `
+	SELECT
+		serial_history.serial_history_id,
+		serial_history.serial_id,
+		serial.serial_number,
+		serial_history.reference_type,
+		serial_history.reference_id,
+		serial_history.location_id,
+		COALESCE(location.name, '') as location_name,
+		serial_history.status,
+		serial_history.description,
+		serial_history.date as date_str
+	FROM serial_history
+	JOIN serial ON serial_history.serial_id = serial.serial_id
+	LEFT JOIN location ON serial_history.location_id = location.location_id
+`
+
+// GetSerialWithProductBySerialNumber is used to get serial with product by its serial number
+func (intr Internal) GetSerialWithProductBySerialNumber(ctx context.Context, serialNumber string) (SerialWithProduct, error) {
+	query := qSelectSerial
+	query += `WHERE
+				serial.serial_number = ?
+			`
+
+	db := intr.Storage.DB
+	rows, err := db.QueryContext(ctx, db.Rebind(query), serialNumber)
+	if err != nil {
+		return SerialWithProduct{}, err
+	}
+	defer rows.Close()
+
+	// not found serial is returned as empty struct
+	if !rows.Next() {
+		return SerialWithProduct{}, rows.Err()
+	}
+
+	return scanSerialWithProduct(rows)
+}
+
+// GetAvailableSerialByProductID is used to get all unit of product which is still in stock
+func (intr Internal) GetAvailableSerialByProductID(ctx context.Context, productID int64) ([]Serial, error) {
+	var serials []Serial
+
+	query := qSelectSerial
+	query += `WHERE
+				serial.product_id = ? AND serial.status = ?
+			ORDER BY serial.location_id, serial.serial_number
+			`
+
+	db := intr.Storage.DB
+	rows, err := db.QueryContext(ctx, db.Rebind(query), productID, SerialInStock)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		serialWithProduct, err := scanSerialWithProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		serials = append(serials, serialWithProduct.Serial)
+	}
+
+	return serials, rows.Err()
+}
+
+// GetSerialBySerialNumberWithTx is used to get serial by its serial number within transaction
+func (intr Internal) GetSerialBySerialNumberWithTx(ctx context.Context, tx *sql.Tx, serialNumber string) (Serial, error) {
+	query := qSelectSerial
+	query += `WHERE
+				serial.serial_number = ?
+			`
+
+	serials, err := intr.selectSerialWithTx(ctx, tx, query, serialNumber)
+	if err != nil || len(serials) == 0 {
+		return Serial{}, err
+	}
+
+	return serials[0], nil
+}
+
+// GetSerialByPurchaseDtlIDWithTx is used to get all unit which is registered by receipt within transaction
+func (intr Internal) GetSerialByPurchaseDtlIDWithTx(ctx context.Context, tx *sql.Tx, purchaseDtlID int64) ([]Serial, error) {
+	query := qSelectSerial
+	query += `WHERE
+				serial.purchase_detail_id = ?
+			ORDER BY serial.serial_number
+			`
+
+	return intr.selectSerialWithTx(ctx, tx, query, purchaseDtlID)
+}
+
+func (intr Internal) selectSerialWithTx(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]Serial, error) {
+	var serials []Serial
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		serialWithProduct, err := scanSerialWithProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		serials = append(serials, serialWithProduct.Serial)
+	}
+
+	return serials, rows.Err()
+}
+
+func scanSerialWithProduct(rows *sql.Rows) (SerialWithProduct, error) {
+	serialWithProduct := SerialWithProduct{}
+	err := rows.Scan(
+		&serialWithProduct.SerialID,
+		&serialWithProduct.ProductID,
+		&serialWithProduct.SerialNumber,
+		&serialWithProduct.LocationID,
+		&serialWithProduct.LocationName,
+		&serialWithProduct.Status,
+		&serialWithProduct.PurchaseDtlID,
+		&serialWithProduct.DateStr,
+		&serialWithProduct.Product.Name,
+		&serialWithProduct.Product.Sku,
+		&serialWithProduct.Product.Unit,
+	)
+	if err != nil {
+		return SerialWithProduct{}, err
+	}
+
+	serialWithProduct.Product.ProductID = serialWithProduct.ProductID
+
+	// convert date string into date time.Time
+	// spit date string to remove character +00:00
+	// date format: yyyy-MM-dd HH:mm:ss
+	splitDateStr := strings.Split(serialWithProduct.DateStr, "+")
+	DateStr := strings.Trim(splitDateStr[0], " ")
+	serialWithProduct.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+	if err != nil {
+		return SerialWithProduct{}, err
+	}
+
+	// using date format: yyyy-MM-dd HH:mm:ss
+	// to standarize date convenient
+	serialWithProduct.DateStr = serialWithProduct.Date.Format("2006-01-02 15:04:05")
+
+	return serialWithProduct, nil
+}
+
+// StoreSerial is to store serial into database
+func (intr Internal) StoreSerial(ctx context.Context, tx *sql.Tx, serial Serial) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO serial
+					(
+						product_id,
+						serial_number,
+						location_id,
+						status,
+						purchase_detail_id,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+	args = append(args,
+		serial.ProductID,
+		serial.SerialNumber,
+		serial.LocationID,
+		serial.Status,
+		serial.PurchaseDtlID,
+		serial.Date,
+	)
+	if serial.SerialID != 0 {
+		query = `UPDATE serial
+				 SET
+						product_id = ?,
+						serial_number = ?,
+						location_id = ?,
+						status = ?,
+						purchase_detail_id = ?,
+						date = ?
+				WHERE
+						serial_id = ?
+		`
+		args = append(args, serial.SerialID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if serial.SerialID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		serial.SerialID, _ = result.LastInsertId()
+	}
+
+	return serial.SerialID, nil
+}
+
+// DeleteSerial is to delete serial with its history from database
+func (intr Internal) DeleteSerial(ctx context.Context, tx *sql.Tx, ID int64) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM serial WHERE serial_id = ?", ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM serial_history WHERE serial_id = ?", ID)
+	return err
+}
+
+// GetSerialHistoryBySerialID is used to get history of serial ordered from its first document
+func (intr Internal) GetSerialHistoryBySerialID(ctx context.Context, serialID int64) ([]SerialHistory, error) {
+	serialHistories := []SerialHistory{}
+
+	query := qSelectSerialHistory
+	query += `WHERE
+				serial_history.serial_id = ?
+			ORDER BY serial_history.date, serial_history.serial_history_id
+			`
+
+	db := intr.Storage.DB
+	rows, err := db.QueryContext(ctx, db.Rebind(query), serialID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		serialHistory, err := scanSerialHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		serialHistories = append(serialHistories, serialHistory)
+	}
+
+	return serialHistories, rows.Err()
+}
+
+// GetSerialHistoryByReferenceWithTx is used to get history of serial by document within transaction
+func (intr Internal) GetSerialHistoryByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]SerialHistory, error) {
+	var serialHistories []SerialHistory
+
+	query := qSelectSerialHistory
+	query += `WHERE
+				serial_history.reference_type = ? AND serial_history.reference_id = ?
+			ORDER BY serial_history.serial_history_id
+			`
+
+	rows, err := tx.QueryContext(ctx, query, referenceType, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		serialHistory, err := scanSerialHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		serialHistories = append(serialHistories, serialHistory)
+	}
+
+	return serialHistories, rows.Err()
+}
+
+func scanSerialHistory(rows *sql.Rows) (SerialHistory, error) {
+	serialHistory := SerialHistory{}
+	err := rows.Scan(
+		&serialHistory.SerialHistoryID,
+		&serialHistory.SerialID,
+		&serialHistory.SerialNumber,
+		&serialHistory.ReferenceType,
+		&serialHistory.ReferenceID,
+		&serialHistory.LocationID,
+		&serialHistory.LocationName,
+		&serialHistory.Status,
+		&serialHistory.Description,
+		&serialHistory.DateStr,
+	)
+	if err != nil {
+		return SerialHistory{}, err
+	}
+
+	// convert date string into date time.Time
+	// spit date string to remove character +00:00
+	// date format: yyyy-MM-dd HH:mm:ss
+	splitDateStr := strings.Split(serialHistory.DateStr, "+")
+	DateStr := strings.Trim(splitDateStr[0], " ")
+	serialHistory.Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+	if err != nil {
+		return SerialHistory{}, err
+	}
+
+	// using date format: yyyy-MM-dd HH:mm:ss
+	// to standarize date convenient
+	serialHistory.DateStr = serialHistory.Date.Format("2006-01-02 15:04:05")
+
+	return serialHistory, nil
+}
+
+// StoreSerialHistory is to store history of serial into database
+func (intr Internal) StoreSerialHistory(ctx context.Context, tx *sql.Tx, serialHistory SerialHistory) (ID int64, err error) {
+	query := `INSERT INTO serial_history
+					(
+						serial_id,
+						reference_type,
+						reference_id,
+						location_id,
+						status,
+						description,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		serialHistory.SerialID,
+		serialHistory.ReferenceType,
+		serialHistory.ReferenceID,
+		serialHistory.LocationID,
+		serialHistory.Status,
+		serialHistory.Description,
+		serialHistory.Date,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
+
+// DeleteSerialHistory is to delete history of serial from database by single ID
+func (intr Internal) DeleteSerialHistory(ctx context.Context, tx *sql.Tx, ID int64) error {
+	query := `DELETE FROM serial_history
+			  WHERE
+				  serial_history_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, ID)
+	return err
+}
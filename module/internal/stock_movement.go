@@ -0,0 +1,272 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// reference type of stock movement
+const (
+	MovementOpening        = "opening"
+	MovementPurchase       = "purchase"
+	MovementOrder          = "order"
+	MovementAdjustment     = "adjustment"
+	MovementReturn         = "sales_return"
+	MovementPurchaseReturn = "purchase_return"
+	MovementTransfer       = "transfer"
+)
+
+// StockMovement is entity that represent schema on table stock_movement
+// LocationID is location which stock is moved in or out
+type StockMovement struct {
+	StockMovementID int64     `db:"stock_movement_id" json:"stock_movement_id"`
+	ProductID       int64     `db:"product_id" json:"product_id"`
+	Quantity        int       `db:"quantity" json:"quantity"`
+	Cost            int64     `db:"cost" json:"cost"`
+	ReferenceType   string    `db:"reference_type" json:"reference_type"`
+	ReferenceID     int64     `db:"reference_id" json:"reference_id"`
+	Description     string    `db:"description" json:"description"`
+	LocationID      int64     `db:"location_id" json:"location_id"`
+	LocationName    string    `db:"location_name" json:"location_name"`
+	Date            time.Time `db:"date" json:"-"`
+	DateStr         string    `db:"date_str" json:"date"`
+	Balance         int       `json:"balance"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectStockMovement = `
+	SELECT
+		stock_movement.stock_movement_id,
+		stock_movement.product_id,
+		stock_movement.quantity,
+		stock_movement.cost,
+		stock_movement.reference_type,
+		stock_movement.reference_id,
+		stock_movement.description,
+		stock_movement.location_id,
+		COALESCE(location.name, '') as location_name,
+		stock_movement.date as date_str
+	FROM stock_movement
+	LEFT JOIN location ON stock_movement.location_id = location.location_id
+`
+
+// cost doesn't depend on location, so movements of one document which are stored at the same time
+// are netted into one movement per product (e.g. transfer is netted into nothing).
+// other column is taken from the latest movement
+var qSelectNetStockMovement = `
+	SELECT
+		MAX(stock_movement_id) as stock_movement_id,
+		product_id,
+		SUM(quantity) as quantity,
+		cost,
+		reference_type,
+		reference_id,
+		description,
+		location_id,
+		'' as location_name,
+		date as date_str
+	FROM stock_movement
+`
+
+// GetStockMovement is used to get all stock movement
+// ordered by date of movement
+func (intr Internal) GetStockMovement(ctx context.Context) ([]StockMovement, error) {
+	var (
+		stockMovements []StockMovement
+		query          string
+	)
+
+	query = qSelectStockMovement
+	query += `ORDER BY stock_movement.date, stock_movement.stock_movement_id
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &stockMovements, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockMovements, parseStockMovementDate(stockMovements)
+}
+
+// GetStockMovementByProductID is used to get all stock movement of product
+// ordered by date of movement
+func (intr Internal) GetStockMovementByProductID(ctx context.Context, productID int64) ([]StockMovement, error) {
+	var (
+		stockMovements []StockMovement
+		query          string
+	)
+
+	query = qSelectStockMovement
+	query += `WHERE
+				stock_movement.product_id = ?
+			ORDER BY stock_movement.date, stock_movement.stock_movement_id
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &stockMovements, db.Rebind(query), productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockMovements, parseStockMovementDate(stockMovements)
+}
+
+// GetNetStockMovement is used to get all stock movement which is netted per product and cost
+// ordered by date of movement, it is used to calculate cost of product
+func (intr Internal) GetNetStockMovement(ctx context.Context) ([]StockMovement, error) {
+	var (
+		stockMovements []StockMovement
+		query          string
+	)
+
+	query = qSelectNetStockMovement
+	query += `GROUP BY product_id, reference_type, reference_id, date, cost
+			HAVING SUM(quantity) != 0
+			ORDER BY date, MAX(stock_movement_id)
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &stockMovements, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockMovements, parseStockMovementDate(stockMovements)
+}
+
+// GetNetStockMovementByProductID is used to get all stock movement of product which is netted
+// ordered by date of movement, it is used to calculate cost of product
+func (intr Internal) GetNetStockMovementByProductID(ctx context.Context, productID int64) ([]StockMovement, error) {
+	var (
+		stockMovements []StockMovement
+		query          string
+	)
+
+	query = qSelectNetStockMovement
+	query += `WHERE
+				product_id = ?
+			GROUP BY product_id, reference_type, reference_id, date, cost
+			HAVING SUM(quantity) != 0
+			ORDER BY date, MAX(stock_movement_id)
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &stockMovements, db.Rebind(query), productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stockMovements, parseStockMovementDate(stockMovements)
+}
+
+// GetStockMovementBalanceByReferenceWithTx is used to get net quantity of document on each product and location
+// within transaction, so movement which has not been committed yet is included
+func (intr Internal) GetStockMovementBalanceByReferenceWithTx(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) ([]StockMovement, error) {
+	var stockMovements []StockMovement
+
+	query := `SELECT
+				product_id,
+				location_id,
+				SUM(quantity)
+			FROM stock_movement
+			WHERE
+				reference_type = ? AND reference_id = ?
+			GROUP BY product_id, location_id
+			HAVING SUM(quantity) != 0
+			`
+
+	row, err := tx.QueryContext(ctx, query, referenceType, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	for row.Next() {
+		stockMovement := StockMovement{
+			ReferenceType: referenceType,
+			ReferenceID:   referenceID,
+		}
+		err = row.Scan(
+			&stockMovement.ProductID,
+			&stockMovement.LocationID,
+			&stockMovement.Quantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		stockMovements = append(stockMovements, stockMovement)
+	}
+
+	return stockMovements, row.Err()
+}
+
+// parseStockMovementDate is to convert date string of stock movements into date time.Time
+func parseStockMovementDate(stockMovements []StockMovement) error {
+	var err error
+	for index, stockMovement := range stockMovements {
+		// spit date string to remove character +00:00
+		// date format: yyyy-MM-dd HH:mm:ss
+		splitDateStr := strings.Split(stockMovement.DateStr, "+")
+		DateStr := strings.Trim(splitDateStr[0], " ")
+		stockMovements[index].Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return err
+		}
+
+		// using date format: yyyy-MM-dd HH:mm:ss
+		// to standarize date convenient
+		stockMovements[index].DateStr = stockMovements[index].Date.Format("2006-01-02 15:04:05")
+	}
+
+	return nil
+}
+
+// StoreStockMovement is to store stock movement into database
+func (intr Internal) StoreStockMovement(ctx context.Context, tx *sql.Tx, stockMovement StockMovement) (ID int64, err error) {
+	query := `INSERT INTO stock_movement
+					(
+						product_id,
+						quantity,
+						cost,
+						reference_type,
+						reference_id,
+						description,
+						location_id,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		stockMovement.ProductID,
+		stockMovement.Quantity,
+		stockMovement.Cost,
+		stockMovement.ReferenceType,
+		stockMovement.ReferenceID,
+		stockMovement.Description,
+		stockMovement.LocationID,
+		stockMovement.Date,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
@@ -0,0 +1,299 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// status of stock take
+const (
+	StockTakeOpen   = "open"
+	StockTakePosted = "posted"
+)
+
+// StockTake is entity that represent schema on table stock_take
+// TotalProduct is number of snapshotted product and TotalCounted is number of counted product
+// stock is counted on one location
+type StockTake struct {
+	StockTakeID   int64     `db:"stock_take_id" json:"stock_take_id"`
+	LocationID    int64     `db:"location_id" json:"location_id"`
+	LocationName  string    `db:"location_name" json:"location_name"`
+	Description   string    `db:"description" json:"description"`
+	Status        string    `db:"status" json:"status"`
+	Date          time.Time `db:"date" json:"-"`
+	DateStr       string    `db:"date_str" json:"date"`
+	DatePostedStr string    `db:"date_posted" json:"date_posted"`
+	TotalProduct  int       `db:"total_product" json:"total_product"`
+	TotalCounted  int       `db:"total_counted" json:"total_counted"`
+}
+
+// StockTakeDetail is entity that represent schema on table stock_take_detail
+// QuantitySystem is stock of product when the session is opened
+// Variance is counted quantity minus QuantitySystem and VarianceValue is its value at AverageCost
+type StockTakeDetail struct {
+	StockTakeDetailID int64   `db:"stock_take_detail_id" json:"stock_take_detail_id"`
+	StockTakeID       int64   `db:"stock_take_id" json:"stock_take_id"`
+	ProductID         int64   `db:"product_id" json:"product_id"`
+	QuantitySystem    int     `db:"quantity_system" json:"quantity_system"`
+	QuantityCounted   int     `db:"quantity_counted" json:"quantity_counted"`
+	IsCounted         bool    `db:"is_counted" json:"is_counted"`
+	Product           Product `json:"product"`
+	Variance          int     `json:"variance"`
+	AverageCost       int     `json:"average_cost"`
+	VarianceValue     int64   `json:"variance_value"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectStockTake = `
+	SELECT
+		stock_take.stock_take_id,
+		stock_take.location_id,
+		COALESCE(location.name, '') as location_name,
+		COALESCE(stock_take.description, '') as description,
+		stock_take.status,
+		stock_take.date as date_str,
+		stock_take.date_posted,
+		COUNT(stock_take_detail.stock_take_detail_id) as total_product,
+		COALESCE(SUM(stock_take_detail.is_counted), 0) as total_counted
+	FROM stock_take
+	LEFT JOIN stock_take_detail ON stock_take.stock_take_id = stock_take_detail.stock_take_id
+	LEFT JOIN location ON stock_take.location_id = location.location_id
+`
+
+// GetStockTake is used to get all stock take, the latest first
+func (intr Internal) GetStockTake(ctx context.Context) ([]StockTake, error) {
+	query := qSelectStockTake
+	query += `GROUP BY stock_take.stock_take_id
+			ORDER BY stock_take.date DESC, stock_take.stock_take_id DESC
+			`
+
+	return intr.selectStockTake(ctx, query)
+}
+
+// GetStockTakeByStatus is used to get all stock take by its status
+func (intr Internal) GetStockTakeByStatus(ctx context.Context, status string) ([]StockTake, error) {
+	query := qSelectStockTake
+	query += `WHERE
+				stock_take.status = ?
+			GROUP BY stock_take.stock_take_id
+			ORDER BY stock_take.date DESC, stock_take.stock_take_id DESC
+			`
+
+	return intr.selectStockTake(ctx, query, status)
+}
+
+// GetStockTakeByStatusAndLocationID is used to get all stock take on location by its status
+func (intr Internal) GetStockTakeByStatusAndLocationID(ctx context.Context, status string, locationID int64) ([]StockTake, error) {
+	query := qSelectStockTake
+	query += `WHERE
+				stock_take.status = ? AND stock_take.location_id = ?
+			GROUP BY stock_take.stock_take_id
+			ORDER BY stock_take.date DESC, stock_take.stock_take_id DESC
+			`
+
+	return intr.selectStockTake(ctx, query, status, locationID)
+}
+
+// GetStockTakeByID is used to get stock take by ID
+func (intr Internal) GetStockTakeByID(ctx context.Context, ID int64) (StockTake, error) {
+	query := qSelectStockTake
+	query += `WHERE
+				stock_take.stock_take_id = ?
+			GROUP BY stock_take.stock_take_id
+			`
+
+	stockTakes, err := intr.selectStockTake(ctx, query, ID)
+	if err != nil || len(stockTakes) == 0 {
+		return StockTake{}, err
+	}
+
+	return stockTakes[0], nil
+}
+
+func (intr Internal) selectStockTake(ctx context.Context, query string, args ...interface{}) ([]StockTake, error) {
+	var stockTakes []StockTake
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &stockTakes, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, stockTake := range stockTakes {
+		// spit date string to remove character +00:00
+		// date format: yyyy-MM-dd HH:mm:ss
+		splitDateStr := strings.Split(stockTake.DateStr, "+")
+		DateStr := strings.Trim(splitDateStr[0], " ")
+		stockTakes[index].Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		// using date format: yyyy-MM-dd HH:mm:ss
+		// to standarize date convenient
+		stockTakes[index].DateStr = stockTakes[index].Date.Format("2006-01-02 15:04:05")
+	}
+
+	return stockTakes, nil
+}
+
+// GetStockTakeDetailByStockTakeID is used to get snapshot of all product in stock take
+func (intr Internal) GetStockTakeDetailByStockTakeID(ctx context.Context, stockTakeID int64) ([]StockTakeDetail, error) {
+	var stockTakeDetails []StockTakeDetail
+
+	query := `
+	SELECT
+		stock_take_detail.stock_take_detail_id,
+		stock_take_detail.stock_take_id,
+		stock_take_detail.product_id,
+		stock_take_detail.quantity_system,
+		stock_take_detail.quantity_counted,
+		stock_take_detail.is_counted,
+		product.name,
+		product.sku,
+		product.unit,
+		` + qProductStock + ` as stock
+	FROM stock_take_detail
+	JOIN product ON stock_take_detail.product_id = product.product_id
+	WHERE
+		stock_take_detail.stock_take_id = ?
+	ORDER BY product.sku
+	`
+
+	db := intr.Storage.DB
+	row, err := db.QueryxContext(ctx, db.Rebind(query), stockTakeID)
+	if err != nil {
+		return nil, err
+	}
+
+	for row.Next() {
+		stockTakeDetail := StockTakeDetail{}
+		err = row.Scan(
+			&stockTakeDetail.StockTakeDetailID,
+			&stockTakeDetail.StockTakeID,
+			&stockTakeDetail.ProductID,
+			&stockTakeDetail.QuantitySystem,
+			&stockTakeDetail.QuantityCounted,
+			&stockTakeDetail.IsCounted,
+			&stockTakeDetail.Product.Name,
+			&stockTakeDetail.Product.Sku,
+			&stockTakeDetail.Product.Unit,
+			&stockTakeDetail.Product.Stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		stockTakeDetail.Product.ProductID = stockTakeDetail.ProductID
+		stockTakeDetails = append(stockTakeDetails, stockTakeDetail)
+	}
+
+	return stockTakeDetails, nil
+}
+
+// StoreStockTake is to store stock take into database
+func (intr Internal) StoreStockTake(ctx context.Context, tx *sql.Tx, stockTake StockTake) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO stock_take
+					(
+						location_id,
+						description,
+						status,
+						date,
+						date_posted
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?,
+						?
+					)
+			`
+	args = append(args,
+		stockTake.LocationID,
+		stockTake.Description,
+		stockTake.Status,
+		stockTake.Date,
+		stockTake.DatePostedStr,
+	)
+	if stockTake.StockTakeID != 0 {
+		query = `UPDATE stock_take
+				 SET
+						location_id = ?,
+						description = ?,
+						status = ?,
+						date = ?,
+						date_posted = ?
+				WHERE
+						stock_take_id = ?
+		`
+		args = append(args, stockTake.StockTakeID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if stockTake.StockTakeID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		stockTake.StockTakeID, _ = result.LastInsertId()
+	}
+
+	return stockTake.StockTakeID, nil
+}
+
+// SnapshotStockTakeDetail is to snapshot current stock of all product on location into stock take
+// bundle is not snapshotted since it doesn't keep its own stock
+func (intr Internal) SnapshotStockTakeDetail(ctx context.Context, tx *sql.Tx, stockTakeID, locationID int64) error {
+	query := `INSERT INTO stock_take_detail
+					(
+						stock_take_id,
+						product_id,
+						quantity_system
+					)
+			SELECT
+					?,
+					product.product_id,
+					(
+						SELECT COALESCE(SUM(stock_movement.quantity), 0)
+						FROM stock_movement
+						WHERE stock_movement.product_id = product.product_id AND stock_movement.location_id = ?
+					)
+			FROM product
+			WHERE
+					product.is_bundle = 0
+			`
+
+	_, err := tx.ExecContext(ctx, query, stockTakeID, locationID)
+	return err
+}
+
+// UpdateStockTakeDetailCount is to store counted quantity of product in stock take
+func (intr Internal) UpdateStockTakeDetailCount(ctx context.Context, tx *sql.Tx, stockTakeID, productID int64, quantity int) error {
+	query := `UPDATE stock_take_detail
+			  SET
+					quantity_counted = ?,
+					is_counted = 1
+			  WHERE
+					stock_take_id = ? AND product_id = ?
+			 `
+
+	_, err := tx.ExecContext(ctx, query, quantity, stockTakeID, productID)
+	return err
+}
+
+// DeleteStockTake is to delete stock take with its snapshot from database
+func (intr Internal) DeleteStockTake(ctx context.Context, tx *sql.Tx, ID int64) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM stock_take_detail WHERE stock_take_id = ?", ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM stock_take WHERE stock_take_id = ?", ID)
+	return err
+}
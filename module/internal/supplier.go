@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Supplier is entity that represent schema on table supplier
+// TotalSpend is value of all ordered quantity after deducted by TotalReturn (value of returned goods)
+// and AverageLeadTime is average days between purchase and its last receipt of finished purchase
+type Supplier struct {
+	SupplierID      int64   `db:"supplier_id" json:"supplier_id"`
+	Name            string  `db:"name" json:"supplier_name"`
+	Phone           string  `db:"phone" json:"phone"`
+	Email           string  `db:"email" json:"email"`
+	Address         string  `db:"address" json:"address"`
+	TotalPurchase   int     `db:"total_purchase" json:"total_purchase"`
+	TotalSpend      int64   `db:"total_spend" json:"total_spend"`
+	TotalReturn     int64   `db:"total_return" json:"total_return"`
+	AverageLeadTime float64 `db:"average_lead_time" json:"average_lead_time"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+// date is cut into yyyy-MM-dd HH:mm:ss since it is stored in more than one format
+var qSelectSupplier = `
+	SELECT
+		supplier.supplier_id,
+		supplier.name,
+		supplier.phone,
+		supplier.email,
+		COALESCE(supplier.address, '') as address,
+		COUNT(purchase.purchase_id) as total_purchase,
+		COALESCE(SUM(COALESCE(purchase.unit_cost * purchase.quantity_order / purchase.unit_factor, purchase.cost * purchase.quantity_order)), 0) - ` + qSupplierReturn + ` as total_spend,
+		` + qSupplierReturn + ` as total_return,
+		COALESCE(ROUND(AVG(
+			CASE WHEN purchase.is_finish THEN
+				julianday((
+					SELECT MAX(SUBSTR(purchase_detail.date, 1, 19))
+					FROM purchase_detail
+					WHERE purchase_detail.purchase_id = purchase.purchase_id
+				)) - julianday(SUBSTR(purchase.date, 1, 19))
+			END
+		), 2), 0) as average_lead_time
+	FROM supplier
+	LEFT JOIN purchase ON supplier.supplier_id = purchase.supplier_id
+`
+
+// value of goods which is returned to supplier
+var qSupplierReturn = `COALESCE((
+			SELECT SUM(purchase_return.cost * purchase_return.quantity)
+			FROM purchase_return
+			JOIN purchase returned_purchase ON purchase_return.purchase_id = returned_purchase.purchase_id
+			WHERE returned_purchase.supplier_id = supplier.supplier_id
+		), 0)`
+
+// GetSupplier is used to get all supplier
+func (intr Internal) GetSupplier(ctx context.Context) ([]Supplier, error) {
+	var (
+		suppliers []Supplier
+		query     string
+	)
+
+	query = qSelectSupplier
+	query += `GROUP BY supplier.supplier_id
+			ORDER BY supplier.name
+			`
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &suppliers, query)
+	return suppliers, err
+}
+
+// GetSupplierByID is used to get supplier by ID
+func (intr Internal) GetSupplierByID(ctx context.Context, ID int64) (Supplier, error) {
+	query := qSelectSupplier
+	query += `WHERE
+				supplier.supplier_id = ?
+			GROUP BY supplier.supplier_id
+			`
+
+	return intr.getSupplier(ctx, query, ID)
+}
+
+// GetSupplierByName is used to get supplier by its name
+func (intr Internal) GetSupplierByName(ctx context.Context, name string) (Supplier, error) {
+	query := qSelectSupplier
+	query += `WHERE
+				supplier.name = ?
+			GROUP BY supplier.supplier_id
+			`
+
+	return intr.getSupplier(ctx, query, name)
+}
+
+func (intr Internal) getSupplier(ctx context.Context, query string, args ...interface{}) (Supplier, error) {
+	var supplier Supplier
+
+	db := intr.Storage.DB
+	err := db.GetContext(ctx, &supplier, db.Rebind(query), args...)
+
+	// keep returning value but with empty struct
+	// since no rows is not error in a system
+	if err == sql.ErrNoRows {
+		return Supplier{}, nil
+	}
+
+	return supplier, err
+}
+
+// StoreSupplier is to store supplier into database
+func (intr Internal) StoreSupplier(ctx context.Context, tx *sql.Tx, supplier Supplier) (ID int64, err error) {
+	var args []interface{}
+	query := `INSERT INTO supplier
+					(
+						name,
+						phone,
+						email,
+						address
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?
+					)
+			`
+	args = append(args,
+		supplier.Name,
+		supplier.Phone,
+		supplier.Email,
+		supplier.Address,
+	)
+	if supplier.SupplierID != 0 {
+		query = `UPDATE supplier
+				 SET
+						name = ?,
+						phone = ?,
+						email = ?,
+						address = ?
+				WHERE
+						supplier_id = ?
+		`
+		args = append(args, supplier.SupplierID)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if supplier.SupplierID == 0 {
+		// no need to check error, since it will be occurred by database incompatibility
+		supplier.SupplierID, _ = result.LastInsertId()
+	}
+
+	return supplier.SupplierID, nil
+}
+
+// DeleteSupplier is to delete supplier from database by single ID
+func (intr Internal) DeleteSupplier(ctx context.Context, ID int64) error {
+	query := `DELETE FROM supplier
+			  WHERE
+				  supplier_id = ?
+			 `
+	db := intr.Storage.DB
+
+	_, err := db.ExecContext(ctx, query, ID)
+	return err
+}
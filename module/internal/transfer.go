@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Transfer is entity that represent schema on table transfer
+// stock of each detail is moved from FromLocationID into ToLocationID
+type Transfer struct {
+	TransferID       int64     `db:"transfer_id" json:"transfer_id"`
+	FromLocationID   int64     `db:"from_location_id" json:"from_location_id"`
+	FromLocationName string    `db:"from_location_name" json:"from_location_name"`
+	ToLocationID     int64     `db:"to_location_id" json:"to_location_id"`
+	ToLocationName   string    `db:"to_location_name" json:"to_location_name"`
+	Description      string    `db:"description" json:"description"`
+	Date             time.Time `db:"date" json:"-"`
+	DateStr          string    `db:"date_str" json:"date"`
+	TotalQuantity    int       `db:"total_quantity" json:"total_quantity"`
+}
+
+// TransferDetail is entity that represent schema on table transfer_detail
+// SerialNumbers is unit of serialized product which is transferred
+type TransferDetail struct {
+	TransferDetailID int64    `db:"transfer_detail_id" json:"transfer_detail_id"`
+	TransferID       int64    `db:"transfer_id" json:"transfer_id"`
+	ProductID        int64    `db:"product_id" json:"product_id"`
+	Quantity         int      `db:"quantity" json:"quantity"`
+	Product          Product  `json:"product"`
+	SerialNumbers    []string `json:"serial_numbers,omitempty"`
+}
+
+// this is a main query. it will be used on many place
+// so, to reduce redudancy, this query need to be declared as a global variable
+var qSelectTransfer = `
+	SELECT
+		transfer.transfer_id,
+		transfer.from_location_id,
+		COALESCE(from_location.name, '') as from_location_name,
+		transfer.to_location_id,
+		COALESCE(to_location.name, '') as to_location_name,
+		COALESCE(transfer.description, '') as description,
+		transfer.date as date_str,
+		COALESCE((
+			SELECT SUM(transfer_detail.quantity)
+			FROM transfer_detail
+			WHERE transfer_detail.transfer_id = transfer.transfer_id
+		), 0) as total_quantity
+	FROM transfer
+	LEFT JOIN location from_location ON transfer.from_location_id = from_location.location_id
+	LEFT JOIN location to_location ON transfer.to_location_id = to_location.location_id
+`
+
+// GetTransfer is used to get all transfer
+func (intr Internal) GetTransfer(ctx context.Context) ([]Transfer, error) {
+	query := qSelectTransfer
+	query += `ORDER BY transfer.date, transfer.transfer_id
+			`
+
+	return intr.selectTransfer(ctx, query)
+}
+
+// GetTransferByID is used to get transfer by ID
+func (intr Internal) GetTransferByID(ctx context.Context, ID int64) (Transfer, error) {
+	query := qSelectTransfer
+	query += `WHERE
+				transfer.transfer_id = ?
+			`
+
+	transfers, err := intr.selectTransfer(ctx, query, ID)
+	if err != nil || len(transfers) == 0 {
+		return Transfer{}, err
+	}
+
+	return transfers[0], nil
+}
+
+func (intr Internal) selectTransfer(ctx context.Context, query string, args ...interface{}) ([]Transfer, error) {
+	var transfers []Transfer
+
+	db := intr.Storage.DB
+	err := db.SelectContext(ctx, &transfers, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, transfer := range transfers {
+		// spit date string to remove character +00:00
+		// date format: yyyy-MM-dd HH:mm:ss
+		splitDateStr := strings.Split(transfer.DateStr, "+")
+		DateStr := strings.Trim(splitDateStr[0], " ")
+		transfers[index].Date, err = time.Parse("2006-01-02 15:04:05", DateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		// using date format: yyyy-MM-dd HH:mm:ss
+		// to standarize date convenient
+		transfers[index].DateStr = transfers[index].Date.Format("2006-01-02 15:04:05")
+	}
+
+	return transfers, nil
+}
+
+// GetTransferDetailByTransferID is used to get all transferred product of transfer
+func (intr Internal) GetTransferDetailByTransferID(ctx context.Context, transferID int64) ([]TransferDetail, error) {
+	var transferDetails []TransferDetail
+
+	query := `
+	SELECT
+		transfer_detail.transfer_detail_id,
+		transfer_detail.transfer_id,
+		transfer_detail.product_id,
+		transfer_detail.quantity,
+		product.name,
+		product.sku,
+		product.unit,
+		` + qProductStock + ` as stock
+	FROM transfer_detail
+	JOIN product ON transfer_detail.product_id = product.product_id
+	WHERE
+		transfer_detail.transfer_id = ?
+	ORDER BY transfer_detail.transfer_detail_id
+	`
+
+	db := intr.Storage.DB
+	row, err := db.QueryxContext(ctx, db.Rebind(query), transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	for row.Next() {
+		transferDetail := TransferDetail{}
+		err = row.Scan(
+			&transferDetail.TransferDetailID,
+			&transferDetail.TransferID,
+			&transferDetail.ProductID,
+			&transferDetail.Quantity,
+			&transferDetail.Product.Name,
+			&transferDetail.Product.Sku,
+			&transferDetail.Product.Unit,
+			&transferDetail.Product.Stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		transferDetail.Product.ProductID = transferDetail.ProductID
+		transferDetails = append(transferDetails, transferDetail)
+	}
+
+	return transferDetails, nil
+}
+
+// StoreTransfer is to store transfer into database
+func (intr Internal) StoreTransfer(ctx context.Context, tx *sql.Tx, transfer Transfer) (ID int64, err error) {
+	query := `INSERT INTO transfer
+					(
+						from_location_id,
+						to_location_id,
+						description,
+						date
+					)
+			VALUES (
+						?,
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		transfer.FromLocationID,
+		transfer.ToLocationID,
+		transfer.Description,
+		transfer.Date,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
+
+// StoreTransferDetail is to store transferred product into database
+func (intr Internal) StoreTransferDetail(ctx context.Context, tx *sql.Tx, transferDetail TransferDetail) (ID int64, err error) {
+	query := `INSERT INTO transfer_detail
+					(
+						transfer_id,
+						product_id,
+						quantity
+					)
+			VALUES (
+						?,
+						?,
+						?
+					)
+			`
+
+	result, err := tx.ExecContext(ctx, query,
+		transferDetail.TransferID,
+		transferDetail.ProductID,
+		transferDetail.Quantity,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// no need to check error, since it will be occurred by database incompatibility
+	ID, _ = result.LastInsertId()
+
+	return ID, nil
+}
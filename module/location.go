@@ -0,0 +1,136 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqLocation is entity of inputed location
+// use to make request that will be stored into database
+type ReqLocation struct {
+	internal.Location
+}
+
+// error of location request
+var (
+	// ErrEmptyLocationName is error when location doesn't have name
+	ErrEmptyLocationName = errors.New("location name is required")
+	// ErrDuplicateLocationName is error when name has been used by other location
+	ErrDuplicateLocationName = errors.New("location name has been used")
+	// ErrLocationNotFound is error when requested location doesn't exist
+	ErrLocationNotFound = errors.New("location not found")
+	// ErrDefaultLocation is error when default location is deleted
+	ErrDefaultLocation = errors.New("default location can't be deleted")
+	// ErrDamagedLocation is error when damaged location is deleted
+	ErrDamagedLocation = errors.New("damaged location can't be deleted")
+	// ErrLocationHasMovement is error when deleted location still has stock movement
+	ErrLocationHasMovement = errors.New("location still has stock movement")
+)
+
+// GetLocation is used to get all location with its total stock
+func (mod Module) GetLocation(ctx context.Context) ([]internal.Location, error) {
+	return mod.internal.GetLocation(ctx)
+}
+
+// GetLocationByID is used to get location by ID
+func (mod Module) GetLocationByID(ctx context.Context, ID int64) (internal.Location, error) {
+	location, err := mod.internal.GetLocationByID(ctx, ID)
+	if err != nil {
+		return internal.Location{}, err
+	}
+
+	if location.LocationID == 0 {
+		return internal.Location{}, ErrLocationNotFound
+	}
+
+	return location, nil
+}
+
+// StoreLocation is to store location into database
+func (mod Module) StoreLocation(ctx context.Context, reqLocation ReqLocation) (ID int64, err error) {
+	location := internal.Location{
+		LocationID:  reqLocation.LocationID,
+		Name:        strings.Trim(reqLocation.Name, " "),
+		Description: reqLocation.Description,
+	}
+
+	if location.Name == "" {
+		return 0, ErrEmptyLocationName
+	}
+
+	if location.LocationID != 0 {
+		_, err = mod.GetLocationByID(ctx, location.LocationID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	existingLocation, err := mod.internal.GetLocationByName(ctx, location.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	if existingLocation.LocationID != 0 && existingLocation.LocationID != location.LocationID {
+		return 0, ErrDuplicateLocationName
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	ID, err = mod.internal.StoreLocation(ctx, tx, location)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
+
+// DeleteLocation is to delete location which never keeps any stock from database
+func (mod Module) DeleteLocation(ctx context.Context, ID int64) error {
+	if ID == internal.DefaultLocationID {
+		return ErrDefaultLocation
+	}
+
+	location, err := mod.GetLocationByID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	// damaged location must stay to keep returned item in damaged condition
+	if location.IsDamaged {
+		return ErrDamagedLocation
+	}
+
+	totalMovement, err := mod.internal.CountStockMovementByLocationID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if totalMovement > 0 {
+		return ErrLocationHasMovement
+	}
+
+	return mod.internal.DeleteLocation(ctx, ID)
+}
+
+// getLocationID is used to resolve location of document
+// zero locationID means default location
+func (mod Module) getLocationID(ctx context.Context, locationID int64) (int64, error) {
+	if locationID == 0 {
+		return internal.DefaultLocationID, nil
+	}
+
+	location, err := mod.GetLocationByID(ctx, locationID)
+	if err != nil {
+		return 0, err
+	}
+
+	return location.LocationID, nil
+}
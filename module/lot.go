@@ -0,0 +1,226 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// defaultNearExpiryDays is number of days which near expiry report looks ahead by default
+const defaultNearExpiryDays = 30
+
+// error of lot request
+var (
+	// ErrInvalidExpiryDate is error when expiry date of receipt is not formatted as yyyy-MM-dd
+	ErrInvalidExpiryDate = errors.New("invalid expiry date, use yyyy-MM-dd")
+	// ErrInvalidNearExpiryDays is error when days of near expiry report is negative
+	ErrInvalidNearExpiryDays = errors.New("invalid near expiry days")
+)
+
+// GetLot is used to get all lot which still has stock on its location
+// ordered from the lot which expires first
+func (mod Module) GetLot(ctx context.Context) ([]internal.LotWithProduct, error) {
+	return mod.internal.GetAvailableLotWithProduct(ctx)
+}
+
+// sanitizeLot is used to trim lot number and validate expiry date of receipt
+func sanitizeLot(purchaseDtl *internal.PurchaseDtl) error {
+	purchaseDtl.LotNumber = strings.Trim(purchaseDtl.LotNumber, " ")
+	purchaseDtl.ExpiryDate = strings.Trim(purchaseDtl.ExpiryDate, " ")
+	if purchaseDtl.ExpiryDate == "" {
+		return nil
+	}
+
+	// date format: yyyy-MM-dd
+	_, err := time.Parse("2006-01-02", purchaseDtl.ExpiryDate)
+	if err != nil {
+		return ErrInvalidExpiryDate
+	}
+
+	return nil
+}
+
+// syncLot is to make lot of receipt has the same quantity, location and expiry as the receipt
+// so updating the receipt only adds or removes the delta of remaining quantity
+// receipt without lot number and expiry date doesn't have any lot
+func (mod Module) syncLot(ctx context.Context, tx *sql.Tx, productID int64, purchaseDtl internal.PurchaseDtl) error {
+	lots, err := mod.internal.GetLotByReferenceWithTx(ctx, tx, internal.LotReceipt, purchaseDtl.PurchaseDtlID)
+	if err != nil {
+		return err
+	}
+
+	lot := internal.Lot{
+		ProductID:     productID,
+		LocationID:    purchaseDtl.LocationID,
+		LotNumber:     purchaseDtl.LotNumber,
+		ExpiryDate:    purchaseDtl.ExpiryDate,
+		ReferenceType: internal.LotReceipt,
+		ReferenceID:   purchaseDtl.PurchaseDtlID,
+		Quantity:      purchaseDtl.Quantity,
+		Date:          purchaseDtl.Date,
+	}
+	if lot.LotNumber == "" && lot.ExpiryDate == "" {
+		lot.Quantity = 0
+	}
+
+	lot.Remaining = lot.Quantity
+	if len(lots) > 0 {
+		prevLot := lots[0]
+		lot.LotID = prevLot.LotID
+		lot.Remaining = prevLot.Remaining + lot.Quantity - prevLot.Quantity
+
+		// quantity which has been consumed can't be taken back
+		if lot.Remaining < 0 {
+			lot.Remaining = 0
+		}
+	}
+
+	// nothing to be tracked
+	if lot.LotID == 0 && lot.Quantity <= 0 {
+		return nil
+	}
+
+	_, err = mod.internal.StoreLot(ctx, tx, lot)
+	return err
+}
+
+// consumeLot is to consume lots of product on location by document (e.g. order line)
+// lot which expires first is consumed first (FEFO), and quantity which exceeds the lots
+// is taken from stock which is not tracked by any lot. it returns consumed quantity of each lot
+func (mod Module) consumeLot(ctx context.Context, tx *sql.Tx, productID, locationID int64, quantity int, referenceType string, referenceID int64) ([]internal.Lot, error) {
+	if locationID == 0 {
+		locationID = internal.DefaultLocationID
+	}
+
+	lots, err := mod.internal.GetAvailableLotByLocationWithTx(ctx, tx, productID, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mod.allocateLot(ctx, tx, lots, quantity, referenceType, referenceID)
+}
+
+// allocateLot is to consume given lots in their order until quantity is fulfilled
+func (mod Module) allocateLot(ctx context.Context, tx *sql.Tx, lots []internal.Lot, quantity int, referenceType string, referenceID int64) ([]internal.Lot, error) {
+	var consumedLots []internal.Lot
+	for _, lot := range lots {
+		if quantity <= 0 {
+			break
+		}
+
+		if lot.Remaining <= 0 {
+			continue
+		}
+
+		consumed := lot.Remaining
+		if consumed > quantity {
+			consumed = quantity
+		}
+
+		err := mod.internal.UpdateLotRemaining(ctx, tx, lot.LotID, -consumed)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = mod.internal.StoreLotAllocation(ctx, tx, internal.LotAllocation{
+			LotID:         lot.LotID,
+			ProductID:     lot.ProductID,
+			ReferenceType: referenceType,
+			ReferenceID:   referenceID,
+			Quantity:      consumed,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		lot.Quantity = consumed
+		consumedLots = append(consumedLots, lot)
+		quantity -= consumed
+	}
+
+	return consumedLots, nil
+}
+
+// returnLot is to take back quantity of purchase return from lot of the returned receipt
+// the rest is consumed from lots on location of the return
+func (mod Module) returnLot(ctx context.Context, tx *sql.Tx, purchaseDtlID int64, stockMovement internal.StockMovement) error {
+	quantity := -stockMovement.Quantity
+
+	if purchaseDtlID != 0 {
+		lots, err := mod.internal.GetLotByReferenceWithTx(ctx, tx, internal.LotReceipt, purchaseDtlID)
+		if err != nil {
+			return err
+		}
+
+		consumedLots, err := mod.allocateLot(ctx, tx, lots, quantity, stockMovement.ReferenceType, stockMovement.ReferenceID)
+		if err != nil {
+			return err
+		}
+
+		for _, consumedLot := range consumedLots {
+			quantity -= consumedLot.Quantity
+		}
+	}
+
+	if quantity == 0 {
+		return nil
+	}
+
+	_, err := mod.consumeLot(ctx, tx,
+		stockMovement.ProductID,
+		stockMovement.LocationID,
+		quantity,
+		stockMovement.ReferenceType,
+		stockMovement.ReferenceID,
+	)
+	return err
+}
+
+// restoreLot is to give back lots which has been consumed by order line for its returned quantity
+// each lot is prorated by quantity of the line which has been returned, rounded down cumulatively,
+// so lots of the line are fully given back when the whole line is returned
+func (mod Module) restoreLot(ctx context.Context, tx *sql.Tx, orderID int64, orderQuantity, prevReturned, returned int) error {
+	if orderQuantity <= 0 {
+		return nil
+	}
+
+	lotAllocations, err := mod.internal.GetLotAllocationByReferenceWithTx(ctx, tx, internal.MovementOrder, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, lotAllocation := range lotAllocations {
+		quantity := lotAllocation.Quantity*(prevReturned+returned)/orderQuantity - lotAllocation.Quantity*prevReturned/orderQuantity
+		if quantity <= 0 {
+			continue
+		}
+
+		err = mod.internal.UpdateLotRemaining(ctx, tx, lotAllocation.LotID, quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseLot is to give back quantity of lots which has been consumed by document
+func (mod Module) releaseLot(ctx context.Context, tx *sql.Tx, referenceType string, referenceID int64) error {
+	lotAllocations, err := mod.internal.GetLotAllocationByReferenceWithTx(ctx, tx, referenceType, referenceID)
+	if err != nil {
+		return err
+	}
+
+	for _, lotAllocation := range lotAllocations {
+		err = mod.internal.UpdateLotRemaining(ctx, tx, lotAllocation.LotID, lotAllocation.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return mod.internal.DeleteLotAllocationByReference(ctx, tx, referenceType, referenceID)
+}
@@ -1,6 +1,9 @@
 package module
 
 import (
+	"context"
+
+	"github.com/sog01/ijahshop/config"
 	"github.com/sog01/ijahshop/module/internal"
 	"github.com/sog01/ijahshop/storage"
 )
@@ -9,20 +12,47 @@ import (
 // Module used to define a functionality of services
 // commonly used, to be exported into handler
 type Module struct {
-	Storage  storage.Storage
-	internal internal.Iinternal
+	Storage       storage.Storage
+	internal      internal.Iinternal
+	costingMethod string
 }
 
 // New to create new instance of module
-func New(storage storage.Storage) Module {
+// configured costing method must be one of the supported costing method
+func New(storage storage.Storage, inventory config.Inventory) (Module, error) {
 	internal := internal.New(storage)
-	return Module{
-		Storage:  storage,
-		internal: internal,
+
+	// moving average is used as default costing method
+	costingMethod := inventory.CostingMethod
+	if costingMethod == "" {
+		costingMethod = CostingMovingAverage
 	}
+
+	switch costingMethod {
+	case CostingAverage, CostingMovingAverage, CostingFIFO:
+	default:
+		return Module{}, ErrInvalidCostingMethod
+	}
+
+	return Module{
+		Storage:       storage,
+		internal:      internal,
+		costingMethod: costingMethod,
+	}, nil
 }
 
 // ImportExcelToDB is to import data from excel to database
 func (mod Module) ImportExcelToDB(filename string) error {
-	return mod.Storage.SeedProductFromEXCEL("files/" + filename)
+	err := mod.Storage.SeedProductFromEXCEL("files/" + filename)
+	if err != nil {
+		return err
+	}
+
+	// imported purchase and order need to be layered
+	err = mod.rebuildCostLayer(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return mod.SyncOrderCost(context.Background())
 }
@@ -9,15 +9,19 @@ import (
 
 // ReqOrder is entity of inputed order
 // use to make request that will be stored into database
+// quantity and price are inputed in Unit of order, empty Unit means base unit of product
 type ReqOrder struct {
 	internal.Order
-	DateRaw string `json:"date_raw"`
+	DateRaw        string `json:"date_raw"`
+	AllowBackorder bool   `json:"allow_backorder"`
 }
 
 // ReqFilterOrder is entity to filter query
 type ReqFilterOrder struct {
-	DateStart time.Time
-	DateEnd   time.Time
+	DateStart     time.Time
+	DateEnd       time.Time
+	CostingMethod string
+	ByParent      bool
 }
 
 // GetOrderWithProduct is used to get all order with product
@@ -36,7 +40,7 @@ func (mod Module) GetOrderWithProduct(ctx context.Context, reqFilter ReqFilterOr
 
 	// calculate total
 	for index, orderWithProduct := range ordersWithProduct {
-		ordersWithProduct[index].Total = orderWithProduct.Price * int64(orderWithProduct.Quantity)
+		ordersWithProduct[index].Total = unitTotal(orderWithProduct.UnitPrice, orderWithProduct.Quantity, orderWithProduct.UnitFactor)
 	}
 
 	return ordersWithProduct, nil
@@ -50,12 +54,13 @@ func (mod Module) GetOrderWithProductByID(ctx context.Context, ID int64) (intern
 	}
 
 	// calculate total
-	orderWithProduct.Total = orderWithProduct.Price * int64(orderWithProduct.Quantity)
+	orderWithProduct.Total = unitTotal(orderWithProduct.UnitPrice, orderWithProduct.Quantity, orderWithProduct.UnitFactor)
 
 	return orderWithProduct, nil
 }
 
 // StoreOrder is to store order into database
+// order is stored as a line of sales order which has the same order number (order_id_format)
 func (mod Module) StoreOrder(ctx context.Context, reqOrder ReqOrder) (ID int64, err error) {
 
 	// date format: yyyy-MM-dd HH:mm:ss
@@ -71,21 +76,125 @@ func (mod Module) StoreOrder(ctx context.Context, reqOrder ReqOrder) (ID int64,
 		Description:   reqOrder.Description,
 		Date:          date,
 		Price:         reqOrder.Price,
+		LocationID:    reqOrder.LocationID,
+		Unit:          reqOrder.Unit,
+		SerialNumbers: reqOrder.SerialNumbers,
 	}
 
-	db := mod.Storage.DB
-	tx, err := db.Begin()
+	// previous order is needed to apply only the delta of stock
+	// when existing order is updated
+	var prevOrders []internal.OrderWithProduct
+	salesOrder := internal.SalesOrder{
+		OrderNumber: order.OrderIDFormat,
+		Status:      internal.SalesOrderConfirmed,
+		Date:        order.Date,
+	}
+	if order.OrderID != 0 {
+		prevOrder, err := mod.internal.GetOrderWithProductByID(ctx, order.OrderID)
+		if err != nil {
+			return 0, err
+		}
+
+		if prevOrder.OrderID == 0 {
+			return 0, ErrInvalidOrderLine
+		}
+		prevOrders = append(prevOrders, prevOrder)
+
+		// updated order stays on its sales order
+		existingSalesOrder, err := mod.internal.GetSalesOrderByID(ctx, prevOrder.SalesOrderID)
+		if err != nil {
+			return 0, err
+		}
+
+		if existingSalesOrder.SalesOrderID != 0 {
+			salesOrder = existingSalesOrder
+		}
+
+		// order which is the only line of its sales order (e.g. stored by this endpoint)
+		// moves its sales order to requested date and order number
+		if existingSalesOrder.TotalLine == 1 {
+			salesOrder.Date = order.Date
+		}
+
+		// order which is moved into other order number goes to sales order of that number
+		if order.OrderIDFormat != "" && order.OrderIDFormat != existingSalesOrder.OrderNumber {
+			targetSalesOrder, err := mod.internal.GetSalesOrderByOrderNumber(ctx, order.OrderIDFormat)
+			if err != nil {
+				return 0, err
+			}
+
+			switch {
+			case targetSalesOrder.SalesOrderID != 0:
+				salesOrder = targetSalesOrder
+			case existingSalesOrder.TotalLine == 1:
+				salesOrder.OrderNumber = order.OrderIDFormat
+			default:
+				salesOrder = internal.SalesOrder{
+					OrderNumber: order.OrderIDFormat,
+					CustomerID:  existingSalesOrder.CustomerID,
+					Customer:    existingSalesOrder.Customer,
+					Status:      internal.SalesOrderConfirmed,
+					Date:        order.Date,
+				}
+			}
+		}
+	} else if order.OrderIDFormat != "" {
+		// new order is added into sales order which has the same order number
+		existingSalesOrder, err := mod.internal.GetSalesOrderByOrderNumber(ctx, order.OrderIDFormat)
+		if err != nil {
+			return 0, err
+		}
+
+		if existingSalesOrder.SalesOrderID != 0 {
+			salesOrder = existingSalesOrder
+		}
+	}
+
+	// customer of existing sales order is kept when it is not requested
+	if reqOrder.CustomerID != 0 {
+		salesOrder.CustomerID = reqOrder.CustomerID
+	}
+
+	_, orderIDs, err := mod.storeSalesOrder(ctx, salesOrder, []internal.Order{order}, prevOrders, reqOrder.AllowBackorder)
 	if err != nil {
 		return 0, err
 	}
 
-	ID, err = mod.internal.StoreOrder(ctx, tx, order)
+	return orderIDs[0], nil
+}
+
+// SyncOrderCost is to snapshot unit cost of order which has not been snapshotted yet
+// the cost is calculated by active costing method at the time the order is made
+func (mod Module) SyncOrderCost(ctx context.Context) error {
+	orders, err := mod.internal.GetOrderWithoutCost(ctx)
 	if err != nil {
-		tx.Rollback()
-		return 0, err
+		return err
+	}
+
+	if len(orders) == 0 {
+		return nil
+	}
+
+	orderCosts, orderCogs, err := mod.getOrderCost(ctx, mod.costingMethod, orders)
+	if err != nil {
+		return err
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		err = mod.internal.UpdateOrderCost(ctx, tx, order.OrderID, int64(orderCosts[order.OrderID]), orderCogs[order.OrderID])
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
-	return ID, tx.Commit()
+	return tx.Commit()
 }
 
 // WriteOrderToCSV to write order entity to CSV
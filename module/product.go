@@ -2,6 +2,8 @@ package module
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/sog01/ijahshop/module/internal"
 )
@@ -13,25 +15,235 @@ type ReqProduct struct {
 }
 
 // GetProduct is used to get all product
+// stock of bundle is quantity which can be made from stock of its components
 func (mod Module) GetProduct(ctx context.Context) ([]internal.Product, error) {
 
-	return mod.internal.GetProduct(ctx)
+	products, err := mod.internal.GetProduct(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	productComponents, err := mod.internal.GetProductComponent(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	componentsByBundle := make(map[int64][]internal.ProductComponent)
+	for _, productComponent := range productComponents {
+		componentsByBundle[productComponent.BundleID] = append(componentsByBundle[productComponent.BundleID], productComponent)
+	}
+
+	for index, product := range products {
+		if product.IsBundle {
+			products[index].Stock = bundleStock(componentsByBundle[product.ProductID])
+		}
+	}
+
+	return products, nil
 }
 
 // GetProductByID is used to get product by ID
 func (mod Module) GetProductByID(ctx context.Context, ID int64) (internal.Product, error) {
 
-	return mod.internal.GetProductByID(ctx, ID)
+	product, err := mod.internal.GetProductByID(ctx, ID)
+	if err != nil || product.ProductID == 0 {
+		return product, err
+	}
+
+	// breakdown of stock on each location
+	product.Locations, err = mod.internal.GetProductLocationStockByProductID(ctx, ID)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	// attribute of variant (e.g. size and color)
+	product.Attributes, err = mod.internal.GetProductAttributeByProductID(ctx, ID)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	// breakdown of stock on each lot which still has stock
+	lotsWithProduct, err := mod.internal.GetAvailableLotWithProductByProductID(ctx, ID)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	for _, lotWithProduct := range lotsWithProduct {
+		product.Lots = append(product.Lots, lotWithProduct.Lot)
+	}
+
+	// pack of product (e.g. dozen, carton) which can be used to purchase and sell the product
+	product.Units, err = mod.internal.GetProductUnitByProductID(ctx, ID)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	// unit of serialized product which is still in stock
+	if product.IsSerialized {
+		product.Serials, err = mod.internal.GetAvailableSerialByProductID(ctx, ID)
+		if err != nil {
+			return internal.Product{}, err
+		}
+	}
+
+	if product.IsBundle {
+		product.Components, err = mod.internal.GetProductComponentByBundleID(ctx, ID)
+		if err != nil {
+			return internal.Product{}, err
+		}
+		product.Stock = bundleStock(product.Components)
+
+		// bundle on each location is made from stock of its components on the location
+		componentLocationStocks := make(map[int64]map[int64]int)
+		for _, productComponent := range product.Components {
+			locationStocks, err := mod.internal.GetProductLocationStockByProductID(ctx, productComponent.ComponentID)
+			if err != nil {
+				return internal.Product{}, err
+			}
+
+			componentLocationStocks[productComponent.ComponentID] = make(map[int64]int)
+			for _, locationStock := range locationStocks {
+				componentLocationStocks[productComponent.ComponentID][locationStock.LocationID] = locationStock.Stock
+			}
+		}
+
+		for index, location := range product.Locations {
+			var locationComponents []internal.ProductComponent
+			for _, productComponent := range product.Components {
+				productComponent.Product.Stock = componentLocationStocks[productComponent.ComponentID][location.LocationID]
+				locationComponents = append(locationComponents, productComponent)
+			}
+			product.Locations[index].Stock = bundleStock(locationComponents)
+		}
+	}
+
+	return product, nil
 }
 
+// bundleStock is used to calculate quantity of bundle which can be made from stock of its components
+func bundleStock(productComponents []internal.ProductComponent) int {
+	if len(productComponents) == 0 {
+		return 0
+	}
+
+	stock := -1
+	for _, productComponent := range productComponents {
+		available := 0
+		if productComponent.Quantity > 0 && productComponent.Product.Stock > 0 {
+			available = productComponent.Product.Stock / productComponent.Quantity
+		}
+
+		if stock < 0 || available < stock {
+			stock = available
+		}
+	}
+
+	return stock
+}
+
+// error of product request
+var (
+	// ErrProductNotFound is error when requested product doesn't exist
+	ErrProductNotFound = errors.New("product not found")
+	// ErrStockNotEditable is error when stock of existing product is changed,
+	// since stock can only be changed by adjustment
+	ErrStockNotEditable = errors.New("stock can't be edited, use adjustment")
+	// ErrBundleStock is error when stock of bundle is moved directly (e.g. purchase or adjustment),
+	// since bundle only consumes stock of its components
+	ErrBundleStock = errors.New("bundle doesn't keep its own stock")
+	// ErrBundleNotEditable is error when existing product is changed into bundle or vice versa
+	ErrBundleNotEditable = errors.New("product can't be changed into bundle or vice versa")
+	// ErrEmptyBundleComponent is error when bundle doesn't have any component
+	ErrEmptyBundleComponent = errors.New("bundle doesn't have any component")
+	// ErrInvalidBundleComponent is error when component doesn't exist, is a bundle,
+	// is listed more than once or its quantity is not positive
+	ErrInvalidBundleComponent = errors.New("invalid bundle component")
+	// ErrInvalidReorder is error when reorder point or reorder quantity is negative
+	ErrInvalidReorder = errors.New("invalid reorder point or reorder quantity")
+	// ErrBundleSold is error when component of bundle which has been sold is changed,
+	// since stock of its order is taken from the previous components
+	ErrBundleSold = errors.New("component of sold bundle can't be changed")
+	// ErrProductIsComponent is error when product which is component of bundle is deleted,
+	// since the bundle can't be made without it
+	ErrProductIsComponent = errors.New("product is component of bundle")
+)
+
 // StoreProduct is to store product into database
+// stock of new product is recorded as its opening balance,
+// while stock of existing product can only be changed by adjustment
+// bundle doesn't have opening balance, its components replace the previous one
 func (mod Module) StoreProduct(ctx context.Context, reqProduct ReqProduct) (ID int64, err error) {
 
 	product := internal.Product{
-		ProductID: reqProduct.ProductID,
-		Name:      reqProduct.Name,
-		Sku:       reqProduct.Sku,
-		Stock:     reqProduct.Stock,
+		ProductID:       reqProduct.ProductID,
+		Name:            reqProduct.Name,
+		Sku:             reqProduct.Sku,
+		Stock:           reqProduct.Stock,
+		IsBundle:        reqProduct.IsBundle,
+		ReorderPoint:    reqProduct.ReorderPoint,
+		ReorderQuantity: reqProduct.ReorderQuantity,
+		IsSerialized:    reqProduct.IsSerialized,
+		Unit:            reqProduct.Unit,
+		Components:      reqProduct.Components,
+		Units:           reqProduct.Units,
+	}
+
+	if product.ReorderPoint < 0 || product.ReorderQuantity < 0 {
+		return 0, ErrInvalidReorder
+	}
+
+	err = validateProductUnit(&product)
+	if err != nil {
+		return 0, err
+	}
+
+	// each unit of serialized product must be received with its serial number
+	if product.ProductID == 0 && product.IsSerialized && product.Stock != 0 {
+		return 0, ErrSerializedStock
+	}
+
+	if product.ProductID != 0 {
+		prevProduct, err := mod.internal.GetProductByID(ctx, product.ProductID)
+		if err != nil {
+			return 0, err
+		}
+
+		if prevProduct.ProductID == 0 {
+			return 0, ErrProductNotFound
+		}
+
+		if product.IsBundle != prevProduct.IsBundle {
+			return 0, ErrBundleNotEditable
+		}
+
+		// stock of bundle is calculated from its components
+		if !product.IsBundle && product.Stock != prevProduct.Stock {
+			return 0, ErrStockNotEditable
+		}
+
+		if product.IsBundle {
+			err = mod.checkBundleSold(ctx, product)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		// unit which is already in stock doesn't have serial number
+		if product.IsSerialized != prevProduct.IsSerialized && prevProduct.Stock != 0 {
+			return 0, ErrSerializedNotEditable
+		}
+
+		if product.IsSerialized && !prevProduct.IsSerialized {
+			err = mod.checkComponentSerialized(ctx, product.ProductID)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	err = mod.validateBundle(ctx, product)
+	if err != nil {
+		return 0, err
 	}
 
 	db := mod.Storage.DB
@@ -46,22 +258,182 @@ func (mod Module) StoreProduct(ctx context.Context, reqProduct ReqProduct) (ID i
 		return 0, err
 	}
 
+	if product.IsBundle {
+		err = mod.internal.DeleteProductComponentByBundleID(ctx, tx, ID)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		for _, productComponent := range product.Components {
+			productComponent.BundleID = ID
+			err = mod.internal.StoreProductComponent(ctx, tx, productComponent)
+			if err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+		}
+	}
+
+	// unit of product replaces the previous one, purchase and order keep factor of their unit
+	// so changing the unit doesn't change the stock which has been moved
+	err = mod.internal.DeleteProductUnitByProductID(ctx, tx, ID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, productUnit := range product.Units {
+		productUnit.ProductID = ID
+		err = mod.internal.StoreProductUnit(ctx, tx, productUnit)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if product.ProductID == 0 && !product.IsBundle {
+		stockMovement := internal.StockMovement{
+			ProductID:     ID,
+			Quantity:      product.Stock,
+			ReferenceType: internal.MovementOpening,
+			ReferenceID:   ID,
+			Description:   "Saldo Awal",
+			Date:          time.Now(),
+		}
+
+		err = mod.storeStockMovement(ctx, tx, stockMovement)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		err = mod.storeCostLayer(ctx, tx, stockMovement)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
 	return ID, tx.Commit()
 }
 
+// validateBundle is used to validate component of bundle
+// component must be existing product which is not a bundle
+func (mod Module) validateBundle(ctx context.Context, product internal.Product) error {
+	if product.IsBundle && product.IsSerialized {
+		return ErrSerializedBundle
+	}
+
+	if !product.IsBundle {
+		if len(product.Components) > 0 {
+			return ErrInvalidBundleComponent
+		}
+		return nil
+	}
+
+	if product.ProductID == 0 && product.Stock != 0 {
+		return ErrBundleStock
+	}
+
+	if len(product.Components) == 0 {
+		return ErrEmptyBundleComponent
+	}
+
+	isListed := make(map[int64]bool)
+	for _, productComponent := range product.Components {
+		if productComponent.Quantity <= 0 || isListed[productComponent.ComponentID] || productComponent.ComponentID == product.ProductID {
+			return ErrInvalidBundleComponent
+		}
+		isListed[productComponent.ComponentID] = true
+
+		component, err := mod.internal.GetProductByID(ctx, productComponent.ComponentID)
+		if err != nil {
+			return err
+		}
+
+		if component.ProductID == 0 || component.IsBundle {
+			return ErrInvalidBundleComponent
+		}
+
+		if component.IsSerialized {
+			return ErrSerializedBundle
+		}
+	}
+
+	return nil
+}
+
+// checkComponentSerialized is used to make sure component of bundle is not changed into serialized product
+// since bundle takes stock of its components without serial number
+func (mod Module) checkComponentSerialized(ctx context.Context, productID int64) error {
+	productComponents, err := mod.internal.GetProductComponent(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, productComponent := range productComponents {
+		if productComponent.ComponentID == productID {
+			return ErrSerializedBundle
+		}
+	}
+
+	return nil
+}
+
+// checkBundleSold is used to make sure component of bundle which has been sold is not changed
+func (mod Module) checkBundleSold(ctx context.Context, product internal.Product) error {
+	prevComponents, err := mod.internal.GetProductComponentByBundleID(ctx, product.ProductID)
+	if err != nil {
+		return err
+	}
+
+	prevQuantities := make(map[int64]int)
+	for _, prevComponent := range prevComponents {
+		prevQuantities[prevComponent.ComponentID] = prevComponent.Quantity
+	}
+
+	isChanged := len(prevComponents) != len(product.Components)
+	for _, productComponent := range product.Components {
+		if prevQuantities[productComponent.ComponentID] != productComponent.Quantity {
+			isChanged = true
+		}
+	}
+
+	if !isChanged {
+		return nil
+	}
+
+	totalOrder, err := mod.internal.CountOrderByProductID(ctx, product.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if totalOrder > 0 {
+		return ErrBundleSold
+	}
+
+	return nil
+}
+
 // DeleteProduct is to delete product from database
+// component of deleted bundle is removed together with the bundle
 func (mod Module) DeleteProduct(ctx context.Context, ID int64) error {
-	err := mod.internal.DeleteProduct(ctx, ID)
+	totalBundle, err := mod.internal.CountProductComponentByComponentID(ctx, ID)
 	if err != nil {
 		return err
 	}
 
-	return err
+	if totalBundle > 0 {
+		return ErrProductIsComponent
+	}
+
+	return mod.internal.DeleteProduct(ctx, ID)
 }
 
 // WriteProductToCSV to write product entity to CSV
 func (mod Module) WriteProductToCSV(ctx context.Context) error {
-	product, err := mod.internal.GetProduct(ctx)
+	product, err := mod.GetProduct(ctx)
 	if err != nil {
 		return err
 	}
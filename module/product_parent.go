@@ -0,0 +1,308 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqProductParent is entity of inputed product parent
+// use to make request that will be stored into database
+type ReqProductParent struct {
+	internal.ProductParent
+}
+
+// ReqProductVariant is entity of inputed variant attribute of product parent
+// variant is generated from every combination of attribute option
+type ReqProductVariant struct {
+	Attributes []ReqVariantAttribute `json:"attributes"`
+}
+
+// ReqVariantAttribute is attribute of variant with its option (e.g. size: L, XL)
+type ReqVariantAttribute struct {
+	Name    string             `json:"name"`
+	Options []ReqVariantOption `json:"options"`
+}
+
+// ReqVariantOption is option of variant attribute
+// Code is used as part of generated sku, empty code means value is used as code
+type ReqVariantOption struct {
+	Value string `json:"value"`
+	Code  string `json:"code"`
+}
+
+// ProductParentWithVariant is entity of product parent with its variant
+// Attributes is list of distinct value of each attribute of the variants
+type ProductParentWithVariant struct {
+	internal.ProductParent
+	Attributes map[string][]string `json:"attributes"`
+	Variants   []internal.Product  `json:"variants"`
+}
+
+// error of product parent request
+var (
+	// ErrEmptyProductParent is error when product parent doesn't have name or sku
+	ErrEmptyProductParent = errors.New("product parent name and sku are required")
+	// ErrDuplicateProductParentSku is error when sku has been used by other product parent
+	ErrDuplicateProductParentSku = errors.New("product parent sku has been used")
+	// ErrProductParentNotFound is error when requested product parent doesn't exist
+	ErrProductParentNotFound = errors.New("product parent not found")
+	// ErrEmptyVariantAttribute is error when variant attribute doesn't have name or option
+	ErrEmptyVariantAttribute = errors.New("variant attribute name and option are required")
+	// ErrVariantSkuUsed is error when generated sku belongs to variant of other product parent
+	ErrVariantSkuUsed = errors.New("variant sku has been used by other product parent")
+)
+
+// GetProductParent is used to get all product parent
+func (mod Module) GetProductParent(ctx context.Context) ([]internal.ProductParent, error) {
+	return mod.internal.GetProductParent(ctx)
+}
+
+// GetProductParentByID is used to get product parent with its variant by ID
+func (mod Module) GetProductParentByID(ctx context.Context, ID int64) (ProductParentWithVariant, error) {
+	productParent, err := mod.internal.GetProductParentByID(ctx, ID)
+	if err != nil {
+		return ProductParentWithVariant{}, err
+	}
+
+	if productParent.ParentID == 0 {
+		return ProductParentWithVariant{}, ErrProductParentNotFound
+	}
+
+	variants, err := mod.internal.GetProductByParentID(ctx, ID)
+	if err != nil {
+		return ProductParentWithVariant{}, err
+	}
+
+	productAttributes, err := mod.internal.GetProductAttributeByParentID(ctx, ID)
+	if err != nil {
+		return ProductParentWithVariant{}, err
+	}
+
+	productParentWithVariant := ProductParentWithVariant{
+		ProductParent: productParent,
+		Attributes:    make(map[string][]string),
+		Variants:      variants,
+	}
+
+	var (
+		indexByProduct = make(map[int64]int)
+		isListed       = make(map[internal.ProductAttribute]bool)
+	)
+	for index, variant := range variants {
+		indexByProduct[variant.ProductID] = index
+	}
+
+	for _, productAttribute := range productAttributes {
+		index := indexByProduct[productAttribute.ProductID]
+		variants[index].Attributes = append(variants[index].Attributes, productAttribute)
+
+		// list each value once regardless of its variant
+		listed := internal.ProductAttribute{Name: productAttribute.Name, Value: productAttribute.Value}
+		if isListed[listed] {
+			continue
+		}
+
+		isListed[listed] = true
+		productParentWithVariant.Attributes[productAttribute.Name] = append(productParentWithVariant.Attributes[productAttribute.Name], productAttribute.Value)
+	}
+
+	return productParentWithVariant, nil
+}
+
+// StoreProductParent is to store product parent into database
+func (mod Module) StoreProductParent(ctx context.Context, reqProductParent ReqProductParent) (ID int64, err error) {
+	productParent := internal.ProductParent{
+		ParentID:    reqProductParent.ParentID,
+		Name:        strings.Trim(reqProductParent.Name, " "),
+		Sku:         strings.Trim(reqProductParent.Sku, " "),
+		Description: reqProductParent.Description,
+	}
+
+	if productParent.Name == "" || productParent.Sku == "" {
+		return 0, ErrEmptyProductParent
+	}
+
+	if productParent.ParentID != 0 {
+		prevProductParent, err := mod.internal.GetProductParentByID(ctx, productParent.ParentID)
+		if err != nil {
+			return 0, err
+		}
+
+		if prevProductParent.ParentID == 0 {
+			return 0, ErrProductParentNotFound
+		}
+	}
+
+	existingProductParent, err := mod.internal.GetProductParentBySku(ctx, productParent.Sku)
+	if err != nil {
+		return 0, err
+	}
+
+	if existingProductParent.ParentID != 0 && existingProductParent.ParentID != productParent.ParentID {
+		return 0, ErrDuplicateProductParentSku
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	ID, err = mod.internal.StoreProductParent(ctx, tx, productParent)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
+
+// StoreProductVariant is to generate variant of product parent from combination of attribute option
+// sku of variant is sku of parent followed by code of each option (e.g. SSI-D01401071-LL-RED)
+// existing product which has the generated sku is linked as variant and keeps its name and stock,
+// so it keeps working on order and purchase, while new variant is stored without stock
+func (mod Module) StoreProductVariant(ctx context.Context, parentID int64, reqProductVariant ReqProductVariant) ([]int64, error) {
+	productParent, err := mod.internal.GetProductParentByID(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if productParent.ParentID == 0 {
+		return nil, ErrProductParentNotFound
+	}
+
+	if len(reqProductVariant.Attributes) == 0 {
+		return nil, ErrEmptyVariantAttribute
+	}
+
+	for _, attribute := range reqProductVariant.Attributes {
+		if strings.Trim(attribute.Name, " ") == "" || len(attribute.Options) == 0 {
+			return nil, ErrEmptyVariantAttribute
+		}
+
+		for _, option := range attribute.Options {
+			if strings.Trim(option.Value, " ") == "" {
+				return nil, ErrEmptyVariantAttribute
+			}
+		}
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var IDs []int64
+	for _, combination := range combineVariantOption(reqProductVariant.Attributes) {
+		var (
+			codes  []string
+			values []string
+		)
+		for _, option := range combination {
+			code := strings.Trim(option.Code, " ")
+			if code == "" {
+				code = strings.Trim(option.Value, " ")
+			}
+
+			codes = append(codes, code)
+			values = append(values, strings.Trim(option.Value, " "))
+		}
+
+		product, err := mod.internal.GetProductBySkuWithTx(ctx, tx, productParent.Sku+"-"+strings.Join(codes, "-"))
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if product.ProductID != 0 && product.ParentID != 0 && product.ParentID != parentID {
+			tx.Rollback()
+			return nil, ErrVariantSkuUsed
+		}
+
+		if product.ProductID == 0 {
+			product.ProductID, err = mod.internal.StoreProduct(ctx, tx, internal.Product{
+				Name:     productParent.Name + " (" + strings.Join(values, ",") + ")",
+				Sku:      productParent.Sku + "-" + strings.Join(codes, "-"),
+				ParentID: parentID,
+			})
+		} else {
+			err = mod.internal.UpdateProductParentID(ctx, tx, product.ProductID, parentID)
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		for index, attribute := range reqProductVariant.Attributes {
+			err = mod.internal.StoreProductAttribute(ctx, tx, internal.ProductAttribute{
+				ProductID: product.ProductID,
+				Name:      strings.Trim(attribute.Name, " "),
+				Value:     values[index],
+			})
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		IDs = append(IDs, product.ProductID)
+	}
+
+	return IDs, tx.Commit()
+}
+
+// combineVariantOption is used to get every combination of attribute option
+// combination follows the order of attribute and its option
+func combineVariantOption(attributes []ReqVariantAttribute) [][]ReqVariantOption {
+	combinations := [][]ReqVariantOption{{}}
+	for _, attribute := range attributes {
+		var nextCombinations [][]ReqVariantOption
+		for _, combination := range combinations {
+			for _, option := range attribute.Options {
+				nextCombination := make([]ReqVariantOption, len(combination), len(combination)+1)
+				copy(nextCombination, combination)
+				nextCombinations = append(nextCombinations, append(nextCombination, option))
+			}
+		}
+		combinations = nextCombinations
+	}
+
+	return combinations
+}
+
+// DeleteProductParent is to delete product parent from database
+// its variants are kept as product without parent
+func (mod Module) DeleteProductParent(ctx context.Context, ID int64) error {
+	productParent, err := mod.internal.GetProductParentByID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if productParent.ParentID == 0 {
+		return ErrProductParentNotFound
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = mod.internal.UnlinkProductByParentID(ctx, tx, ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = mod.internal.DeleteProductParent(ctx, tx, ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
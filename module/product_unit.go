@@ -0,0 +1,88 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// error of unit of measure request
+var (
+	// ErrUnitNotFound is error when requested unit is neither base unit nor unit of the product
+	ErrUnitNotFound = errors.New("unit of product not found")
+	// ErrInvalidProductUnit is error when unit of product doesn't have name, is listed more than once,
+	// has the same name as base unit or its factor is not more than one
+	ErrInvalidProductUnit = errors.New("invalid product unit")
+)
+
+// validateProductUnit is used to trim and validate unit of product
+// each unit must contain more than one base unit, since base unit itself is not a pack
+func validateProductUnit(product *internal.Product) error {
+	product.Unit = strings.Trim(product.Unit, " ")
+	if product.Unit == "" {
+		product.Unit = internal.DefaultUnit
+	}
+
+	isListed := map[string]bool{strings.ToLower(product.Unit): true}
+	for index, productUnit := range product.Units {
+		name := strings.Trim(productUnit.Name, " ")
+		if name == "" || productUnit.Factor <= 1 || isListed[strings.ToLower(name)] {
+			return ErrInvalidProductUnit
+		}
+		isListed[strings.ToLower(name)] = true
+
+		product.Units[index].Name = name
+	}
+
+	return nil
+}
+
+// getProductUnit is used to get name and factor of requested unit of product
+// empty unit means base unit of product, which factor is one
+func (mod Module) getProductUnit(ctx context.Context, productID int64, unit string) (string, int, error) {
+	product, err := mod.internal.GetProductByID(ctx, productID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	unit = strings.Trim(unit, " ")
+	if unit == "" || strings.EqualFold(unit, product.Unit) {
+		return product.Unit, 1, nil
+	}
+
+	productUnits, err := mod.internal.GetProductUnitByProductID(ctx, productID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	for _, productUnit := range productUnits {
+		if strings.EqualFold(unit, productUnit.Name) {
+			return productUnit.Name, productUnit.Factor, nil
+		}
+	}
+
+	return "", 0, ErrUnitNotFound
+}
+
+// baseUnitPrice is used to convert price (or cost) of one unit into price of one base unit
+// rounded to the nearest integer, since stock and its cost are kept in base unit
+func baseUnitPrice(price int64, factor int) int64 {
+	if factor <= 1 {
+		return price
+	}
+
+	return int64(math.Round(float64(price) / float64(factor)))
+}
+
+// unitTotal is used to calculate total of quantity (in base unit) at price (or cost) of one unit,
+// so total of document which is inputed in pack is not affected by rounding of price of its base unit
+func unitTotal(unitPrice int64, quantity, factor int) int64 {
+	if factor <= 1 {
+		return unitPrice * int64(quantity)
+	}
+
+	return int64(math.Round(float64(unitPrice) * float64(quantity) / float64(factor)))
+}
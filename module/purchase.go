@@ -2,6 +2,8 @@ package module
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/sog01/ijahshop/module/internal"
@@ -9,33 +11,61 @@ import (
 
 // ReqPurchase is entity of inputed purchase with purchase detail
 // use to make request that will be stored into database
+// LocationID, LotNumber, ExpiryDate and SerialNumbers are used for receipt
+// when purchase is accepted without any receipt
+// quantity and cost of purchase and its receipts are inputed in Unit of purchase,
+// empty Unit means base unit of product
 type ReqPurchase struct {
 	internal.Purchase
-	DateRaw     string           `json:"date_raw"`
-	PurchaseDtl []ReqPurchaseDtl `json:"purchase_dtl"`
+	DateRaw       string           `json:"date_raw"`
+	LocationID    int64            `json:"location_id"`
+	LotNumber     string           `json:"lot_number"`
+	ExpiryDate    string           `json:"expiry_date"`
+	SerialNumbers []string         `json:"serial_numbers"`
+	PurchaseDtl   []ReqPurchaseDtl `json:"purchase_dtl"`
 }
 
 // ReqPurchaseDtl is entity of inputed purchase detail
 // use to make request that will be stored into database
+// zero LocationID means receipt goes to default location
 type ReqPurchaseDtl struct {
 	internal.PurchaseDtl
 	DateRaw string `json:"date_raw"`
 }
 
 // ReqFilterPurchase is entity to filter query
+// zero SupplierID means purchase of all supplier
 type ReqFilterPurchase struct {
-	DateStart time.Time
-	DateEnd   time.Time
+	DateStart  time.Time
+	DateEnd    time.Time
+	SupplierID int64
 }
 
+// error of purchase receipt
+var (
+	// ErrPurchaseNotFound is error when received purchase doesn't exist
+	ErrPurchaseNotFound = errors.New("purchase not found")
+	// ErrInvalidReceiptQuantity is error when received quantity is not positive
+	// or more than ordered quantity of purchase
+	ErrInvalidReceiptQuantity = errors.New("invalid receipt quantity")
+	// ErrPurchaseDtlNotFound is error when updated receipt doesn't belong to the purchase
+	ErrPurchaseDtlNotFound = errors.New("receipt of purchase not found")
+	// ErrReturnedPurchase is error when product of purchase which has been returned is changed
+	// or its received quantity is less than returned quantity
+	ErrReturnedPurchase = errors.New("purchase has been returned")
+)
+
 // GetPurchaseWithProduct is used to get all purchase with product
 func (mod Module) GetPurchaseWithProduct(ctx context.Context, reqFilter ReqFilterPurchase) ([]internal.PurchaseWithProduct, error) {
 	var err error
 	purchasesProduct := []internal.PurchaseWithProduct{}
 
-	if reqFilter.DateStart != (time.Time{}) && reqFilter.DateEnd != (time.Time{}) {
+	switch {
+	case reqFilter.SupplierID != 0:
+		purchasesProduct, err = mod.internal.GetPurchaseWithProductBySupplierID(ctx, reqFilter.SupplierID, reqFilter.DateStart, reqFilter.DateEnd)
+	case reqFilter.DateStart != (time.Time{}) && reqFilter.DateEnd != (time.Time{}):
 		purchasesProduct, err = mod.internal.GetPurchaseWithProductByDate(ctx, reqFilter.DateStart, reqFilter.DateEnd)
-	} else {
+	default:
 		purchasesProduct, err = mod.internal.GetPurchaseWithProduct(ctx)
 	}
 
@@ -45,13 +75,14 @@ func (mod Module) GetPurchaseWithProduct(ctx context.Context, reqFilter ReqFilte
 
 	// calculate total
 	for index, purchaseProduct := range purchasesProduct {
-		purchasesProduct[index].Total = purchaseProduct.Cost * int64(purchaseProduct.QuantityOrder)
+		purchasesProduct[index].Total = unitTotal(purchaseProduct.UnitCost, purchaseProduct.QuantityOrder, purchaseProduct.UnitFactor)
 	}
 
 	return purchasesProduct, nil
 }
 
 // GetPurchaseWithProductByID is used to get purchase with product by ID
+// including its receipts and returns
 func (mod Module) GetPurchaseWithProductByID(ctx context.Context, ID int64) (internal.PurchaseWithProduct, error) {
 
 	purchaseProduct, err := mod.internal.GetPurchaseWithProductByID(ctx, ID)
@@ -60,29 +91,168 @@ func (mod Module) GetPurchaseWithProductByID(ctx context.Context, ID int64) (int
 	}
 
 	// calculate total
-	purchaseProduct.Total = purchaseProduct.Cost * int64(purchaseProduct.QuantityOrder)
+	purchaseProduct.Total = unitTotal(purchaseProduct.UnitCost, purchaseProduct.QuantityOrder, purchaseProduct.UnitFactor)
+
+	purchaseProduct.Receipts, err = mod.internal.GetPurchaseDtlByPurchaseID(ctx, ID)
+	if err != nil {
+		return internal.PurchaseWithProduct{}, err
+	}
+
+	purchaseProduct.Returns, err = mod.internal.GetPurchaseReturnWithPurchaseByPurchaseID(ctx, ID)
+	if err != nil {
+		return internal.PurchaseWithProduct{}, err
+	}
+
+	// calculate total of return
+	for index, purchaseReturn := range purchaseProduct.Returns {
+		purchaseProduct.Returns[index].Total = purchaseReturn.Cost * int64(purchaseReturn.Quantity)
+	}
 
 	return purchaseProduct, nil
 }
 
-// StorePurchase is to store purchase into database
-func (mod Module) StorePurchase(ctx context.Context, reqPurchase ReqPurchase) (ID int64, err error) {
+// newPurchase is used to validate request of purchase and convert it into purchase and its receipts
+// quantity and cost of purchase is normalized into base unit
+func (mod Module) newPurchase(ctx context.Context, reqPurchase ReqPurchase) (purchase internal.Purchase, purchaseDtls []internal.PurchaseDtl, err error) {
 
 	// date format: yyyy-MM-dd HH:mm:ss
 	reqPurchase.Date, err = time.Parse("2006-01-02 15:04:05", reqPurchase.DateRaw)
+	if err != nil {
+		return internal.Purchase{}, nil, err
+	}
+	purchase = internal.Purchase{
+		PurchaseID:    reqPurchase.PurchaseID,
+		ProductID:     reqPurchase.ProductID,
+		QuantityOrder: reqPurchase.QuantityOrder,
+		Description:   reqPurchase.Description,
+		InvoiceNumber: reqPurchase.InvoiceNumber,
+		Cost:          reqPurchase.Cost,
+		Date:          reqPurchase.Date,
+		SupplierID:    reqPurchase.SupplierID,
+	}
+
+	// stock is kept in base unit, so quantity and cost of purchase is normalized into base unit
+	purchase.Unit, purchase.UnitFactor, err = mod.getProductUnit(ctx, purchase.ProductID, reqPurchase.Unit)
+	if err != nil {
+		return internal.Purchase{}, nil, err
+	}
+	purchase.QuantityOrder *= purchase.UnitFactor
+	purchase.UnitCost = purchase.Cost
+	purchase.Cost = baseUnitPrice(purchase.Cost, purchase.UnitFactor)
+
+	// purchase without supplier is still allowed (e.g. bought from market)
+	if purchase.SupplierID != 0 {
+		supplier, err := mod.internal.GetSupplierByID(ctx, purchase.SupplierID)
+		if err != nil {
+			return internal.Purchase{}, nil, err
+		}
+
+		if supplier.SupplierID == 0 {
+			return internal.Purchase{}, nil, ErrSupplierNotFound
+		}
+	}
+
+	for _, reqPurchaseDtl := range reqPurchase.PurchaseDtl {
+		// date format: yyyy-MM-dd HH:mm:ss
+		reqPurchaseDtl.Date, err = time.Parse("2006-01-02 15:04:05", reqPurchaseDtl.DateRaw)
+		if err != nil {
+			return internal.Purchase{}, nil, err
+		}
+
+		if reqPurchaseDtl.Quantity <= 0 {
+			return internal.Purchase{}, nil, ErrInvalidReceiptQuantity
+		}
+
+		reqPurchaseDtl.LocationID, err = mod.getLocationID(ctx, reqPurchaseDtl.LocationID)
+		if err != nil {
+			return internal.Purchase{}, nil, err
+		}
+
+		err = sanitizeLot(&reqPurchaseDtl.PurchaseDtl)
+		if err != nil {
+			return internal.Purchase{}, nil, err
+		}
+
+		purchaseDtls = append(purchaseDtls, internal.PurchaseDtl{
+			PurchaseDtlID: reqPurchaseDtl.PurchaseDtlID,
+			Quantity:      reqPurchaseDtl.Quantity * purchase.UnitFactor,
+			Description:   reqPurchaseDtl.Description,
+			LocationID:    reqPurchaseDtl.LocationID,
+			LotNumber:     reqPurchaseDtl.LotNumber,
+			ExpiryDate:    reqPurchaseDtl.ExpiryDate,
+			Date:          reqPurchaseDtl.Date,
+			SerialNumbers: reqPurchaseDtl.SerialNumbers,
+		})
+	}
+
+	// new purchase which is already accepted without any receipt
+	// is received at the date of purchase
+	if purchase.PurchaseID == 0 && len(purchaseDtls) == 0 && reqPurchase.QuantityAccepted > 0 {
+		locationID, err := mod.getLocationID(ctx, reqPurchase.LocationID)
+		if err != nil {
+			return internal.Purchase{}, nil, err
+		}
+
+		purchaseDtl := internal.PurchaseDtl{
+			Quantity:      reqPurchase.QuantityAccepted * purchase.UnitFactor,
+			Description:   purchase.Description,
+			LocationID:    locationID,
+			LotNumber:     reqPurchase.LotNumber,
+			ExpiryDate:    reqPurchase.ExpiryDate,
+			Date:          purchase.Date,
+			SerialNumbers: reqPurchase.SerialNumbers,
+		}
+
+		err = sanitizeLot(&purchaseDtl)
+		if err != nil {
+			return internal.Purchase{}, nil, err
+		}
+
+		purchaseDtls = append(purchaseDtls, purchaseDtl)
+	}
+
+	return purchase, purchaseDtls, nil
+}
+
+// StorePurchase is to store purchase into database
+// accepted quantity is sum of its receipts (purchase detail)
+func (mod Module) StorePurchase(ctx context.Context, reqPurchase ReqPurchase) (ID int64, err error) {
+	purchase, purchaseDtls, err := mod.newPurchase(ctx, reqPurchase)
 	if err != nil {
 		return 0, err
 	}
-	purchase := internal.Purchase{
-		PurchaseID:       reqPurchase.PurchaseID,
-		ProductID:        reqPurchase.ProductID,
-		QuantityOrder:    reqPurchase.QuantityOrder,
-		QuantityAccepted: reqPurchase.QuantityAccepted,
-		Description:      reqPurchase.Description,
-		InvoiceNumber:    reqPurchase.InvoiceNumber,
-		Cost:             reqPurchase.Cost,
-		Date:             reqPurchase.Date,
-		IsFinish:         reqPurchase.IsFinish,
+
+	// previous purchase is needed to apply only the delta of stock
+	// when existing purchase is updated, and lot of its receipts follows product of the purchase
+	var (
+		prevPurchase internal.PurchaseWithProduct
+		prevReceipts []internal.PurchaseDtl
+	)
+	if purchase.PurchaseID != 0 {
+		prevPurchase, err = mod.internal.GetPurchaseWithProductByID(ctx, purchase.PurchaseID)
+		if err != nil {
+			return 0, err
+		}
+		purchase.QuantityAccepted = prevPurchase.QuantityAccepted
+
+		prevReceipts, err = mod.internal.GetPurchaseDtlByPurchaseID(ctx, purchase.PurchaseID)
+		if err != nil {
+			return 0, err
+		}
+
+		// unit which has been received is registered for product of the purchase
+		if prevPurchase.QuantityAccepted > 0 && prevPurchase.ProductID != purchase.ProductID {
+			for _, productID := range []int64{prevPurchase.ProductID, purchase.ProductID} {
+				product, err := mod.internal.GetProductByID(ctx, productID)
+				if err != nil {
+					return 0, err
+				}
+
+				if product.IsSerialized {
+					return 0, ErrSerializedNotEditable
+				}
+			}
+		}
 	}
 
 	db := mod.Storage.DB
@@ -91,34 +261,229 @@ func (mod Module) StorePurchase(ctx context.Context, reqPurchase ReqPurchase) (I
 		return 0, err
 	}
 
-	purchaseID, err := mod.internal.StorePurchase(ctx, tx, purchase)
+	ID, err = mod.storePurchase(ctx, tx, purchase, purchaseDtls, prevPurchase, prevReceipts)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
-	for _, reqPurchaseDtl := range reqPurchase.PurchaseDtl {
-		// date format: yyyy-MM-dd HH:mm:ss
-		reqPurchaseDtl.Date, err = time.Parse("2006-01-02 15:04:05", reqPurchaseDtl.DateRaw)
+	return ID, tx.Commit()
+}
+
+// storePurchase is to store purchase and its receipts within transaction
+// and then move the stock of the delta of its accepted quantity
+func (mod Module) storePurchase(ctx context.Context, tx *sql.Tx, purchase internal.Purchase, purchaseDtls []internal.PurchaseDtl, prevPurchase internal.PurchaseWithProduct, prevReceipts []internal.PurchaseDtl) (ID int64, err error) {
+	purchase.PurchaseID, err = mod.internal.StorePurchase(ctx, tx, purchase)
+	if err != nil {
+		return 0, err
+	}
+
+	receiptByID := make(map[int64]internal.PurchaseDtl)
+	for _, prevReceipt := range prevReceipts {
+		receiptByID[prevReceipt.PurchaseDtlID] = prevReceipt
+	}
+
+	for _, purchaseDtl := range purchaseDtls {
+		// only receipt of the purchase can be updated
+		if purchaseDtl.PurchaseDtlID != 0 {
+			prevReceipt, err := mod.internal.GetPurchaseDtlByIDWithTx(ctx, tx, purchaseDtl.PurchaseDtlID)
+			if err != nil {
+				return 0, err
+			}
+
+			if prevReceipt.PurchaseID != purchase.PurchaseID {
+				return 0, ErrPurchaseDtlNotFound
+			}
+		}
+
+		purchaseDtl.PurchaseID = purchase.PurchaseID
+		purchaseDtl.PurchaseDtlID, err = mod.internal.StorePurchaseDtl(ctx, tx, purchaseDtl)
 		if err != nil {
-			tx.Rollback()
 			return 0, err
 		}
-		purchaseDtl := internal.PurchaseDtl{
-			PurchaseDtlID: reqPurchaseDtl.PurchaseDtlID,
-			PurchaseID:    purchaseID,
-			Quantity:      reqPurchaseDtl.Quantity,
-			Description:   reqPurchaseDtl.Description,
-			Date:          reqPurchaseDtl.Date,
+		receiptByID[purchaseDtl.PurchaseDtlID] = purchaseDtl
+
+		err = mod.receiveSerial(ctx, tx, purchase, purchaseDtl)
+		if err != nil {
+			return 0, err
 		}
-		_, err = mod.internal.StorePurchaseDtl(ctx, tx, purchaseDtl)
+	}
+
+	err = mod.receivePurchase(ctx, tx, purchase, prevPurchase, purchase.Date)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, receipt := range receiptByID {
+		err = mod.syncLot(ctx, tx, purchase.ProductID, receipt)
 		if err != nil {
-			tx.Rollback()
 			return 0, err
 		}
 	}
 
-	return purchaseID, tx.Commit()
+	return purchase.PurchaseID, nil
+}
+
+// StorePurchaseReceipt is to store receipt (partial delivery) of purchase into database
+// quantity of receipt is inputed in unit of the purchase
+func (mod Module) StorePurchaseReceipt(ctx context.Context, purchaseID int64, reqPurchaseDtl ReqPurchaseDtl) (ID int64, err error) {
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	date, err := time.Parse("2006-01-02 15:04:05", reqPurchaseDtl.DateRaw)
+	if err != nil {
+		return 0, err
+	}
+
+	if reqPurchaseDtl.Quantity <= 0 {
+		return 0, ErrInvalidReceiptQuantity
+	}
+
+	locationID, err := mod.getLocationID(ctx, reqPurchaseDtl.LocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = sanitizeLot(&reqPurchaseDtl.PurchaseDtl)
+	if err != nil {
+		return 0, err
+	}
+
+	prevPurchase, err := mod.internal.GetPurchaseWithProductByID(ctx, purchaseID)
+	if err != nil {
+		return 0, err
+	}
+
+	if prevPurchase.PurchaseID == 0 {
+		return 0, ErrPurchaseNotFound
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	purchaseDtl := internal.PurchaseDtl{
+		PurchaseID:    purchaseID,
+		Quantity:      reqPurchaseDtl.Quantity * prevPurchase.UnitFactor,
+		Description:   reqPurchaseDtl.Description,
+		LocationID:    locationID,
+		LotNumber:     reqPurchaseDtl.LotNumber,
+		ExpiryDate:    reqPurchaseDtl.ExpiryDate,
+		Date:          date,
+		SerialNumbers: reqPurchaseDtl.SerialNumbers,
+	}
+
+	ID, err = mod.internal.StorePurchaseDtl(ctx, tx, purchaseDtl)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = mod.receivePurchase(ctx, tx, prevPurchase.Purchase, prevPurchase, date)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	purchaseDtl.PurchaseDtlID = ID
+	err = mod.syncLot(ctx, tx, prevPurchase.ProductID, purchaseDtl)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = mod.receiveSerial(ctx, tx, prevPurchase.Purchase, purchaseDtl)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
+
+// receivePurchase is to derive accepted quantity and finish status of purchase from its receipts
+// and then move the stock of the delta on location of each receipt at given date
+func (mod Module) receivePurchase(ctx context.Context, tx *sql.Tx, purchase internal.Purchase, prevPurchase internal.PurchaseWithProduct, date time.Time) error {
+	quantityAccepted, err := mod.internal.SumPurchaseDtlQuantityWithTx(ctx, tx, purchase.PurchaseID)
+	if err != nil {
+		return err
+	}
+
+	// received quantity can't be more than ordered quantity
+	if quantityAccepted > purchase.QuantityOrder {
+		return ErrInvalidReceiptQuantity
+	}
+
+	// returned goods can't be taken back from purchase
+	quantityReturned, err := mod.internal.SumPurchaseReturnQuantityWithTx(ctx, tx, purchase.PurchaseID, 0)
+	if err != nil {
+		return err
+	}
+
+	if quantityReturned > 0 && (quantityAccepted < quantityReturned || purchase.ProductID != prevPurchase.ProductID) {
+		return ErrReturnedPurchase
+	}
+
+	// purchase is finished when all ordered quantity has been received
+	err = mod.internal.UpdatePurchaseAccepted(ctx, tx, purchase.PurchaseID, quantityAccepted, quantityAccepted >= purchase.QuantityOrder)
+	if err != nil {
+		return err
+	}
+
+	// take back stock which has been accepted by previous purchase on each location
+	// and then accept stock for current purchase on location of its receipts
+	prevMovements, err := mod.internal.GetStockMovementBalanceByReferenceWithTx(ctx, tx, internal.MovementPurchase, purchase.PurchaseID)
+	if err != nil {
+		return err
+	}
+
+	quantityByLocation, err := mod.internal.SumPurchaseDtlQuantityByLocationWithTx(ctx, tx, purchase.PurchaseID)
+	if err != nil {
+		return err
+	}
+
+	var stockMovements []internal.StockMovement
+	for _, prevMovement := range prevMovements {
+		stockMovements = append(stockMovements, internal.StockMovement{
+			ProductID:     prevMovement.ProductID,
+			Quantity:      -prevMovement.Quantity,
+			Cost:          prevPurchase.Cost,
+			ReferenceType: internal.MovementPurchase,
+			ReferenceID:   purchase.PurchaseID,
+			Description:   purchase.InvoiceNumber,
+			LocationID:    prevMovement.LocationID,
+			Date:          date,
+		})
+	}
+
+	for locationID, quantity := range quantityByLocation {
+		stockMovements = append(stockMovements, internal.StockMovement{
+			ProductID:     purchase.ProductID,
+			Quantity:      quantity,
+			Cost:          purchase.Cost,
+			ReferenceType: internal.MovementPurchase,
+			ReferenceID:   purchase.PurchaseID,
+			Description:   purchase.InvoiceNumber,
+			LocationID:    locationID,
+			Date:          date,
+		})
+	}
+
+	err = mod.storeStockMovement(ctx, tx, stockMovements...)
+	if err != nil {
+		return err
+	}
+
+	// accepted quantity of purchase is a cost layer
+	return mod.syncCostLayer(ctx, tx, internal.CostLayer{
+		ProductID:     purchase.ProductID,
+		ReferenceType: internal.MovementPurchase,
+		ReferenceID:   purchase.PurchaseID,
+		Quantity:      quantityAccepted,
+		Cost:          purchase.Cost,
+		Date:          purchase.Date,
+	})
 }
 
 // WritePurchaseToCSV to write purchase entity to CSV
@@ -0,0 +1,216 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqPurchaseReturn is entity of inputed purchase return
+// use to make request that will be stored into database
+type ReqPurchaseReturn struct {
+	internal.PurchaseReturn
+	DateRaw string `json:"date_raw"`
+}
+
+// ErrPurchaseReceiptNotFound is error when returned receipt doesn't belong to the purchase
+var ErrPurchaseReceiptNotFound = errors.New("purchase receipt not found")
+
+// GetPurchaseReturn is used to get all purchase return with its purchase
+func (mod Module) GetPurchaseReturn(ctx context.Context) ([]internal.PurchaseReturnWithPurchase, error) {
+	purchaseReturns, err := mod.internal.GetPurchaseReturnWithPurchase(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// calculate total
+	for index, purchaseReturn := range purchaseReturns {
+		purchaseReturns[index].Total = purchaseReturn.Cost * int64(purchaseReturn.Quantity)
+	}
+
+	return purchaseReturns, nil
+}
+
+// GetPurchaseReturnByID is used to get purchase return with its purchase by ID
+func (mod Module) GetPurchaseReturnByID(ctx context.Context, ID int64) (internal.PurchaseReturnWithPurchase, error) {
+	purchaseReturn, err := mod.internal.GetPurchaseReturnWithPurchaseByID(ctx, ID)
+	if err != nil {
+		return internal.PurchaseReturnWithPurchase{}, err
+	}
+
+	// calculate total
+	purchaseReturn.Total = purchaseReturn.Cost * int64(purchaseReturn.Quantity)
+
+	return purchaseReturn, nil
+}
+
+// StorePurchaseReturn is to store goods which is sent back to supplier into database
+// returned goods leave the stock at cost of the purchase without editing the purchase
+func (mod Module) StorePurchaseReturn(ctx context.Context, reqPurchaseReturn ReqPurchaseReturn) (ID int64, err error) {
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	date, err := time.Parse("2006-01-02 15:04:05", reqPurchaseReturn.DateRaw)
+	if err != nil {
+		return 0, err
+	}
+
+	if reqPurchaseReturn.Quantity <= 0 {
+		return 0, ErrInvalidReturnQuantity
+	}
+
+	purchase, err := mod.internal.GetPurchaseWithProductByID(ctx, reqPurchaseReturn.PurchaseID)
+	if err != nil {
+		return 0, err
+	}
+
+	if purchase.PurchaseID == 0 {
+		return 0, ErrPurchaseNotFound
+	}
+
+	purchaseDtls, err := mod.internal.GetPurchaseDtlByPurchaseID(ctx, purchase.PurchaseID)
+	if err != nil {
+		return 0, err
+	}
+
+	// returned goods can be traced to the receipt which delivers them
+	var (
+		purchaseDtl  internal.PurchaseDtl
+		isReceivedBy = make(map[int64]bool)
+	)
+	for _, receipt := range purchaseDtls {
+		if reqPurchaseReturn.PurchaseDtlID == 0 || receipt.PurchaseDtlID == reqPurchaseReturn.PurchaseDtlID {
+			isReceivedBy[receipt.PurchaseDtlID] = true
+		}
+
+		if receipt.PurchaseDtlID == reqPurchaseReturn.PurchaseDtlID {
+			purchaseDtl = receipt
+		}
+	}
+
+	if reqPurchaseReturn.PurchaseDtlID != 0 && purchaseDtl.PurchaseDtlID == 0 {
+		return 0, ErrPurchaseReceiptNotFound
+	}
+
+	purchaseReturn := internal.PurchaseReturn{
+		PurchaseID:    purchase.PurchaseID,
+		PurchaseDtlID: purchaseDtl.PurchaseDtlID,
+		ProductID:     purchase.ProductID,
+		Quantity:      reqPurchaseReturn.Quantity,
+		Cost:          purchase.Cost,
+		Description:   reqPurchaseReturn.Description,
+		Date:          date,
+		SerialNumbers: reqPurchaseReturn.SerialNumbers,
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// purchase can't be returned more than its received quantity
+	returned, err := mod.internal.SumPurchaseReturnQuantityWithTx(ctx, tx, purchase.PurchaseID, 0)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if returned+purchaseReturn.Quantity > purchase.QuantityAccepted {
+		tx.Rollback()
+		return 0, ErrInvalidReturnQuantity
+	}
+
+	if purchaseDtl.PurchaseDtlID != 0 {
+		returned, err = mod.internal.SumPurchaseReturnQuantityWithTx(ctx, tx, purchase.PurchaseID, purchaseDtl.PurchaseDtlID)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		if returned+purchaseReturn.Quantity > purchaseDtl.Quantity {
+			tx.Rollback()
+			return 0, ErrInvalidReturnQuantity
+		}
+	}
+
+	ID, err = mod.internal.StorePurchaseReturn(ctx, tx, purchaseReturn)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// returned goods are taken from location of its receipt
+	// or from default location when the receipt is unspecified
+	stockMovement := internal.StockMovement{
+		ProductID:     purchaseReturn.ProductID,
+		Quantity:      -purchaseReturn.Quantity,
+		Cost:          purchaseReturn.Cost,
+		ReferenceType: internal.MovementPurchaseReturn,
+		ReferenceID:   ID,
+		Description:   "Retur " + purchase.InvoiceNumber,
+		LocationID:    purchaseDtl.LocationID,
+		Date:          purchaseReturn.Date,
+	}
+	if stockMovement.LocationID == 0 {
+		stockMovement.LocationID = internal.DefaultLocationID
+	}
+
+	err = mod.storeStockMovement(ctx, tx, stockMovement)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// goods which has been sold can't be returned
+	err = mod.checkStockAvailability(ctx, tx, map[productLocation]int{
+		{purchaseReturn.ProductID, stockMovement.LocationID}: purchaseReturn.Quantity,
+	})
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = mod.returnCostLayer(ctx, tx, purchase.PurchaseID, stockMovement)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = mod.returnLot(ctx, tx, purchaseDtl.PurchaseDtlID, stockMovement)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// unit of serialized product must be delivered by the purchase (or by the returned receipt)
+	for _, serialNumber := range purchaseReturn.SerialNumbers {
+		serial, err := mod.internal.GetSerialBySerialNumberWithTx(ctx, tx, strings.Trim(serialNumber, " "))
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		if !isReceivedBy[serial.PurchaseDtlID] {
+			tx.Rollback()
+			return 0, ErrSerialNotAvailable
+		}
+	}
+
+	err = mod.takeSerial(ctx, tx, purchaseReturn.ProductID, stockMovement.LocationID, purchaseReturn.SerialNumbers, purchaseReturn.Quantity, internal.SerialHistory{
+		ReferenceType: internal.MovementPurchaseReturn,
+		ReferenceID:   ID,
+		LocationID:    stockMovement.LocationID,
+		Status:        internal.SerialReturned,
+		Description:   stockMovement.Description,
+		Date:          purchaseReturn.Date,
+	})
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
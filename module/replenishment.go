@@ -0,0 +1,298 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// default of replenishment filter in days
+const (
+	defaultReplenishmentWindow    = 30
+	defaultReplenishmentCoverDays = 30
+	defaultReplenishmentLeadTime  = 7
+)
+
+// ReqFilterReplenishment is entity to filter replenishment report
+// Window is number of days of order history which sales velocity is averaged from,
+// CoverDays is number of days the stock should last after it is received
+// and LeadTime is used when supplier of product doesn't have average lead time yet
+// zero value means its default value is used
+type ReqFilterReplenishment struct {
+	Window    int `json:"window"`
+	CoverDays int `json:"cover_days"`
+	LeadTime  int `json:"lead_time"`
+}
+
+// ReqReplenishmentPurchase is entity of inputed replenishment which is converted into purchase
+// suggestion is recalculated by the filter, so only product which still needs to be replenished is purchased
+type ReqReplenishmentPurchase struct {
+	ReqFilterReplenishment
+	ProductIDs []int64 `json:"product_ids"`
+	DateRaw    string  `json:"date_raw"`
+}
+
+// Replenishment is entity of suggested purchase of product
+// supplier and cost are taken from the last purchase of product
+// QuantityNeeded is quantity which is sold during lead time and cover days
+type Replenishment struct {
+	internal.Product
+	SupplierID        int64   `json:"supplier_id"`
+	SupplierName      string  `json:"supplier_name"`
+	Cost              int64   `json:"cost"`
+	QuantitySold      int     `json:"quantity_sold"`
+	AverageDailySales float64 `json:"average_daily_sales"`
+	LeadTime          float64 `json:"lead_time"`
+	QuantityOnOrder   int     `json:"quantity_on_order"`
+	QuantityAvailable int     `json:"quantity_available"`
+	QuantityNeeded    int     `json:"quantity_needed"`
+	QuantitySuggested int     `json:"quantity_suggested"`
+}
+
+// ReplenishmentWithSummary is entity of replenishment with summary
+type ReplenishmentWithSummary struct {
+	Replenishment []Replenishment
+	Summary       SummaryReplenishment `json:"summary"`
+}
+
+// SummaryReplenishment is summary of replenishment
+// which consist of few elements
+type SummaryReplenishment struct {
+	DatePrint      string `json:"date_print"`
+	Window         int    `json:"window"`
+	CoverDays      int    `json:"cover_days"`
+	TotalSku       int    `json:"total_sku"`
+	TotalSuggested int    `json:"total_suggested"`
+	TotalValue     int64  `json:"total_value"`
+}
+
+// error of replenishment request
+var (
+	// ErrInvalidReplenishmentFilter is error when window, cover days or lead time is negative
+	ErrInvalidReplenishmentFilter = errors.New("invalid replenishment window, cover days or lead time")
+	// ErrEmptyReplenishment is error when no product is selected to be purchased
+	ErrEmptyReplenishment = errors.New("replenishment doesn't have any product")
+	// ErrReplenishmentNotFound is error when selected product doesn't need to be replenished
+	ErrReplenishmentNotFound = errors.New("product doesn't need to be replenished")
+)
+
+// GetReplenishment is used to get suggested purchase of product from its sales velocity
+// average daily sales is quantity ordered during the window (bundle is counted on its components),
+// and product is suggested when its stock plus outstanding purchase can't cover
+// the sales during lead time of its supplier and the cover days
+func (mod Module) GetReplenishment(ctx context.Context, reqFilter ReqFilterReplenishment) (ReplenishmentWithSummary, error) {
+	var (
+		replenishmentWithSummary ReplenishmentWithSummary
+		replenishments           = []Replenishment{}
+		summary                  SummaryReplenishment
+	)
+
+	if reqFilter.Window < 0 || reqFilter.CoverDays < 0 || reqFilter.LeadTime < 0 {
+		return ReplenishmentWithSummary{}, ErrInvalidReplenishmentFilter
+	}
+
+	if reqFilter.Window == 0 {
+		reqFilter.Window = defaultReplenishmentWindow
+	}
+
+	if reqFilter.CoverDays == 0 {
+		reqFilter.CoverDays = defaultReplenishmentCoverDays
+	}
+
+	if reqFilter.LeadTime == 0 {
+		reqFilter.LeadTime = defaultReplenishmentLeadTime
+	}
+
+	products, err := mod.internal.GetProduct(ctx)
+	if err != nil {
+		return ReplenishmentWithSummary{}, err
+	}
+
+	productComponents, err := mod.internal.GetProductComponent(ctx)
+	if err != nil {
+		return ReplenishmentWithSummary{}, err
+	}
+
+	componentsByBundle := make(map[int64][]internal.ProductComponent)
+	for _, productComponent := range productComponents {
+		componentsByBundle[productComponent.BundleID] = append(componentsByBundle[productComponent.BundleID], productComponent)
+	}
+
+	// window includes today
+	now := time.Now()
+	ordersWithProduct, err := mod.internal.GetOrderWithProductByDate(ctx, now.AddDate(0, 0, -(reqFilter.Window-1)), now)
+	if err != nil {
+		return ReplenishmentWithSummary{}, err
+	}
+
+	quantitySold := make(map[int64]int)
+	for _, orderWithProduct := range ordersWithProduct {
+		if bundleComponents, ok := componentsByBundle[orderWithProduct.ProductID]; ok {
+			for _, productComponent := range bundleComponents {
+				quantitySold[productComponent.ComponentID] += orderWithProduct.Quantity * productComponent.Quantity
+			}
+			continue
+		}
+
+		quantitySold[orderWithProduct.ProductID] += orderWithProduct.Quantity
+	}
+
+	purchasesWithProduct, err := mod.internal.GetPurchaseWithProduct(ctx)
+	if err != nil {
+		return ReplenishmentWithSummary{}, err
+	}
+
+	var (
+		lastPurchases   = make(map[int64]internal.PurchaseWithProduct)
+		quantityOnOrder = make(map[int64]int)
+	)
+	for _, purchaseWithProduct := range purchasesWithProduct {
+		if purchaseWithProduct.QuantityAccepted < purchaseWithProduct.QuantityOrder {
+			quantityOnOrder[purchaseWithProduct.ProductID] += purchaseWithProduct.QuantityOrder - purchaseWithProduct.QuantityAccepted
+		}
+
+		lastPurchase, ok := lastPurchases[purchaseWithProduct.ProductID]
+		if !ok || !purchaseWithProduct.Date.Before(lastPurchase.Date) {
+			lastPurchases[purchaseWithProduct.ProductID] = purchaseWithProduct
+		}
+	}
+
+	suppliers, err := mod.internal.GetSupplier(ctx)
+	if err != nil {
+		return ReplenishmentWithSummary{}, err
+	}
+
+	supplierLeadTimes := make(map[int64]float64)
+	for _, supplier := range suppliers {
+		supplierLeadTimes[supplier.SupplierID] = supplier.AverageLeadTime
+	}
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	summary.DatePrint = now.Format("2006-01-02 15:04:05")
+	summary.Window = reqFilter.Window
+	summary.CoverDays = reqFilter.CoverDays
+
+	for _, product := range products {
+		if product.IsBundle || quantitySold[product.ProductID] <= 0 {
+			continue
+		}
+
+		lastPurchase := lastPurchases[product.ProductID]
+		replenishment := Replenishment{
+			Product:         product,
+			SupplierID:      lastPurchase.SupplierID,
+			SupplierName:    lastPurchase.Supplier.Name,
+			QuantitySold:    quantitySold[product.ProductID],
+			LeadTime:        supplierLeadTimes[lastPurchase.SupplierID],
+			QuantityOnOrder: quantityOnOrder[product.ProductID],
+		}
+
+		if replenishment.LeadTime <= 0 {
+			replenishment.LeadTime = float64(reqFilter.LeadTime)
+		}
+
+		averageDailySales := float64(replenishment.QuantitySold) / float64(reqFilter.Window)
+		replenishment.AverageDailySales = math.Round(averageDailySales*100) / 100
+		replenishment.QuantityNeeded = int(math.Ceil(averageDailySales * (replenishment.LeadTime + float64(reqFilter.CoverDays))))
+		replenishment.QuantityAvailable = product.Stock + replenishment.QuantityOnOrder
+		if replenishment.QuantityAvailable >= replenishment.QuantityNeeded {
+			continue
+		}
+		replenishment.QuantitySuggested = replenishment.QuantityNeeded - replenishment.QuantityAvailable
+
+		// product which has never been purchased is valued at its current cost
+		if !lastPurchase.Date.IsZero() {
+			replenishment.Cost = lastPurchase.Cost
+		} else {
+			cost, err := mod.getProductCost(ctx, product.ProductID)
+			if err != nil {
+				return ReplenishmentWithSummary{}, err
+			}
+			replenishment.Cost = int64(cost)
+		}
+
+		summary.TotalSku++
+		summary.TotalSuggested += replenishment.QuantitySuggested
+		summary.TotalValue += int64(replenishment.QuantitySuggested) * replenishment.Cost
+
+		replenishments = append(replenishments, replenishment)
+	}
+
+	replenishmentWithSummary.Replenishment = replenishments
+	replenishmentWithSummary.Summary = summary
+
+	return replenishmentWithSummary, nil
+}
+
+// StoreReplenishmentPurchase is to convert selected replenishment into purchase which has not been received
+// purchase is made with suggested quantity, supplier and cost of the replenishment,
+// all purchase is stored within one transaction so none is stored when one of them fails
+func (mod Module) StoreReplenishmentPurchase(ctx context.Context, reqReplenishment ReqReplenishmentPurchase) ([]int64, error) {
+	if len(reqReplenishment.ProductIDs) == 0 {
+		return nil, ErrEmptyReplenishment
+	}
+
+	// empty date means the purchase is made now
+	// date format: yyyy-MM-dd HH:mm:ss
+	if reqReplenishment.DateRaw == "" {
+		reqReplenishment.DateRaw = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	replenishmentWithSummary, err := mod.GetReplenishment(ctx, reqReplenishment.ReqFilterReplenishment)
+	if err != nil {
+		return nil, err
+	}
+
+	replenishmentByProduct := make(map[int64]Replenishment)
+	for _, replenishment := range replenishmentWithSummary.Replenishment {
+		replenishmentByProduct[replenishment.ProductID] = replenishment
+	}
+
+	// validate all selected product before any purchase is stored
+	var (
+		purchases  []internal.Purchase
+		isSelected = make(map[int64]bool)
+	)
+	for _, productID := range reqReplenishment.ProductIDs {
+		replenishment, ok := replenishmentByProduct[productID]
+		if !ok || isSelected[productID] {
+			return nil, ErrReplenishmentNotFound
+		}
+		isSelected[productID] = true
+
+		reqPurchase := ReqPurchase{DateRaw: reqReplenishment.DateRaw}
+		reqPurchase.ProductID = productID
+		reqPurchase.QuantityOrder = replenishment.QuantitySuggested
+		reqPurchase.Cost = replenishment.Cost
+		reqPurchase.SupplierID = replenishment.SupplierID
+		reqPurchase.Description = "Pesan Ulang"
+
+		purchase, _, err := mod.newPurchase(ctx, reqPurchase)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, purchase)
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var IDs []int64
+	for _, purchase := range purchases {
+		ID, err := mod.storePurchase(ctx, tx, purchase, nil, internal.PurchaseWithProduct{}, nil)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, tx.Commit()
+}
@@ -2,11 +2,22 @@ package module
 
 import (
 	"context"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/sog01/ijahshop/module/internal"
 )
 
+// ReqFilterProductAvgValue is entity to filter product value report
+// zero AsOf means current stock and value
+// ByParent rolls variant up into one row of its product parent
+type ReqFilterProductAvgValue struct {
+	CostingMethod string
+	AsOf          time.Time
+	ByParent      bool
+}
+
 // ProductAvgValueWithSummary is entity of product value with summary
 type ProductAvgValueWithSummary struct {
 	ProductAvgValue []internal.ProductAvgValue
@@ -14,11 +25,16 @@ type ProductAvgValueWithSummary struct {
 }
 
 // OrderWithProductValue is entity of order with product average value
+// AverageCost is unit cost of product at the time the order is made
+// return of order line has SalesReturnID and negative quantity
 type OrderWithProductValue struct {
 	internal.OrderWithProduct
-	AverageCost int   `db:"average_cost" json:"average_cost"`
-	Total       int64 `json:"total"`
-	Profit      int64 `json:"profit"`
+	SalesReturnID int64   `json:"sales_return_id,omitempty"`
+	AverageCost   int     `db:"average_cost" json:"average_cost"`
+	Total         int64   `json:"total"`
+	Cogs          int64   `json:"cogs"`
+	Profit        int64   `json:"profit"`
+	MarginPercent float64 `json:"margin_percent"`
 }
 
 // OrderWithProductValueWithSummary is entity of order with product average value with summary
@@ -27,36 +43,227 @@ type OrderWithProductValueWithSummary struct {
 	Summary               SummaryOrderWithProductValue `json:"summary"`
 }
 
+// OutstandingPurchase is entity of purchase which has not been fully received
+// ValueOutstanding is value of quantity which is still due at purchase cost
+type OutstandingPurchase struct {
+	internal.PurchaseWithProduct
+	QuantityOutstanding int   `json:"quantity_outstanding"`
+	DaysOutstanding     int   `json:"days_outstanding"`
+	ValueOutstanding    int64 `json:"value_outstanding"`
+}
+
+// OutstandingPurchaseWithSummary is entity of outstanding purchase with summary
+type OutstandingPurchaseWithSummary struct {
+	OutstandingPurchase []OutstandingPurchase
+	Summary             SummaryOutstandingPurchase `json:"summary"`
+}
+
+// LowStockProduct is entity of product which stock is at or below its reorder point
+// QuantityAvailable is stock plus quantity which has been purchased but not received yet
+type LowStockProduct struct {
+	internal.Product
+	QuantityOnOrder   int `json:"quantity_on_order"`
+	QuantityAvailable int `json:"quantity_available"`
+	QuantitySuggested int `json:"quantity_suggested"`
+}
+
+// LowStockProductWithSummary is entity of low stock product with summary
+type LowStockProductWithSummary struct {
+	LowStockProduct []LowStockProduct
+	Summary         SummaryLowStock `json:"summary"`
+}
+
+// ReqFilterNearExpiry is entity to filter near expiry report
+// Days is number of days from today which lot expires within, zero means 30 days
+type ReqFilterNearExpiry struct {
+	Days int
+}
+
+// NearExpiryLot is entity of lot which expires within filtered days
+// negative DaysToExpiry means the lot has expired, and Value is remaining quantity at current cost
+type NearExpiryLot struct {
+	internal.LotWithProduct
+	DaysToExpiry int   `json:"days_to_expiry"`
+	IsExpired    bool  `json:"is_expired"`
+	Cost         int   `json:"cost"`
+	Value        int64 `json:"value"`
+}
+
+// NearExpiryLotWithSummary is entity of near expiry lot with summary
+type NearExpiryLotWithSummary struct {
+	NearExpiryLot []NearExpiryLot
+	Summary       SummaryNearExpiry `json:"summary"`
+}
+
+// AdjustmentWithSummary is entity of adjustment with summary
+type AdjustmentWithSummary struct {
+	Adjustment []internal.AdjustmentWithProduct
+	Summary    SummaryAdjustment `json:"summary"`
+}
+
 // SummaryAvgValue is summary of average value product
 // which consist of few elements
 type SummaryAvgValue struct {
-	DatePrint    string `json:"date_print"`
-	TotalSku     int    `json:"total_sku"`
+	DatePrint     string                 `json:"date_print"`
+	AsOf          string                 `json:"as_of"`
+	CostingMethod string                 `json:"costing_method"`
+	TotalSku      int                    `json:"total_sku"`
+	TotalProduct  int                    `json:"total_product"`
+	TotalValue    int                    `json:"total_value"`
+	Locations     []SummaryLocationValue `json:"locations"`
+}
+
+// SummaryLocationValue is summary of stock and its value on one location
+type SummaryLocationValue struct {
+	LocationID   int64  `json:"location_id"`
+	Name         string `json:"location_name"`
 	TotalProduct int    `json:"total_product"`
-	TotalValue   int    `json:"total_value"`
+	TotalValue   int64  `json:"total_value"`
 }
 
 // SummaryOrderWithProductValue is summary of order with product value
 // which consist of few elements
 type SummaryOrderWithProductValue struct {
-	DatePrint   string `json:"date_print"`
-	Date        string `json:"date"`
+	DatePrint       string  `json:"date_print"`
+	CostingMethod   string  `json:"costing_method"`
+	Date            string  `json:"date"`
+	TotalPrice      int64   `json:"total_price"`
+	TotalReturn     int64   `json:"total_return"`
+	TotalCogs       int64   `json:"total_cogs"`
+	TotalProfit     int64   `json:"total_profit"`
+	MarginPercent   float64 `json:"margin_percent"`
+	TotalSold       int     `json:"total_sold"`
+	TotalItem       int     `json:"total_item"`
+	TotalReturnItem int     `json:"total_return_item"`
+
+	Parents []SummaryParentValue `json:"parents,omitempty"`
+}
+
+// SummaryParentValue is summary of sold product which is rolled up into its product parent
+// product without parent is summarized by itself
+type SummaryParentValue struct {
+	ParentID    int64  `json:"parent_id"`
+	Name        string `json:"name"`
+	Sku         string `json:"sku"`
+	TotalItem   int    `json:"total_item"`
 	TotalPrice  int64  `json:"total_price"`
+	TotalCogs   int64  `json:"total_cogs"`
 	TotalProfit int64  `json:"total_profit"`
-	TotalSold   int    `json:"total_sold"`
-	TotalItem   int    `json:"total_item"`
+}
+
+// SummaryOutstandingPurchase is summary of outstanding purchase
+// which consist of few elements
+type SummaryOutstandingPurchase struct {
+	DatePrint     string `json:"date_print"`
+	TotalPurchase int    `json:"total_purchase"`
+	TotalQuantity int    `json:"total_quantity"`
+	TotalValue    int64  `json:"total_value"`
+}
+
+// SummaryLowStock is summary of low stock product
+// which consist of few elements
+type SummaryLowStock struct {
+	DatePrint      string `json:"date_print"`
+	TotalSku       int    `json:"total_sku"`
+	TotalSuggested int    `json:"total_suggested"`
+}
+
+// SummaryNearExpiry is summary of near expiry lot
+// which consist of few elements
+type SummaryNearExpiry struct {
+	DatePrint     string `json:"date_print"`
+	Days          int    `json:"days"`
+	TotalLot      int    `json:"total_lot"`
+	TotalQuantity int    `json:"total_quantity"`
+	TotalExpired  int    `json:"total_expired"`
+	TotalValue    int64  `json:"total_value"`
+}
+
+// SummaryAdjustment is summary of adjustment
+// value of each reason is signed, negative value means stock is taken out
+type SummaryAdjustment struct {
+	DatePrint       string `json:"date_print"`
+	Date            string `json:"date"`
+	TotalAdjustment int    `json:"total_adjustment"`
+	TotalQuantity   int    `json:"total_quantity"`
+	TotalValue      int64  `json:"total_value"`
+	TotalLost       int64  `json:"total_lost"`
+	TotalDamaged    int64  `json:"total_damaged"`
+	TotalSample     int64  `json:"total_sample"`
+	TotalCorrection int64  `json:"total_correction"`
 }
 
 // GetProductAvgValue is used to get all product with average value
-func (mod Module) GetProductAvgValue(ctx context.Context) (ProductAvgValueWithSummary, error) {
+// bundle is not listed, since its stock is kept and valued by its components
+func (mod Module) GetProductAvgValue(ctx context.Context, reqFilter ReqFilterProductAvgValue) (ProductAvgValueWithSummary, error) {
 	var productAvgValueWithSummary ProductAvgValueWithSummary
 
-	productsAvgValue, err := mod.internal.GetProductAvgValue(ctx)
+	costingMethod, err := mod.getCostingMethod(reqFilter.CostingMethod)
 	if err != nil {
 		return ProductAvgValueWithSummary{}, err
 	}
 
+	// date format: yyyy-MM-dd
+	asOf := time.Now()
+	productsAvgValue := []internal.ProductAvgValue{}
+	if reqFilter.AsOf != (time.Time{}) {
+		// stock and value is reconstructed until the end of the day
+		asOf = time.Date(
+			reqFilter.AsOf.Year(),
+			time.Month(reqFilter.AsOf.Month()),
+			reqFilter.AsOf.Day(),
+			23, 59, 59, 0, time.UTC,
+		)
+		productsAvgValue, err = mod.internal.GetProductAvgValueByDate(ctx, asOf)
+	} else {
+		productsAvgValue, err = mod.internal.GetProductAvgValue(ctx)
+	}
+
+	if err != nil {
+		return ProductAvgValueWithSummary{}, err
+	}
+
+	// average cost from storage is plain average of purchase cost
+	// so it need to be replaced when other costing method is used
+	if costingMethod == CostingMovingAverage {
+		var dateEnd time.Time
+		if reqFilter.AsOf != (time.Time{}) {
+			dateEnd = asOf
+		}
+
+		movingAverageCosts, err := mod.getMovingAverageCost(ctx, dateEnd)
+		if err != nil {
+			return ProductAvgValueWithSummary{}, err
+		}
+
+		for index, productAvgValue := range productsAvgValue {
+			productsAvgValue[index].AverageCost = 0
+			if movingAverageCost, ok := movingAverageCosts[productAvgValue.ProductID]; ok {
+				productsAvgValue[index].AverageCost = int(math.Round(movingAverageCost.Cost))
+			}
+		}
+	}
+
+	if costingMethod == CostingFIFO {
+		var fifoCosts map[int64]int
+		if reqFilter.AsOf != (time.Time{}) {
+			fifoCosts, err = mod.getFIFOCostByDate(ctx, asOf)
+		} else {
+			fifoCosts, err = mod.getFIFOCost(ctx)
+		}
+
+		if err != nil {
+			return ProductAvgValueWithSummary{}, err
+		}
+
+		for index, productAvgValue := range productsAvgValue {
+			productsAvgValue[index].AverageCost = fifoCosts[productAvgValue.ProductID]
+		}
+	}
+
 	productAvgValueWithSummary.ProductAvgValue = productsAvgValue
+	productAvgValueWithSummary.Summary.CostingMethod = costingMethod
+	productAvgValueWithSummary.Summary.AsOf = asOf.Format("2006-01-02")
 
 	// calculate total and summary
 	// date format: yyyy-MM-dd HH:mm:ss
@@ -68,9 +275,123 @@ func (mod Module) GetProductAvgValue(ctx context.Context) (ProductAvgValueWithSu
 		productAvgValueWithSummary.Summary.TotalValue += productAvgValueWithSummary.ProductAvgValue[index].Total
 	}
 
+	// breakdown of stock and value on each location
+	var dateEnd time.Time
+	if reqFilter.AsOf != (time.Time{}) {
+		dateEnd = asOf
+	}
+
+	locations, err := mod.internal.GetLocation(ctx)
+	if err != nil {
+		return ProductAvgValueWithSummary{}, err
+	}
+
+	productLocationStocks, err := mod.internal.GetProductLocationStock(ctx, dateEnd)
+	if err != nil {
+		return ProductAvgValueWithSummary{}, err
+	}
+
+	indexByLocation := make(map[int64]int)
+	for index, location := range locations {
+		indexByLocation[location.LocationID] = index
+		productAvgValueWithSummary.Summary.Locations = append(productAvgValueWithSummary.Summary.Locations, SummaryLocationValue{
+			LocationID: location.LocationID,
+			Name:       location.Name,
+		})
+	}
+
+	locationStocksByProduct := make(map[int64][]internal.ProductLocationStock)
+	for _, productLocationStock := range productLocationStocks {
+		locationStocksByProduct[productLocationStock.ProductID] = append(locationStocksByProduct[productLocationStock.ProductID], productLocationStock)
+	}
+
+	for index, productAvgValue := range productAvgValueWithSummary.ProductAvgValue {
+		locationStocks := locationStocksByProduct[productAvgValue.ProductID]
+		for indexLocation, locationStock := range locationStocks {
+			locationStocks[indexLocation].Value = int64(locationStock.Stock) * int64(productAvgValue.AverageCost)
+
+			summaryLocation := &productAvgValueWithSummary.Summary.Locations[indexByLocation[locationStock.LocationID]]
+			summaryLocation.TotalProduct += locationStock.Stock
+			summaryLocation.TotalValue += locationStocks[indexLocation].Value
+		}
+		productAvgValueWithSummary.ProductAvgValue[index].Locations = locationStocks
+	}
+
+	if reqFilter.ByParent {
+		productAvgValueWithSummary.ProductAvgValue, err = mod.rollUpProductAvgValue(ctx, productAvgValueWithSummary.ProductAvgValue)
+		if err != nil {
+			return ProductAvgValueWithSummary{}, err
+		}
+	}
+
 	return productAvgValueWithSummary, nil
 }
 
+// rollUpProductAvgValue is used to merge value of variant into one row of its product parent
+// average cost of the parent is weighted by stock of its variant
+func (mod Module) rollUpProductAvgValue(ctx context.Context, productsAvgValue []internal.ProductAvgValue) ([]internal.ProductAvgValue, error) {
+	productParents, err := mod.internal.GetProductParent(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parentByID := make(map[int64]internal.ProductParent)
+	for _, productParent := range productParents {
+		parentByID[productParent.ParentID] = productParent
+	}
+
+	var (
+		rolledUp      []internal.ProductAvgValue
+		indexByParent = make(map[int64]int)
+	)
+	for _, productAvgValue := range productsAvgValue {
+		productParent, ok := parentByID[productAvgValue.ParentID]
+		if !ok {
+			rolledUp = append(rolledUp, productAvgValue)
+			continue
+		}
+
+		index, ok := indexByParent[productParent.ParentID]
+		if !ok {
+			indexByParent[productParent.ParentID] = len(rolledUp)
+			rolledUp = append(rolledUp, internal.ProductAvgValue{
+				Product: internal.Product{
+					Name:     productParent.Name,
+					Sku:      productParent.Sku,
+					ParentID: productParent.ParentID,
+				},
+			})
+			index = len(rolledUp) - 1
+		}
+
+		parentAvgValue := &rolledUp[index]
+		parentAvgValue.Stock += productAvgValue.Stock
+		parentAvgValue.Total += productAvgValue.Total
+		for _, locationStock := range productAvgValue.Locations {
+			isMerged := false
+			for indexLocation := range parentAvgValue.Locations {
+				if parentAvgValue.Locations[indexLocation].LocationID == locationStock.LocationID {
+					parentAvgValue.Locations[indexLocation].Stock += locationStock.Stock
+					parentAvgValue.Locations[indexLocation].Value += locationStock.Value
+					isMerged = true
+				}
+			}
+
+			if !isMerged {
+				parentAvgValue.Locations = append(parentAvgValue.Locations, locationStock)
+			}
+		}
+	}
+
+	for index := range rolledUp {
+		if rolledUp[index].ProductID == 0 && rolledUp[index].Stock != 0 {
+			rolledUp[index].AverageCost = int(math.Round(float64(rolledUp[index].Total) / float64(rolledUp[index].Stock)))
+		}
+	}
+
+	return rolledUp, nil
+}
+
 // GetOrderWithProductAvgValueByDate is used to get order with product average value
 func (mod Module) GetOrderWithProductAvgValueByDate(ctx context.Context, reqFilter ReqFilterOrder) (OrderWithProductValueWithSummary, error) {
 	var (
@@ -79,22 +400,55 @@ func (mod Module) GetOrderWithProductAvgValueByDate(ctx context.Context, reqFilt
 		summary                          SummaryOrderWithProductValue
 	)
 
+	costingMethod, err := mod.getCostingMethod(reqFilter.CostingMethod)
+	if err != nil {
+		return OrderWithProductValueWithSummary{}, err
+	}
+
 	ordersWithProduct, err := mod.internal.GetOrderWithProductByDate(ctx, reqFilter.DateStart, reqFilter.DateEnd)
 	if err != nil {
 		return OrderWithProductValueWithSummary{}, err
 	}
 
+	// return is reported at the date it is received, not at the date of its order
+	salesReturns, err := mod.internal.GetSalesReturnWithOrderByDate(ctx, reqFilter.DateStart, reqFilter.DateEnd)
+	if err != nil {
+		return OrderWithProductValueWithSummary{}, err
+	}
+
+	// cost of order by active costing method is snapshotted when the order is stored,
+	// other costing method is calculated at the time the order is made
+	isSnapshot := costingMethod == mod.costingMethod
+	var (
+		orderCosts = make(map[int64]int)
+		orderCogs  = make(map[int64]int64)
+	)
+	if !isSnapshot {
+		orders := ordersOf(ordersWithProduct)
+		for _, salesReturn := range salesReturns {
+			orders = append(orders, salesReturn.Order)
+		}
+
+		orderCosts, orderCogs, err = mod.getOrderCost(ctx, costingMethod, orders)
+		if err != nil {
+			return OrderWithProductValueWithSummary{}, err
+		}
+	}
+
 	// date format: yyyy-MM-dd HH:mm:ss
 	summary.DatePrint = time.Now().Format("2006-01-02 15:04:05")
+	summary.CostingMethod = costingMethod
 
 	// date has format: date start - date end
 	summary.Date = reqFilter.DateStart.Format("2006-01-02 15:04:05") + "-" + reqFilter.DateEnd.Format("2006-01-02 15:04:05")
 
 	for _, orderWithProduct := range ordersWithProduct {
 		var orderWithProductValue OrderWithProductValue
-		productAvgValue, err := mod.internal.GetProductAvgValueByProductID(ctx, orderWithProduct.ProductID)
-		if err != nil {
-			return OrderWithProductValueWithSummary{}, err
+		orderWithProductValue.AverageCost = orderCosts[orderWithProduct.OrderID]
+		orderWithProductValue.Cogs = orderCogs[orderWithProduct.OrderID]
+		if isSnapshot {
+			orderWithProductValue.AverageCost = int(orderWithProduct.Cost)
+			orderWithProductValue.Cogs = orderWithProduct.Cogs
 		}
 
 		orderWithProductValue.OrderID = orderWithProduct.OrderID
@@ -105,12 +459,14 @@ func (mod Module) GetOrderWithProductAvgValueByDate(ctx context.Context, reqFilt
 		orderWithProductValue.Date = orderWithProduct.Date
 		orderWithProductValue.DateStr = orderWithProduct.DateStr
 		orderWithProductValue.Price = orderWithProduct.Price
+		orderWithProductValue.Cost = orderWithProduct.Cost
 		orderWithProductValue.Product = orderWithProduct.Product
-		orderWithProductValue.Total = orderWithProductValue.Price * int64(orderWithProductValue.Quantity)
-		orderWithProductValue.AverageCost = productAvgValue.AverageCost
-		orderWithProductValue.Profit = orderWithProductValue.Total - int64(orderWithProductValue.AverageCost)
+		orderWithProductValue.Total = unitTotal(orderWithProduct.UnitPrice, orderWithProduct.Quantity, orderWithProduct.UnitFactor)
+		orderWithProductValue.Profit = orderWithProductValue.Total - orderWithProductValue.Cogs
+		orderWithProductValue.MarginPercent = marginPercent(orderWithProductValue.Profit, orderWithProductValue.Total)
 
 		summary.TotalPrice += orderWithProductValue.Total
+		summary.TotalCogs += orderWithProductValue.Cogs
 		summary.TotalProfit += orderWithProductValue.Profit
 		summary.TotalItem += orderWithProductValue.Quantity
 		summary.TotalSold++
@@ -118,6 +474,50 @@ func (mod Module) GetOrderWithProductAvgValueByDate(ctx context.Context, reqFilt
 		ordersWithProductValue = append(ordersWithProductValue, orderWithProductValue)
 	}
 
+	// return reverses revenue and cost of goods sold of its order line,
+	// damaged item goes back to damaged location so its cost is reversed as well
+	for _, salesReturn := range salesReturns {
+		var returnValue OrderWithProductValue
+		returnValue.SalesReturnID = salesReturn.SalesReturnID
+		returnValue.AverageCost = orderCosts[salesReturn.OrderID]
+		lineCogs := orderCogs[salesReturn.OrderID]
+		if isSnapshot {
+			returnValue.AverageCost = int(salesReturn.Cost)
+			lineCogs = salesReturn.Order.Cogs
+		}
+
+		returnValue.Order = salesReturn.Order
+		returnValue.Quantity = -salesReturn.Quantity
+		returnValue.Description = salesReturn.Description
+		returnValue.Date = salesReturn.Date
+		returnValue.DateStr = salesReturn.DateStr
+		returnValue.Price = salesReturn.Price
+		returnValue.Product = salesReturn.Product
+		returnValue.Total = unitTotal(salesReturn.UnitPrice, returnValue.Quantity, salesReturn.Order.UnitFactor)
+		// cost of goods sold of the order line is reversed by returned portion of the line
+		if salesReturn.Order.Quantity > 0 {
+			returnValue.Cogs = -int64(math.Round(float64(lineCogs) * float64(salesReturn.Quantity) / float64(salesReturn.Order.Quantity)))
+		}
+		returnValue.Profit = returnValue.Total - returnValue.Cogs
+		returnValue.MarginPercent = marginPercent(returnValue.Profit, returnValue.Total)
+
+		summary.TotalReturn -= returnValue.Total
+		summary.TotalCogs += returnValue.Cogs
+		summary.TotalProfit += returnValue.Profit
+		summary.TotalReturnItem += salesReturn.Quantity
+
+		ordersWithProductValue = append(ordersWithProductValue, returnValue)
+	}
+
+	summary.MarginPercent = marginPercent(summary.TotalProfit, summary.TotalPrice-summary.TotalReturn)
+
+	if reqFilter.ByParent {
+		summary.Parents, err = mod.rollUpOrderValue(ctx, ordersWithProductValue)
+		if err != nil {
+			return OrderWithProductValueWithSummary{}, err
+		}
+	}
+
 	orderWithProductValueWithSummary.OrderWithProductValue = ordersWithProductValue
 	orderWithProductValueWithSummary.Summary = summary
 
@@ -125,9 +525,288 @@ func (mod Module) GetOrderWithProductAvgValueByDate(ctx context.Context, reqFilt
 
 }
 
+// rollUpOrderValue is used to summarize sold product by its product parent
+// return is counted with negative quantity, so it reduces sold item of its parent
+func (mod Module) rollUpOrderValue(ctx context.Context, ordersWithProductValue []OrderWithProductValue) ([]SummaryParentValue, error) {
+	products, err := mod.internal.GetProduct(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	productParents, err := mod.internal.GetProductParent(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		productByID = make(map[int64]internal.Product)
+		parentByID  = make(map[int64]internal.ProductParent)
+	)
+	for _, product := range products {
+		productByID[product.ProductID] = product
+	}
+
+	for _, productParent := range productParents {
+		parentByID[productParent.ParentID] = productParent
+	}
+
+	type rollUpKey struct {
+		parentID  int64
+		productID int64
+	}
+
+	var (
+		summaryParents []SummaryParentValue
+		indexByKey     = make(map[rollUpKey]int)
+	)
+	for _, orderWithProductValue := range ordersWithProductValue {
+		product := productByID[orderWithProductValue.ProductID]
+		summaryParent := SummaryParentValue{
+			Name: orderWithProductValue.Product.Name,
+			Sku:  orderWithProductValue.Product.Sku,
+		}
+		key := rollUpKey{productID: orderWithProductValue.ProductID}
+		if productParent, ok := parentByID[product.ParentID]; ok {
+			summaryParent = SummaryParentValue{
+				ParentID: productParent.ParentID,
+				Name:     productParent.Name,
+				Sku:      productParent.Sku,
+			}
+			key = rollUpKey{parentID: productParent.ParentID}
+		}
+
+		index, ok := indexByKey[key]
+		if !ok {
+			indexByKey[key] = len(summaryParents)
+			summaryParents = append(summaryParents, summaryParent)
+			index = len(summaryParents) - 1
+		}
+
+		summaryParents[index].TotalItem += orderWithProductValue.Quantity
+		summaryParents[index].TotalPrice += orderWithProductValue.Total
+		summaryParents[index].TotalCogs += orderWithProductValue.Cogs
+		summaryParents[index].TotalProfit += orderWithProductValue.Profit
+	}
+
+	return summaryParents, nil
+}
+
+// GetOutstandingPurchase is used to get purchase which received quantity is below ordered quantity
+func (mod Module) GetOutstandingPurchase(ctx context.Context) (OutstandingPurchaseWithSummary, error) {
+	var (
+		outstandingPurchaseWithSummary OutstandingPurchaseWithSummary
+		outstandingPurchases           = []OutstandingPurchase{}
+		summary                        SummaryOutstandingPurchase
+	)
+
+	purchasesWithProduct, err := mod.internal.GetOutstandingPurchaseWithProduct(ctx)
+	if err != nil {
+		return OutstandingPurchaseWithSummary{}, err
+	}
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	now := time.Now()
+	summary.DatePrint = now.Format("2006-01-02 15:04:05")
+
+	for _, purchaseWithProduct := range purchasesWithProduct {
+		outstandingPurchase := OutstandingPurchase{
+			PurchaseWithProduct: purchaseWithProduct,
+			QuantityOutstanding: purchaseWithProduct.QuantityOrder - purchaseWithProduct.QuantityAccepted,
+		}
+		outstandingPurchase.Total = unitTotal(purchaseWithProduct.UnitCost, purchaseWithProduct.QuantityOrder, purchaseWithProduct.UnitFactor)
+		outstandingPurchase.ValueOutstanding = unitTotal(purchaseWithProduct.UnitCost, outstandingPurchase.QuantityOutstanding, purchaseWithProduct.UnitFactor)
+
+		// purchase made in the future is not outstanding yet
+		if now.After(purchaseWithProduct.Date) {
+			outstandingPurchase.DaysOutstanding = int(now.Sub(purchaseWithProduct.Date).Hours() / 24)
+		}
+
+		summary.TotalPurchase++
+		summary.TotalQuantity += outstandingPurchase.QuantityOutstanding
+		summary.TotalValue += outstandingPurchase.ValueOutstanding
+
+		outstandingPurchases = append(outstandingPurchases, outstandingPurchase)
+	}
+
+	outstandingPurchaseWithSummary.OutstandingPurchase = outstandingPurchases
+	outstandingPurchaseWithSummary.Summary = summary
+
+	return outstandingPurchaseWithSummary, nil
+}
+
+// GetLowStockReport is used to get product which stock plus its outstanding purchase
+// is at or below its reorder point, product without reorder point and bundle are never listed
+// suggested quantity is reorder quantity, raised up to the reorder point when it is not enough
+func (mod Module) GetLowStockReport(ctx context.Context) (LowStockProductWithSummary, error) {
+	var (
+		lowStockWithSummary LowStockProductWithSummary
+		lowStockProducts    = []LowStockProduct{}
+		summary             SummaryLowStock
+	)
+
+	products, err := mod.internal.GetProduct(ctx)
+	if err != nil {
+		return LowStockProductWithSummary{}, err
+	}
+
+	purchasesWithProduct, err := mod.internal.GetOutstandingPurchaseWithProduct(ctx)
+	if err != nil {
+		return LowStockProductWithSummary{}, err
+	}
+
+	quantityOnOrder := make(map[int64]int)
+	for _, purchaseWithProduct := range purchasesWithProduct {
+		quantityOnOrder[purchaseWithProduct.ProductID] += purchaseWithProduct.QuantityOrder - purchaseWithProduct.QuantityAccepted
+	}
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	summary.DatePrint = time.Now().Format("2006-01-02 15:04:05")
+
+	for _, product := range products {
+		if product.IsBundle || product.ReorderPoint <= 0 {
+			continue
+		}
+
+		lowStockProduct := LowStockProduct{
+			Product:         product,
+			QuantityOnOrder: quantityOnOrder[product.ProductID],
+		}
+		lowStockProduct.QuantityAvailable = product.Stock + lowStockProduct.QuantityOnOrder
+		if lowStockProduct.QuantityAvailable > product.ReorderPoint {
+			continue
+		}
+
+		lowStockProduct.QuantitySuggested = product.ReorderQuantity
+		if shortage := product.ReorderPoint - lowStockProduct.QuantityAvailable; shortage > lowStockProduct.QuantitySuggested {
+			lowStockProduct.QuantitySuggested = shortage
+		}
+
+		summary.TotalSku++
+		summary.TotalSuggested += lowStockProduct.QuantitySuggested
+
+		lowStockProducts = append(lowStockProducts, lowStockProduct)
+	}
+
+	lowStockWithSummary.LowStockProduct = lowStockProducts
+	lowStockWithSummary.Summary = summary
+
+	return lowStockWithSummary, nil
+}
+
+// GetNearExpiryReport is used to get lot which still has stock and expires within filtered days
+// including lot which has expired, ordered from the lot which expires first
+func (mod Module) GetNearExpiryReport(ctx context.Context, reqFilter ReqFilterNearExpiry) (NearExpiryLotWithSummary, error) {
+	var (
+		nearExpiryWithSummary NearExpiryLotWithSummary
+		nearExpiryLots        = []NearExpiryLot{}
+		summary               SummaryNearExpiry
+	)
+
+	if reqFilter.Days < 0 {
+		return NearExpiryLotWithSummary{}, ErrInvalidNearExpiryDays
+	}
+
+	if reqFilter.Days == 0 {
+		reqFilter.Days = defaultNearExpiryDays
+	}
+
+	// expiry is compared by date only
+	// date format: yyyy-MM-dd
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	lotsWithProduct, err := mod.internal.GetExpiringLotWithProduct(ctx, today.AddDate(0, 0, reqFilter.Days).Format("2006-01-02"))
+	if err != nil {
+		return NearExpiryLotWithSummary{}, err
+	}
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	summary.DatePrint = now.Format("2006-01-02 15:04:05")
+	summary.Days = reqFilter.Days
+
+	productCosts := make(map[int64]int)
+	for _, lotWithProduct := range lotsWithProduct {
+		expiryDate, err := time.Parse("2006-01-02", lotWithProduct.ExpiryDate)
+		if err != nil {
+			return NearExpiryLotWithSummary{}, err
+		}
+
+		if _, ok := productCosts[lotWithProduct.ProductID]; !ok {
+			productCosts[lotWithProduct.ProductID], err = mod.getProductCost(ctx, lotWithProduct.ProductID)
+			if err != nil {
+				return NearExpiryLotWithSummary{}, err
+			}
+		}
+
+		nearExpiryLot := NearExpiryLot{
+			LotWithProduct: lotWithProduct,
+			DaysToExpiry:   int(expiryDate.Sub(today).Hours() / 24),
+			Cost:           productCosts[lotWithProduct.ProductID],
+		}
+		nearExpiryLot.IsExpired = nearExpiryLot.DaysToExpiry < 0
+		nearExpiryLot.Value = int64(lotWithProduct.Remaining) * int64(nearExpiryLot.Cost)
+
+		summary.TotalLot++
+		summary.TotalQuantity += lotWithProduct.Remaining
+		summary.TotalValue += nearExpiryLot.Value
+		if nearExpiryLot.IsExpired {
+			summary.TotalExpired += lotWithProduct.Remaining
+		}
+
+		nearExpiryLots = append(nearExpiryLots, nearExpiryLot)
+	}
+
+	nearExpiryWithSummary.NearExpiryLot = nearExpiryLots
+	nearExpiryWithSummary.Summary = summary
+
+	return nearExpiryWithSummary, nil
+}
+
+// GetAdjustmentReport is used to get adjustment by filter date with its value by reason
+func (mod Module) GetAdjustmentReport(ctx context.Context, reqFilter ReqFilterAdjustment) (AdjustmentWithSummary, error) {
+	var (
+		adjustmentWithSummary AdjustmentWithSummary
+		summary               SummaryAdjustment
+	)
+
+	adjustments, err := mod.internal.GetAdjustmentWithProductByDate(ctx, reqFilter.DateStart, reqFilter.DateEnd)
+	if err != nil {
+		return AdjustmentWithSummary{}, err
+	}
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	summary.DatePrint = time.Now().Format("2006-01-02 15:04:05")
+
+	// date has format: date start - date end
+	summary.Date = reqFilter.DateStart.Format("2006-01-02 15:04:05") + "-" + reqFilter.DateEnd.Format("2006-01-02 15:04:05")
+
+	for index, adjustment := range adjustments {
+		adjustments[index].Total = adjustment.Cost * int64(adjustment.Quantity)
+
+		summary.TotalAdjustment++
+		summary.TotalQuantity += adjustment.Quantity
+		summary.TotalValue += adjustments[index].Total
+
+		switch adjustment.Reason {
+		case internal.AdjustmentLost:
+			summary.TotalLost += adjustments[index].Total
+		case internal.AdjustmentDamaged:
+			summary.TotalDamaged += adjustments[index].Total
+		case internal.AdjustmentSample:
+			summary.TotalSample += adjustments[index].Total
+		case internal.AdjustmentCorrection:
+			summary.TotalCorrection += adjustments[index].Total
+		}
+	}
+
+	adjustmentWithSummary.Adjustment = adjustments
+	adjustmentWithSummary.Summary = summary
+
+	return adjustmentWithSummary, nil
+}
+
 // WriteProductReportToCSV to write product report entity to CSV
-func (mod Module) WriteProductReportToCSV(ctx context.Context) error {
-	productReport, err := mod.GetProductAvgValue(ctx)
+func (mod Module) WriteProductReportToCSV(ctx context.Context, reqFilter ReqFilterProductAvgValue) error {
+	productReport, err := mod.GetProductAvgValue(ctx, reqFilter)
 	if err != nil {
 		return err
 	}
@@ -135,6 +814,24 @@ func (mod Module) WriteProductReportToCSV(ctx context.Context) error {
 	productAvgValue := mod.entityIntoArrayString(productReport.ProductAvgValue)
 	summary := mod.entityIntoArrayString(productReport.Summary)
 
+	// stock of each location is appended as columns after total
+	for index := range productAvgValue {
+		for _, location := range productReport.Summary.Locations {
+			if index == 0 {
+				productAvgValue[index] = append(productAvgValue[index], "Jumlah "+location.Name, "Nilai "+location.Name)
+				continue
+			}
+
+			var locationStock internal.ProductLocationStock
+			for _, productLocationStock := range productReport.ProductAvgValue[index-1].Locations {
+				if productLocationStock.LocationID == location.LocationID {
+					locationStock = productLocationStock
+				}
+			}
+			productAvgValue[index] = append(productAvgValue[index], strconv.Itoa(locationStock.Stock), strconv.FormatInt(locationStock.Value, 10))
+		}
+	}
+
 	row := summary
 
 	// add enter
@@ -164,3 +861,53 @@ func (mod Module) WriteOrderReportToCSV(ctx context.Context, reqFilter ReqFilter
 
 	return mod.writeToCSV(ctx, "Laporan Penjualan", row)
 }
+
+// WriteOutstandingPurchaseReportToCSV to write outstanding purchase report entity to CSV
+func (mod Module) WriteOutstandingPurchaseReportToCSV(ctx context.Context) error {
+	outstandingPurchaseReport, err := mod.GetOutstandingPurchase(ctx)
+	if err != nil {
+		return err
+	}
+
+	outstandingPurchase := mod.entityIntoArrayString(outstandingPurchaseReport.OutstandingPurchase)
+	summary := mod.entityIntoArrayString(outstandingPurchaseReport.Summary)
+
+	row := summary
+
+	// add enter
+	row = append(row, []string{})
+
+	row = append(row, outstandingPurchase...)
+
+	return mod.writeToCSV(ctx, "Laporan Barang Belum Diterima", row)
+}
+
+// WriteAdjustmentReportToCSV to write adjustment report entity to CSV
+func (mod Module) WriteAdjustmentReportToCSV(ctx context.Context, reqFilter ReqFilterAdjustment) error {
+	adjustmentReport, err := mod.GetAdjustmentReport(ctx, reqFilter)
+	if err != nil {
+		return err
+	}
+
+	adjustment := mod.entityIntoArrayString(adjustmentReport.Adjustment)
+	summary := mod.entityIntoArrayString(adjustmentReport.Summary)
+
+	row := summary
+
+	// add enter
+	row = append(row, []string{})
+
+	row = append(row, adjustment...)
+
+	return mod.writeToCSV(ctx, "Laporan Penyesuaian Stok", row)
+}
+
+// marginPercent is to calculate percentage of profit from total price
+// rounded into two decimal places
+func marginPercent(profit, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return math.Round(float64(profit)/float64(total)*10000) / 100
+}
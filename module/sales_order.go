@@ -0,0 +1,504 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqSalesOrder is entity of inputed sales order with its lines
+// use to make request that will be stored into database
+// quantity and price of each line are inputed in Unit of the line, empty Unit means base unit of product
+type ReqSalesOrder struct {
+	internal.SalesOrder
+	DateRaw        string           `json:"date_raw"`
+	AllowBackorder bool             `json:"allow_backorder"`
+	Lines          []internal.Order `json:"lines"`
+}
+
+// error of sales order request
+var (
+	// ErrEmptySalesOrder is error when sales order doesn't have any line
+	ErrEmptySalesOrder = errors.New("sales order doesn't have any line")
+	// ErrInvalidSalesOrderStatus is error when requested status is not supported
+	ErrInvalidSalesOrderStatus = errors.New("invalid sales order status")
+	// ErrDuplicateOrderNumber is error when order number has been used by other sales order
+	ErrDuplicateOrderNumber = errors.New("order number has been used")
+	// ErrInvalidOrderLine is error when updated line doesn't belong to the sales order
+	ErrInvalidOrderLine = errors.New("order line doesn't belong to sales order")
+)
+
+// GetSalesOrder is used to get all sales order
+func (mod Module) GetSalesOrder(ctx context.Context) ([]internal.SalesOrder, error) {
+	return mod.internal.GetSalesOrder(ctx)
+}
+
+// GetSalesOrderByID is used to get sales order with its lines by ID
+func (mod Module) GetSalesOrderByID(ctx context.Context, ID int64) (internal.SalesOrderWithLine, error) {
+	salesOrder, err := mod.internal.GetSalesOrderByID(ctx, ID)
+	if err != nil {
+		return internal.SalesOrderWithLine{}, err
+	}
+
+	// not found sales order is returned as empty struct
+	if salesOrder.SalesOrderID == 0 {
+		return internal.SalesOrderWithLine{}, nil
+	}
+
+	lines, err := mod.internal.GetOrderWithProductBySalesOrderID(ctx, ID)
+	if err != nil {
+		return internal.SalesOrderWithLine{}, err
+	}
+
+	// calculate total
+	for index, line := range lines {
+		lines[index].Total = unitTotal(line.UnitPrice, line.Quantity, line.UnitFactor)
+	}
+
+	return internal.SalesOrderWithLine{
+		SalesOrder: salesOrder,
+		Lines:      lines,
+	}, nil
+}
+
+// StoreSalesOrder is to store sales order with all of its lines into database
+// line of existing sales order which is not requested anymore is removed
+func (mod Module) StoreSalesOrder(ctx context.Context, reqSalesOrder ReqSalesOrder) (ID int64, err error) {
+	if len(reqSalesOrder.Lines) == 0 {
+		return 0, ErrEmptySalesOrder
+	}
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	date, err := time.Parse("2006-01-02 15:04:05", reqSalesOrder.DateRaw)
+	if err != nil {
+		return 0, err
+	}
+
+	salesOrder := internal.SalesOrder{
+		SalesOrderID: reqSalesOrder.SalesOrderID,
+		OrderNumber:  reqSalesOrder.OrderNumber,
+		CustomerID:   reqSalesOrder.CustomerID,
+		Customer:     reqSalesOrder.Customer,
+		Status:       reqSalesOrder.Status,
+		Notes:        reqSalesOrder.Notes,
+		Date:         date,
+	}
+
+	if salesOrder.Status == "" {
+		salesOrder.Status = internal.SalesOrderConfirmed
+	}
+
+	if salesOrder.Status != internal.SalesOrderConfirmed && salesOrder.Status != internal.SalesOrderCompleted {
+		return 0, ErrInvalidSalesOrderStatus
+	}
+
+	// order number is unique for each sales order
+	if salesOrder.OrderNumber != "" {
+		existingSalesOrder, err := mod.internal.GetSalesOrderByOrderNumber(ctx, salesOrder.OrderNumber)
+		if err != nil {
+			return 0, err
+		}
+
+		if existingSalesOrder.SalesOrderID != 0 && existingSalesOrder.SalesOrderID != salesOrder.SalesOrderID {
+			return 0, ErrDuplicateOrderNumber
+		}
+	}
+
+	// all previous lines are needed to apply only the delta of stock
+	// and to remove lines which are not requested anymore
+	var prevOrders []internal.OrderWithProduct
+	if salesOrder.SalesOrderID != 0 {
+		prevOrders, err = mod.internal.GetOrderWithProductBySalesOrderID(ctx, salesOrder.SalesOrderID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	ID, _, err = mod.storeSalesOrder(ctx, salesOrder, reqSalesOrder.Lines, prevOrders, reqSalesOrder.AllowBackorder)
+	return ID, err
+}
+
+// storeSalesOrder is to store sales order and its lines within one transaction
+// prevOrders is previous lines which are affected by the request, previous line which
+// is not requested is removed. It returns ID of sales order and ID of each line
+func (mod Module) storeSalesOrder(ctx context.Context, salesOrder internal.SalesOrder, lines []internal.Order, prevOrders []internal.OrderWithProduct, allowBackorder bool) (int64, []int64, error) {
+	prevOrderByID := make(map[int64]internal.OrderWithProduct)
+	for _, prevOrder := range prevOrders {
+		prevOrderByID[prevOrder.OrderID] = prevOrder
+	}
+
+	// only line of the sales order can be updated
+	for index, line := range lines {
+		prevOrder, ok := prevOrderByID[line.OrderID]
+		if line.OrderID != 0 && !ok {
+			return 0, nil, ErrInvalidOrderLine
+		}
+
+		// line without location stays on its previous location
+		if line.LocationID == 0 {
+			line.LocationID = prevOrder.LocationID
+		}
+
+		locationID, err := mod.getLocationID(ctx, line.LocationID)
+		if err != nil {
+			return 0, nil, err
+		}
+		lines[index].LocationID = locationID
+
+		// stock is kept in base unit, so quantity and price of line is normalized into base unit
+		unit, factor, err := mod.getProductUnit(ctx, line.ProductID, line.Unit)
+		if err != nil {
+			return 0, nil, err
+		}
+		lines[index].Unit = unit
+		lines[index].UnitFactor = factor
+		lines[index].Quantity = line.Quantity * factor
+		lines[index].UnitPrice = line.Price
+		lines[index].Price = baseUnitPrice(line.Price, factor)
+	}
+
+	err := mod.applyCustomer(ctx, &salesOrder)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// cost of stock movement is taken from current cost of product
+	// and bundle takes stock of its components, so cost of the components is needed too
+	var (
+		productCosts       = make(map[int64]int)
+		componentsByBundle = make(map[int64][]internal.ProductComponent)
+	)
+	for _, order := range append(ordersOf(prevOrders), lines...) {
+		if _, ok := productCosts[order.ProductID]; ok {
+			continue
+		}
+
+		cost, err := mod.getProductCost(ctx, order.ProductID)
+		if err != nil {
+			return 0, nil, err
+		}
+		productCosts[order.ProductID] = cost
+
+		productComponents, err := mod.internal.GetProductComponentByBundleID(ctx, order.ProductID)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		componentsByBundle[order.ProductID] = productComponents
+		for _, productComponent := range productComponents {
+			if _, ok := productCosts[productComponent.ComponentID]; ok {
+				continue
+			}
+
+			productCosts[productComponent.ComponentID], err = mod.getProductCost(ctx, productComponent.ComponentID)
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	salesOrder.SalesOrderID, err = mod.internal.StoreSalesOrder(ctx, tx, salesOrder)
+	if err != nil {
+		tx.Rollback()
+		return 0, nil, err
+	}
+
+	// order number is generated when it is not given
+	// format: SO-yyyyMMdd-{sales order id}
+	if salesOrder.OrderNumber == "" {
+		salesOrder.OrderNumber = fmt.Sprintf("SO-%s-%d", salesOrder.Date.Format("20060102"), salesOrder.SalesOrderID)
+		_, err = mod.internal.StoreSalesOrder(ctx, tx, salesOrder)
+		if err != nil {
+			tx.Rollback()
+			return 0, nil, err
+		}
+	}
+
+	var (
+		orderIDs    []int64
+		isRequested = make(map[int64]bool)
+		requested   = make(map[productLocation]int)
+	)
+	for _, line := range lines {
+		// line without its own date (e.g. line of sales order request) follows date of the sales order
+		date := line.Date
+		if date.IsZero() {
+			date = salesOrder.Date
+		}
+
+		order := internal.Order{
+			OrderID:       line.OrderID,
+			SalesOrderID:  salesOrder.SalesOrderID,
+			CustomerID:    salesOrder.CustomerID,
+			OrderIDFormat: salesOrder.OrderNumber,
+			ProductID:     line.ProductID,
+			Quantity:      line.Quantity,
+			Description:   line.Description,
+			Date:          date,
+			Price:         line.Price,
+			LocationID:    line.LocationID,
+			Unit:          line.Unit,
+			UnitFactor:    line.UnitFactor,
+			UnitPrice:     line.UnitPrice,
+			SerialNumbers: line.SerialNumbers,
+		}
+		prevOrder := prevOrderByID[order.OrderID]
+
+		// returned item must stay on its order line
+		if order.OrderID != 0 {
+			returned, err := mod.internal.SumSalesReturnQuantityByOrderIDWithTx(ctx, tx, order.OrderID)
+			if err != nil {
+				tx.Rollback()
+				return 0, nil, err
+			}
+
+			if returned > 0 && (order.ProductID != prevOrder.ProductID || order.LocationID != prevOrder.LocationID || order.Quantity < returned) {
+				tx.Rollback()
+				return 0, nil, ErrReturnedOrderLine
+			}
+		}
+
+		order.OrderID, err = mod.storeOrderLine(ctx, tx, order, prevOrder, productCosts, componentsByBundle)
+		if err != nil {
+			tx.Rollback()
+			return 0, nil, err
+		}
+		orderIDs = append(orderIDs, order.OrderID)
+		isRequested[order.OrderID] = true
+
+		// only check product which stock is taken more than previous line
+		if order.ProductID != prevOrder.ProductID || order.LocationID != prevOrder.LocationID || order.Quantity > prevOrder.Quantity {
+			for _, stockMovement := range orderLineMovement(internal.StockMovement{ProductID: order.ProductID, Quantity: order.Quantity}, productCosts, componentsByBundle) {
+				requested[productLocation{stockMovement.ProductID, order.LocationID}] += stockMovement.Quantity
+			}
+		}
+	}
+
+	for _, prevOrder := range prevOrders {
+		if isRequested[prevOrder.OrderID] {
+			continue
+		}
+
+		returned, err := mod.internal.SumSalesReturnQuantityByOrderIDWithTx(ctx, tx, prevOrder.OrderID)
+		if err != nil {
+			tx.Rollback()
+			return 0, nil, err
+		}
+
+		if returned > 0 {
+			tx.Rollback()
+			return 0, nil, ErrReturnedOrderLine
+		}
+
+		err = mod.removeOrderLine(ctx, tx, prevOrder, productCosts, componentsByBundle)
+		if err != nil {
+			tx.Rollback()
+			return 0, nil, err
+		}
+	}
+
+	// line which is not requested keeps following customer of its sales order
+	err = mod.internal.UpdateOrderCustomerBySalesOrderID(ctx, tx, salesOrder.SalesOrderID, salesOrder.CustomerID)
+	if err != nil {
+		tx.Rollback()
+		return 0, nil, err
+	}
+
+	// line which is moved into other sales order may leave its previous sales order without any line
+	for _, prevOrder := range prevOrders {
+		if prevOrder.SalesOrderID == salesOrder.SalesOrderID {
+			continue
+		}
+
+		err = mod.internal.DeleteSalesOrderWithoutLine(ctx, tx, prevOrder.SalesOrderID)
+		if err != nil {
+			tx.Rollback()
+			return 0, nil, err
+		}
+	}
+
+	// backorder is allowed to take stock more than available (e.g. pre-order)
+	if !allowBackorder && len(requested) > 0 {
+		err = mod.checkStockAvailability(ctx, tx, requested)
+		if err != nil {
+			tx.Rollback()
+			return 0, nil, err
+		}
+	}
+
+	return salesOrder.SalesOrderID, orderIDs, tx.Commit()
+}
+
+// storeOrderLine is to store one line of sales order within transaction
+// and move the stock of the line
+func (mod Module) storeOrderLine(ctx context.Context, tx *sql.Tx, order internal.Order, prevOrder internal.OrderWithProduct, productCosts map[int64]int, componentsByBundle map[int64][]internal.ProductComponent) (ID int64, err error) {
+	cost := productCosts[order.ProductID]
+
+	ID, err = mod.internal.StoreOrder(ctx, tx, order)
+	if err != nil {
+		return 0, err
+	}
+
+	// release layers and lots consumed by previous order and then consume the oldest layers
+	// and the lots which expire first
+	err = mod.releaseCostLayer(ctx, tx, internal.MovementOrder, ID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = mod.releaseLot(ctx, tx, internal.MovementOrder, ID)
+	if err != nil {
+		return 0, err
+	}
+
+	// unit of serialized product which is sold by previous order goes back to stock
+	// before the line picks its unit
+	releasedSerialNumbers, err := mod.releaseSerial(ctx, tx, ID)
+	if err != nil {
+		return 0, err
+	}
+
+	order.OrderID = ID
+	err = mod.pickSerial(ctx, tx, order, prevOrder, releasedSerialNumbers)
+	if err != nil {
+		return 0, err
+	}
+
+	stockMovements := orderLineMovement(internal.StockMovement{
+		ProductID:     prevOrder.ProductID,
+		Quantity:      prevOrder.Quantity,
+		Cost:          int64(productCosts[prevOrder.ProductID]),
+		ReferenceType: internal.MovementOrder,
+		ReferenceID:   ID,
+		Description:   order.OrderIDFormat,
+		LocationID:    prevOrder.LocationID,
+		Date:          order.Date,
+	}, productCosts, componentsByBundle)
+
+	var fifoCost int64
+	for _, stockMovement := range orderLineMovement(internal.StockMovement{
+		ProductID:     order.ProductID,
+		Quantity:      order.Quantity,
+		Cost:          int64(cost),
+		ReferenceType: internal.MovementOrder,
+		ReferenceID:   ID,
+		Description:   order.OrderIDFormat,
+		LocationID:    order.LocationID,
+		Date:          order.Date,
+	}, productCosts, componentsByBundle) {
+		consumedCost, err := mod.consumeCostLayer(ctx, tx, stockMovement.ProductID, stockMovement.Quantity, internal.MovementOrder, ID, stockMovement.Cost)
+		if err != nil {
+			return 0, err
+		}
+		fifoCost += consumedCost
+
+		_, err = mod.consumeLot(ctx, tx, stockMovement.ProductID, stockMovement.LocationID, stockMovement.Quantity, internal.MovementOrder, ID)
+		if err != nil {
+			return 0, err
+		}
+
+		// take stock for current order
+		stockMovement.Quantity = -stockMovement.Quantity
+		stockMovements = append(stockMovements, stockMovement)
+	}
+
+	// cost of goods sold of fifo is value of the consumed layers,
+	// its unit cost is rounded and only used for display
+	cogs := int64(cost) * int64(order.Quantity)
+	if mod.costingMethod == CostingFIFO && order.Quantity > 0 {
+		cost = int(math.Round(float64(fifoCost) / float64(order.Quantity)))
+		cogs = fifoCost
+	}
+
+	// snapshot unit cost so report of closed period doesn't change
+	err = mod.internal.UpdateOrderCost(ctx, tx, ID, int64(cost), cogs)
+	if err != nil {
+		return 0, err
+	}
+
+	// give back stock which has been taken by previous order
+	// and then take stock for current order
+	err = mod.storeStockMovement(ctx, tx, stockMovements...)
+	if err != nil {
+		return 0, err
+	}
+
+	return ID, nil
+}
+
+// removeOrderLine is to remove line of sales order within transaction
+// and give back the stock which has been taken by the line
+func (mod Module) removeOrderLine(ctx context.Context, tx *sql.Tx, prevOrder internal.OrderWithProduct, productCosts map[int64]int, componentsByBundle map[int64][]internal.ProductComponent) error {
+	err := mod.releaseCostLayer(ctx, tx, internal.MovementOrder, prevOrder.OrderID)
+	if err != nil {
+		return err
+	}
+
+	err = mod.releaseLot(ctx, tx, internal.MovementOrder, prevOrder.OrderID)
+	if err != nil {
+		return err
+	}
+
+	_, err = mod.releaseSerial(ctx, tx, prevOrder.OrderID)
+	if err != nil {
+		return err
+	}
+
+	err = mod.storeStockMovement(ctx, tx, orderLineMovement(internal.StockMovement{
+		ProductID:     prevOrder.ProductID,
+		Quantity:      prevOrder.Quantity,
+		Cost:          int64(productCosts[prevOrder.ProductID]),
+		ReferenceType: internal.MovementOrder,
+		ReferenceID:   prevOrder.OrderID,
+		Description:   prevOrder.OrderIDFormat,
+		LocationID:    prevOrder.LocationID,
+		Date:          prevOrder.Date,
+	}, productCosts, componentsByBundle)...)
+	if err != nil {
+		return err
+	}
+
+	return mod.internal.DeleteOrder(ctx, tx, prevOrder.OrderID)
+}
+
+// orderLineMovement is to take stock movement of order line
+// bundle doesn't keep its own stock, so its movement is replaced by movement of its components
+// which quantity is multiplied by quantity of component on one bundle
+func orderLineMovement(stockMovement internal.StockMovement, productCosts map[int64]int, componentsByBundle map[int64][]internal.ProductComponent) []internal.StockMovement {
+	productComponents := componentsByBundle[stockMovement.ProductID]
+	if len(productComponents) == 0 {
+		return []internal.StockMovement{stockMovement}
+	}
+
+	var stockMovements []internal.StockMovement
+	for _, productComponent := range productComponents {
+		componentMovement := stockMovement
+		componentMovement.ProductID = productComponent.ComponentID
+		componentMovement.Quantity = stockMovement.Quantity * productComponent.Quantity
+		componentMovement.Cost = int64(productCosts[productComponent.ComponentID])
+		stockMovements = append(stockMovements, componentMovement)
+	}
+
+	return stockMovements
+}
+
+// ordersOf is to take order from order with product
+func ordersOf(ordersWithProduct []internal.OrderWithProduct) []internal.Order {
+	var orders []internal.Order
+	for _, orderWithProduct := range ordersWithProduct {
+		orders = append(orders, orderWithProduct.Order)
+	}
+
+	return orders
+}
@@ -0,0 +1,207 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqSalesReturn is entity of inputed sales return
+// use to make request that will be stored into database
+type ReqSalesReturn struct {
+	internal.SalesReturn
+	DateRaw string `json:"date_raw"`
+}
+
+// error of sales return request
+var (
+	// ErrOrderNotFound is error when returned order line doesn't exist
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrInvalidReturnQuantity is error when returned quantity is not positive
+	// or more than quantity of order line which has not been returned
+	ErrInvalidReturnQuantity = errors.New("invalid return quantity")
+	// ErrInvalidReturnCondition is error when condition of returned item is not supported
+	ErrInvalidReturnCondition = errors.New("invalid return condition")
+	// ErrReturnedOrderLine is error when order line which has been returned
+	// is removed or its quantity is less than returned quantity
+	ErrReturnedOrderLine = errors.New("order line has been returned")
+)
+
+// GetSalesReturn is used to get all sales return with its order line
+func (mod Module) GetSalesReturn(ctx context.Context) ([]internal.SalesReturnWithOrder, error) {
+	salesReturns, err := mod.internal.GetSalesReturnWithOrder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// calculate total
+	for index, salesReturn := range salesReturns {
+		salesReturns[index].Total = unitTotal(salesReturn.UnitPrice, salesReturn.Quantity, salesReturn.Order.UnitFactor)
+	}
+
+	return salesReturns, nil
+}
+
+// GetSalesReturnByID is used to get sales return with its order line by ID
+func (mod Module) GetSalesReturnByID(ctx context.Context, ID int64) (internal.SalesReturnWithOrder, error) {
+	salesReturn, err := mod.internal.GetSalesReturnWithOrderByID(ctx, ID)
+	if err != nil {
+		return internal.SalesReturnWithOrder{}, err
+	}
+
+	// calculate total
+	salesReturn.Total = unitTotal(salesReturn.UnitPrice, salesReturn.Quantity, salesReturn.Order.UnitFactor)
+
+	return salesReturn, nil
+}
+
+// StoreSalesReturn is to store return of order line into database
+// quantity and price are inputed in the unit of the order line,
+// returned item in good condition goes back to stock (and lots of the order line) on location of the order line at its cost,
+// returned item in damaged condition goes to damaged location at the same cost, so it can be adjusted later
+func (mod Module) StoreSalesReturn(ctx context.Context, reqSalesReturn ReqSalesReturn) (ID int64, err error) {
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	date, err := time.Parse("2006-01-02 15:04:05", reqSalesReturn.DateRaw)
+	if err != nil {
+		return 0, err
+	}
+
+	if reqSalesReturn.Quantity <= 0 {
+		return 0, ErrInvalidReturnQuantity
+	}
+
+	if reqSalesReturn.Condition == "" {
+		reqSalesReturn.Condition = internal.ReturnGood
+	}
+
+	if reqSalesReturn.Condition != internal.ReturnGood && reqSalesReturn.Condition != internal.ReturnDamaged {
+		return 0, ErrInvalidReturnCondition
+	}
+
+	order, err := mod.internal.GetOrderWithProductByID(ctx, reqSalesReturn.OrderID)
+	if err != nil {
+		return 0, err
+	}
+
+	if order.OrderID == 0 {
+		return 0, ErrOrderNotFound
+	}
+
+	// damaged item is kept apart from sellable stock
+	locationID := order.LocationID
+	if reqSalesReturn.Condition == internal.ReturnDamaged {
+		damagedLocation, err := mod.internal.GetDamagedLocation(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		if damagedLocation.LocationID == 0 {
+			return 0, ErrLocationNotFound
+		}
+		locationID = damagedLocation.LocationID
+	}
+
+	// stock is kept in base unit, so quantity and price of return is normalized into base unit
+	// refund is valued at price of the order line when it is not given
+	salesReturn := internal.SalesReturn{
+		OrderID:       order.OrderID,
+		ProductID:     order.ProductID,
+		Quantity:      reqSalesReturn.Quantity * order.UnitFactor,
+		UnitPrice:     reqSalesReturn.Price,
+		Cost:          order.Cost,
+		Condition:     reqSalesReturn.Condition,
+		Description:   reqSalesReturn.Description,
+		Date:          date,
+		SerialNumbers: reqSalesReturn.SerialNumbers,
+	}
+	if salesReturn.UnitPrice == 0 {
+		salesReturn.UnitPrice = order.UnitPrice
+	}
+	salesReturn.Price = baseUnitPrice(salesReturn.UnitPrice, order.UnitFactor)
+
+	// returned bundle goes back to stock as its components at their current cost
+	productComponents, err := mod.internal.GetProductComponentByBundleID(ctx, order.ProductID)
+	if err != nil {
+		return 0, err
+	}
+
+	productCosts := make(map[int64]int)
+	componentsByBundle := map[int64][]internal.ProductComponent{order.ProductID: productComponents}
+	for _, productComponent := range productComponents {
+		productCosts[productComponent.ComponentID], err = mod.getProductCost(ctx, productComponent.ComponentID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// order line can't be returned more than its quantity
+	returned, err := mod.internal.SumSalesReturnQuantityByOrderIDWithTx(ctx, tx, order.OrderID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if returned+salesReturn.Quantity > order.Quantity {
+		tx.Rollback()
+		return 0, ErrInvalidReturnQuantity
+	}
+
+	ID, err = mod.internal.StoreSalesReturn(ctx, tx, salesReturn)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// unit of serialized product must be sold by the order line
+	salesReturn.SalesReturnID = ID
+	err = mod.returnSerial(ctx, tx, order, salesReturn, locationID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	stockMovements := orderLineMovement(internal.StockMovement{
+		ProductID:     salesReturn.ProductID,
+		Quantity:      salesReturn.Quantity,
+		Cost:          salesReturn.Cost,
+		ReferenceType: internal.MovementReturn,
+		ReferenceID:   ID,
+		Description:   "Retur " + order.OrderIDFormat,
+		LocationID:    locationID,
+		Date:          salesReturn.Date,
+	}, productCosts, componentsByBundle)
+
+	err = mod.storeStockMovement(ctx, tx, stockMovements...)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, stockMovement := range stockMovements {
+		err = mod.storeCostLayer(ctx, tx, stockMovement)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	// returned item in good condition goes back to the lots which has been consumed by the order line
+	if salesReturn.Condition == internal.ReturnGood {
+		err = mod.restoreLot(ctx, tx, order.OrderID, order.Quantity, returned, salesReturn.Quantity)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	return ID, tx.Commit()
+}
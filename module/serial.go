@@ -0,0 +1,402 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// SerialWithHistory is entity of serial with every document which moves the unit
+type SerialWithHistory struct {
+	internal.SerialWithProduct
+	History []SerialMovement `json:"history"`
+}
+
+// SerialMovement is history of serial with the party of its document
+// SupplierName is filled on receipt of purchase and CustomerName is filled on order line
+type SerialMovement struct {
+	internal.SerialHistory
+	SupplierName string `json:"supplier_name,omitempty"`
+	CustomerName string `json:"customer_name,omitempty"`
+}
+
+// error of serial request
+var (
+	// ErrSerialNotFound is error when requested serial number doesn't exist
+	ErrSerialNotFound = errors.New("serial not found")
+	// ErrInvalidSerialNumber is error when serial numbers are empty, duplicated or don't match the quantity,
+	// or when serial numbers are given for product which is not serialized
+	ErrInvalidSerialNumber = errors.New("serial numbers must be unique and match the quantity")
+	// ErrSerialRegistered is error when received serial number has been registered by other unit
+	ErrSerialRegistered = errors.New("serial number has been registered")
+	// ErrSerialNotAvailable is error when serial number is not in stock on the location
+	// or can't be moved by the document (e.g. returned unit which was not sold by the order line)
+	ErrSerialNotAvailable = errors.New("serial number is not available")
+	// ErrSerializedBundle is error when bundle is serialized or has serialized component
+	ErrSerializedBundle = errors.New("bundle can't be or contain serialized product")
+	// ErrSerializedNotEditable is error when serialization of product which has stock is changed
+	// or product of purchase which has been received is changed from or into serialized product
+	ErrSerializedNotEditable = errors.New("serialization of product which has stock can't be changed")
+	// ErrSerializedStock is error when serialized product is created with opening stock,
+	// since each unit must be received with its serial number
+	ErrSerializedStock = errors.New("stock of serialized product must be received with its serial numbers")
+)
+
+// GetSerial is used to get unit by its serial number with its history
+// e.g. invoice of purchase which delivers the unit, order line which sells it and its returns
+func (mod Module) GetSerial(ctx context.Context, serialNumber string) (SerialWithHistory, error) {
+	serialWithProduct, err := mod.internal.GetSerialWithProductBySerialNumber(ctx, strings.Trim(serialNumber, " "))
+	if err != nil {
+		return SerialWithHistory{}, err
+	}
+
+	if serialWithProduct.SerialID == 0 {
+		return SerialWithHistory{}, ErrSerialNotFound
+	}
+
+	serialHistories, err := mod.internal.GetSerialHistoryBySerialID(ctx, serialWithProduct.SerialID)
+	if err != nil {
+		return SerialWithHistory{}, err
+	}
+
+	serialMovements := []SerialMovement{}
+	for _, serialHistory := range serialHistories {
+		serialMovement := SerialMovement{SerialHistory: serialHistory}
+
+		switch serialHistory.ReferenceType {
+		case internal.MovementPurchase:
+			purchase, err := mod.internal.GetPurchaseWithProductByID(ctx, serialHistory.ReferenceID)
+			if err != nil {
+				return SerialWithHistory{}, err
+			}
+			serialMovement.SupplierName = purchase.Supplier.Name
+		case internal.MovementOrder:
+			order, err := mod.internal.GetOrderWithProductByID(ctx, serialHistory.ReferenceID)
+			if err != nil {
+				return SerialWithHistory{}, err
+			}
+
+			salesOrder, err := mod.internal.GetSalesOrderByID(ctx, order.SalesOrderID)
+			if err != nil {
+				return SerialWithHistory{}, err
+			}
+			serialMovement.CustomerName = salesOrder.Customer
+		}
+
+		serialMovements = append(serialMovements, serialMovement)
+	}
+
+	return SerialWithHistory{
+		SerialWithProduct: serialWithProduct,
+		History:           serialMovements,
+	}, nil
+}
+
+// sanitizeSerial is used to trim serial numbers and make sure each unit is listed once
+// and the number of unit equals to the quantity
+func sanitizeSerial(serialNumbers []string, quantity int) ([]string, error) {
+	if len(serialNumbers) != quantity {
+		return nil, ErrInvalidSerialNumber
+	}
+
+	var (
+		sanitized []string
+		isListed  = make(map[string]bool)
+	)
+	for _, serialNumber := range serialNumbers {
+		serialNumber = strings.Trim(serialNumber, " ")
+		if serialNumber == "" || isListed[serialNumber] {
+			return nil, ErrInvalidSerialNumber
+		}
+		isListed[serialNumber] = true
+
+		sanitized = append(sanitized, serialNumber)
+	}
+
+	return sanitized, nil
+}
+
+// isSerialized is used to check whether product is tracked by its serial number within transaction
+// serial numbers can only be given for serialized product
+func (mod Module) isSerialized(ctx context.Context, tx *sql.Tx, productID int64, serialNumbers []string) (bool, error) {
+	product, err := mod.internal.GetProductByIDWithTx(ctx, tx, productID)
+	if err != nil {
+		return false, err
+	}
+
+	if !product.IsSerialized && len(serialNumbers) > 0 {
+		return false, ErrInvalidSerialNumber
+	}
+
+	return product.IsSerialized, nil
+}
+
+// receiveSerial is to register unit of serialized product which is delivered by receipt of purchase
+// unit of previous receipt which is still in stock is replaced, while unit which has left the stock
+// (e.g. sold) must be kept by the receipt
+func (mod Module) receiveSerial(ctx context.Context, tx *sql.Tx, purchase internal.Purchase, purchaseDtl internal.PurchaseDtl) error {
+	serialized, err := mod.isSerialized(ctx, tx, purchase.ProductID, purchaseDtl.SerialNumbers)
+	if err != nil || !serialized {
+		return err
+	}
+
+	serialNumbers, err := sanitizeSerial(purchaseDtl.SerialNumbers, purchaseDtl.Quantity)
+	if err != nil {
+		return err
+	}
+
+	isRequested := make(map[string]bool)
+	for _, serialNumber := range serialNumbers {
+		isRequested[serialNumber] = true
+	}
+
+	prevSerials, err := mod.internal.GetSerialByPurchaseDtlIDWithTx(ctx, tx, purchaseDtl.PurchaseDtlID)
+	if err != nil {
+		return err
+	}
+
+	isKept := make(map[string]bool)
+	for _, prevSerial := range prevSerials {
+		if prevSerial.Status == internal.SerialInStock {
+			err = mod.internal.DeleteSerial(ctx, tx, prevSerial.SerialID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isRequested[prevSerial.SerialNumber] {
+			return ErrSerialNotAvailable
+		}
+		isKept[prevSerial.SerialNumber] = true
+	}
+
+	var newSerialNumbers []string
+	for _, serialNumber := range serialNumbers {
+		if !isKept[serialNumber] {
+			newSerialNumbers = append(newSerialNumbers, serialNumber)
+		}
+	}
+
+	return mod.registerSerial(ctx, tx, internal.Serial{
+		ProductID:     purchase.ProductID,
+		PurchaseDtlID: purchaseDtl.PurchaseDtlID,
+	}, newSerialNumbers, internal.SerialHistory{
+		ReferenceType: internal.MovementPurchase,
+		ReferenceID:   purchase.PurchaseID,
+		LocationID:    purchaseDtl.LocationID,
+		Description:   purchase.InvoiceNumber,
+		Date:          purchaseDtl.Date,
+	})
+}
+
+// registerSerial is to register new unit into stock on location of its history
+// serial number which has been registered by other unit can't be registered again
+func (mod Module) registerSerial(ctx context.Context, tx *sql.Tx, serial internal.Serial, serialNumbers []string, serialHistory internal.SerialHistory) error {
+	for _, serialNumber := range serialNumbers {
+		existingSerial, err := mod.internal.GetSerialBySerialNumberWithTx(ctx, tx, serialNumber)
+		if err != nil {
+			return err
+		}
+
+		if existingSerial.SerialID != 0 {
+			return ErrSerialRegistered
+		}
+
+		serial.SerialID = 0
+		serial.SerialNumber = serialNumber
+		serial.Date = serialHistory.Date
+
+		serialHistory.Status = internal.SerialInStock
+		err = mod.moveSerial(ctx, tx, serial, serialHistory)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// takeSerial is to move unit of serialized product which is in stock on location by document
+// (e.g. order line, transfer and adjustment) into status and location of its history
+func (mod Module) takeSerial(ctx context.Context, tx *sql.Tx, productID, locationID int64, serialNumbers []string, quantity int, serialHistory internal.SerialHistory) error {
+	serialized, err := mod.isSerialized(ctx, tx, productID, serialNumbers)
+	if err != nil || !serialized {
+		return err
+	}
+
+	serialNumbers, err = sanitizeSerial(serialNumbers, quantity)
+	if err != nil {
+		return err
+	}
+
+	for _, serialNumber := range serialNumbers {
+		serial, err := mod.internal.GetSerialBySerialNumberWithTx(ctx, tx, serialNumber)
+		if err != nil {
+			return err
+		}
+
+		if serial.SerialID == 0 || serial.ProductID != productID || serial.Status != internal.SerialInStock || serial.LocationID != locationID {
+			return ErrSerialNotAvailable
+		}
+
+		err = mod.moveSerial(ctx, tx, serial, serialHistory)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pickSerial is to sell unit which is picked by order line from stock on location of the line
+// updated line which doesn't pick any unit keeps its previous unit when its product, location
+// and quantity don't change, and line which has been returned can't change its unit
+func (mod Module) pickSerial(ctx context.Context, tx *sql.Tx, order internal.Order, prevOrder internal.OrderWithProduct, releasedSerialNumbers []string) error {
+	serialized, err := mod.isSerialized(ctx, tx, order.ProductID, order.SerialNumbers)
+	if err != nil || !serialized {
+		return err
+	}
+
+	var (
+		serialNumbers = order.SerialNumbers
+		quantity      = order.Quantity
+	)
+
+	returned, err := mod.internal.SumSalesReturnQuantityByOrderIDWithTx(ctx, tx, order.OrderID)
+	if err != nil {
+		return err
+	}
+
+	isUnchanged := order.ProductID == prevOrder.ProductID && order.LocationID == prevOrder.LocationID && order.Quantity == prevOrder.Quantity
+	switch {
+	case returned > 0:
+		if len(serialNumbers) > 0 || !isUnchanged {
+			return ErrReturnedOrderLine
+		}
+
+		// returned unit is not sold by the line anymore
+		serialNumbers = releasedSerialNumbers
+		quantity -= returned
+	case len(serialNumbers) == 0 && isUnchanged:
+		serialNumbers = releasedSerialNumbers
+	}
+
+	return mod.takeSerial(ctx, tx, order.ProductID, order.LocationID, serialNumbers, quantity, internal.SerialHistory{
+		ReferenceType: internal.MovementOrder,
+		ReferenceID:   order.OrderID,
+		LocationID:    order.LocationID,
+		Status:        internal.SerialSold,
+		Description:   order.OrderIDFormat,
+		Date:          order.Date,
+	})
+}
+
+// releaseSerial is to give back unit which is still sold by order line into stock on location of the line
+// and remove the line from history of the unit. it returns serial number of the released unit
+func (mod Module) releaseSerial(ctx context.Context, tx *sql.Tx, orderID int64) ([]string, error) {
+	serialHistories, err := mod.internal.GetSerialHistoryByReferenceWithTx(ctx, tx, internal.MovementOrder, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var releasedSerialNumbers []string
+	for _, serialHistory := range serialHistories {
+		serial, err := mod.internal.GetSerialBySerialNumberWithTx(ctx, tx, serialHistory.SerialNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		// unit which has been returned is moved by its return
+		if serial.Status != internal.SerialSold {
+			continue
+		}
+
+		serial.Status = internal.SerialInStock
+		serial.LocationID = serialHistory.LocationID
+		_, err = mod.internal.StoreSerial(ctx, tx, serial)
+		if err != nil {
+			return nil, err
+		}
+
+		err = mod.internal.DeleteSerialHistory(ctx, tx, serialHistory.SerialHistoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		releasedSerialNumbers = append(releasedSerialNumbers, serial.SerialNumber)
+	}
+
+	return releasedSerialNumbers, nil
+}
+
+// returnSerial is to take back unit which is sold by order line from customer into stock on locationID
+// (location of the order line for unit in good condition, damaged location for damaged unit)
+func (mod Module) returnSerial(ctx context.Context, tx *sql.Tx, order internal.OrderWithProduct, salesReturn internal.SalesReturn, locationID int64) error {
+	serialized, err := mod.isSerialized(ctx, tx, order.ProductID, salesReturn.SerialNumbers)
+	if err != nil || !serialized {
+		return err
+	}
+
+	serialNumbers, err := sanitizeSerial(salesReturn.SerialNumbers, salesReturn.Quantity)
+	if err != nil {
+		return err
+	}
+
+	serialHistories, err := mod.internal.GetSerialHistoryByReferenceWithTx(ctx, tx, internal.MovementOrder, order.OrderID)
+	if err != nil {
+		return err
+	}
+
+	isSold := make(map[string]bool)
+	for _, serialHistory := range serialHistories {
+		isSold[serialHistory.SerialNumber] = true
+	}
+
+	serialHistory := internal.SerialHistory{
+		ReferenceType: internal.MovementReturn,
+		ReferenceID:   salesReturn.SalesReturnID,
+		LocationID:    locationID,
+		Status:        internal.SerialInStock,
+		Description:   "Retur " + order.OrderIDFormat,
+		Date:          salesReturn.Date,
+	}
+
+	for _, serialNumber := range serialNumbers {
+		serial, err := mod.internal.GetSerialBySerialNumberWithTx(ctx, tx, serialNumber)
+		if err != nil {
+			return err
+		}
+
+		if !isSold[serialNumber] || serial.Status != internal.SerialSold {
+			return ErrSerialNotAvailable
+		}
+
+		err = mod.moveSerial(ctx, tx, serial, serialHistory)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveSerial is to store status and location of unit after it is moved by document
+// and record the document on history of the unit
+func (mod Module) moveSerial(ctx context.Context, tx *sql.Tx, serial internal.Serial, serialHistory internal.SerialHistory) error {
+	var err error
+
+	serial.Status = serialHistory.Status
+	serial.LocationID = serialHistory.LocationID
+	serial.SerialID, err = mod.internal.StoreSerial(ctx, tx, serial)
+	if err != nil {
+		return err
+	}
+
+	serialHistory.SerialID = serial.SerialID
+	_, err = mod.internal.StoreSerialHistory(ctx, tx, serialHistory)
+	return err
+}
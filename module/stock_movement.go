@@ -0,0 +1,175 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// StockShortage is entity of product which stock on location is not enough
+// to fulfill requested quantity
+type StockShortage struct {
+	ProductID  int64  `json:"product_id"`
+	Sku        string `json:"product_sku"`
+	LocationID int64  `json:"location_id"`
+	Requested  int    `json:"requested"`
+	Available  int    `json:"available"`
+}
+
+// productLocation is key of stock which is kept per product on each location
+type productLocation struct {
+	ProductID  int64
+	LocationID int64
+}
+
+// productLocationCost is key of movement which can be netted,
+// movement of the same product and location at different cost is kept for costing
+type productLocationCost struct {
+	productLocation
+	Cost int64
+}
+
+// ErrInsufficientStock is error when requested quantity exceed stock of product
+type ErrInsufficientStock struct {
+	Shortages []StockShortage
+}
+
+func (e ErrInsufficientStock) Error() string {
+	return fmt.Sprintf("insufficient stock of %d product", len(e.Shortages))
+}
+
+// GetStockMovementByProductID is used to get stock card of product
+// which consist of every movement with its running balance
+func (mod Module) GetStockMovementByProductID(ctx context.Context, productID int64) ([]internal.StockMovement, error) {
+	stockMovements, err := mod.internal.GetStockMovementByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// calculate running balance
+	balance := 0
+	for index, stockMovement := range stockMovements {
+		balance += stockMovement.Quantity
+		stockMovements[index].Balance = balance
+	}
+
+	return stockMovements, nil
+}
+
+// WriteStockMovementToCSV to write stock card of product to CSV
+func (mod Module) WriteStockMovementToCSV(ctx context.Context, productID int64) error {
+	stockMovements, err := mod.GetStockMovementByProductID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	return mod.writeToCSV(ctx, "Kartu Stok", stockMovements)
+}
+
+// storeStockMovement is to store stock movements within transaction
+// movements of the same product on the same location at the same cost are netted, so updating a document
+// only records the delta of its quantity, while changed cost is recorded as reversal at previous cost
+// and entry at current cost. movement without location is kept on default location
+// and bundle can't be moved since it doesn't keep its own stock
+func (mod Module) storeStockMovement(ctx context.Context, tx *sql.Tx, stockMovements ...internal.StockMovement) error {
+	var (
+		nettedMovements []internal.StockMovement
+		indexByProduct  = make(map[productLocationCost]int)
+	)
+
+	for _, stockMovement := range stockMovements {
+		if stockMovement.LocationID == 0 {
+			stockMovement.LocationID = internal.DefaultLocationID
+		}
+
+		key := productLocationCost{productLocation{stockMovement.ProductID, stockMovement.LocationID}, stockMovement.Cost}
+		index, ok := indexByProduct[key]
+		if !ok {
+			indexByProduct[key] = len(nettedMovements)
+			nettedMovements = append(nettedMovements, stockMovement)
+			continue
+		}
+
+		nettedMovements[index].Quantity += stockMovement.Quantity
+	}
+
+	for _, stockMovement := range nettedMovements {
+		// nothing to be moved
+		if stockMovement.Quantity == 0 {
+			continue
+		}
+
+		// bundle only moves stock of its components
+		product, err := mod.internal.GetProductByIDWithTx(ctx, tx, stockMovement.ProductID)
+		if err != nil {
+			return err
+		}
+
+		if product.IsBundle {
+			return ErrBundleStock
+		}
+
+		_, err = mod.internal.StoreStockMovement(ctx, tx, stockMovement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkStockAvailability is to make sure stock of requested product on its location is not negative
+// after movement has been stored within transaction
+// requested is quantity of each product on each location which is taken by document
+func (mod Module) checkStockAvailability(ctx context.Context, tx *sql.Tx, requested map[productLocation]int) error {
+	var (
+		keys      []productLocation
+		shortages []StockShortage
+	)
+
+	for key := range requested {
+		keys = append(keys, key)
+	}
+
+	// keep order of shortages consistent
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ProductID != keys[j].ProductID {
+			return keys[i].ProductID < keys[j].ProductID
+		}
+		return keys[i].LocationID < keys[j].LocationID
+	})
+
+	for _, key := range keys {
+		stock, err := mod.internal.GetProductLocationStockWithTx(ctx, tx, key.ProductID, key.LocationID)
+		if err != nil {
+			return err
+		}
+
+		if stock >= 0 {
+			continue
+		}
+
+		product, err := mod.internal.GetProductByIDWithTx(ctx, tx, key.ProductID)
+		if err != nil {
+			return err
+		}
+
+		shortages = append(shortages, StockShortage{
+			ProductID:  key.ProductID,
+			Sku:        product.Sku,
+			LocationID: key.LocationID,
+			Requested:  requested[key],
+			Available:  requested[key] + stock,
+		})
+	}
+
+	if len(shortages) > 0 {
+		return ErrInsufficientStock{
+			Shortages: shortages,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,396 @@
+package module
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqStockTake is entity of inputed stock take
+// use to make request that will be stored into database
+type ReqStockTake struct {
+	internal.StockTake
+}
+
+// ReqStockTakeCount is entity of inputed counted quantity of product
+// product is matched by its ID or, when ID is not given, by its SKU
+type ReqStockTakeCount struct {
+	ProductID       int64  `json:"product_id"`
+	Sku             string `json:"product_sku"`
+	QuantityCounted int    `json:"quantity_counted"`
+}
+
+// StockTakeWithVariance is entity of stock take with variance of each product
+type StockTakeWithVariance struct {
+	internal.StockTake
+	Summary SummaryStockTake           `json:"summary"`
+	Details []internal.StockTakeDetail `json:"details"`
+}
+
+// SummaryStockTake is summary of variance of counted product
+type SummaryStockTake struct {
+	CostingMethod      string `json:"costing_method"`
+	TotalVariance      int    `json:"total_variance"`
+	TotalVarianceValue int64  `json:"total_variance_value"`
+}
+
+// error of stock take request
+var (
+	// ErrStockTakeNotFound is error when requested stock take doesn't exist
+	ErrStockTakeNotFound = errors.New("stock take not found")
+	// ErrStockTakeStillOpen is error when other stock take is still open on the same location
+	ErrStockTakeStillOpen = errors.New("other stock take is still open")
+	// ErrStockTakeNotOpen is error when stock take has been posted
+	ErrStockTakeNotOpen = errors.New("stock take has been posted")
+	// ErrStockTakeProductNotFound is error when counted product is not snapshotted by stock take
+	ErrStockTakeProductNotFound = errors.New("product is not in stock take")
+	// ErrInvalidCountQuantity is error when counted quantity is negative
+	ErrInvalidCountQuantity = errors.New("invalid counted quantity")
+	// ErrInvalidStockTakeCSV is error when uploaded csv doesn't have column SKU and Jumlah Sekarang
+	ErrInvalidStockTakeCSV = errors.New("invalid stock take csv")
+	// ErrSerializedVariance is error when stock take is posted while serialized product has variance,
+	// since unit of serialized product is adjusted by its serial number
+	ErrSerializedVariance = errors.New("variance of serialized product must be adjusted by serial number")
+)
+
+// GetStockTake is used to get all stock take
+func (mod Module) GetStockTake(ctx context.Context) ([]internal.StockTake, error) {
+	return mod.internal.GetStockTake(ctx)
+}
+
+// GetStockTakeByID is used to get stock take with variance of each product
+// variance is valued at unit cost of product on location of the stock take (value of its stock on the location
+// divided by the stock), product which has no stock on the location is valued at unit cost by active costing method
+func (mod Module) GetStockTakeByID(ctx context.Context, ID int64) (StockTakeWithVariance, error) {
+	stockTake, err := mod.internal.GetStockTakeByID(ctx, ID)
+	if err != nil {
+		return StockTakeWithVariance{}, err
+	}
+
+	if stockTake.StockTakeID == 0 {
+		return StockTakeWithVariance{}, ErrStockTakeNotFound
+	}
+
+	stockTakeDetails, err := mod.internal.GetStockTakeDetailByStockTakeID(ctx, ID)
+	if err != nil {
+		return StockTakeWithVariance{}, err
+	}
+
+	productAvgValue, err := mod.GetProductAvgValue(ctx, ReqFilterProductAvgValue{})
+	if err != nil {
+		return StockTakeWithVariance{}, err
+	}
+
+	productCosts := make(map[int64]int)
+	for _, product := range productAvgValue.ProductAvgValue {
+		productCosts[product.ProductID] = product.AverageCost
+	}
+
+	productLocationStocks, err := mod.internal.GetProductLocationStockByLocationID(ctx, stockTake.LocationID)
+	if err != nil {
+		return StockTakeWithVariance{}, err
+	}
+
+	for _, productLocationStock := range productLocationStocks {
+		if productLocationStock.Stock <= 0 || productLocationStock.Value < 0 {
+			continue
+		}
+		productCosts[productLocationStock.ProductID] = int(math.Round(float64(productLocationStock.Value) / float64(productLocationStock.Stock)))
+	}
+
+	stockTakeWithVariance := StockTakeWithVariance{
+		StockTake: stockTake,
+		Details:   stockTakeDetails,
+	}
+	stockTakeWithVariance.Summary.CostingMethod = productAvgValue.Summary.CostingMethod
+
+	// product which has not been counted doesn't have variance
+	for index, stockTakeDetail := range stockTakeDetails {
+		stockTakeDetails[index].AverageCost = productCosts[stockTakeDetail.ProductID]
+		if !stockTakeDetail.IsCounted {
+			continue
+		}
+
+		stockTakeDetails[index].Variance = stockTakeDetail.QuantityCounted - stockTakeDetail.QuantitySystem
+		stockTakeDetails[index].VarianceValue = int64(stockTakeDetails[index].Variance) * int64(stockTakeDetails[index].AverageCost)
+
+		stockTakeWithVariance.Summary.TotalVariance += stockTakeDetails[index].Variance
+		stockTakeWithVariance.Summary.TotalVarianceValue += stockTakeDetails[index].VarianceValue
+	}
+
+	return stockTakeWithVariance, nil
+}
+
+// OpenStockTake is to open stock take which snapshots current stock of all product on location
+// only one stock take can be open at a time on each location
+func (mod Module) OpenStockTake(ctx context.Context, reqStockTake ReqStockTake) (ID int64, err error) {
+	locationID, err := mod.getLocationID(ctx, reqStockTake.LocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	openStockTakes, err := mod.internal.GetStockTakeByStatusAndLocationID(ctx, internal.StockTakeOpen, locationID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(openStockTakes) > 0 {
+		return 0, ErrStockTakeStillOpen
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	ID, err = mod.internal.StoreStockTake(ctx, tx, internal.StockTake{
+		LocationID:  locationID,
+		Description: reqStockTake.Description,
+		Status:      internal.StockTakeOpen,
+		Date:        time.Now(),
+	})
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = mod.internal.SnapshotStockTakeDetail(ctx, tx, ID, locationID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
+
+// StoreStockTakeCount is to store counted quantity of product into open stock take
+// recounted product replaces its previous count
+func (mod Module) StoreStockTakeCount(ctx context.Context, ID int64, reqStockTakeCounts []ReqStockTakeCount) error {
+	stockTake, err := mod.internal.GetStockTakeByID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if stockTake.StockTakeID == 0 {
+		return ErrStockTakeNotFound
+	}
+
+	if stockTake.Status != internal.StockTakeOpen {
+		return ErrStockTakeNotOpen
+	}
+
+	stockTakeDetails, err := mod.internal.GetStockTakeDetailByStockTakeID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		isSnapshotted = make(map[int64]bool)
+		skuToProduct  = make(map[string]int64)
+	)
+	for _, stockTakeDetail := range stockTakeDetails {
+		isSnapshotted[stockTakeDetail.ProductID] = true
+		skuToProduct[stockTakeDetail.Product.Sku] = stockTakeDetail.ProductID
+	}
+
+	// validate all counts before any of them is stored
+	for index, reqStockTakeCount := range reqStockTakeCounts {
+		if reqStockTakeCount.ProductID == 0 {
+			reqStockTakeCounts[index].ProductID = skuToProduct[strings.Trim(reqStockTakeCount.Sku, " ")]
+		}
+
+		if !isSnapshotted[reqStockTakeCounts[index].ProductID] {
+			return ErrStockTakeProductNotFound
+		}
+
+		if reqStockTakeCount.QuantityCounted < 0 {
+			return ErrInvalidCountQuantity
+		}
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, reqStockTakeCount := range reqStockTakeCounts {
+		err = mod.internal.UpdateStockTakeDetailCount(ctx, tx, ID, reqStockTakeCount.ProductID, reqStockTakeCount.QuantityCounted)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// StoreStockTakeCountFromCSV is to store counted quantity from csv into open stock take
+// csv has the same layout as Catatan Jumlah Barang, product is matched by column SKU
+// and counted quantity is taken from column Jumlah Sekarang
+func (mod Module) StoreStockTakeCountFromCSV(ctx context.Context, ID int64, file io.Reader) error {
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil || len(rows) == 0 {
+		return ErrInvalidStockTakeCSV
+	}
+
+	indexSku, indexQuantity := -1, -1
+	for index, column := range rows[0] {
+		// csv which is saved by spreadsheet may start with byte order mark
+		switch strings.Trim(strings.TrimPrefix(column, "\ufeff"), " ") {
+		case "SKU":
+			indexSku = index
+		case "Jumlah Sekarang":
+			indexQuantity = index
+		}
+	}
+
+	if indexSku < 0 || indexQuantity < 0 {
+		return ErrInvalidStockTakeCSV
+	}
+
+	// bundle is listed on Catatan Jumlah Barang but it is not counted
+	products, err := mod.internal.GetProduct(ctx)
+	if err != nil {
+		return err
+	}
+
+	isBundle := make(map[string]bool)
+	for _, product := range products {
+		isBundle[product.Sku] = product.IsBundle
+	}
+
+	var reqStockTakeCounts []ReqStockTakeCount
+	for _, row := range rows[1:] {
+		if len(row) <= indexSku || len(row) <= indexQuantity {
+			return ErrInvalidStockTakeCSV
+		}
+
+		// skip empty row and bundle
+		sku := strings.Trim(row[indexSku], " ")
+		if sku == "" || isBundle[sku] {
+			continue
+		}
+
+		quantity, err := strconv.Atoi(strings.Trim(row[indexQuantity], " "))
+		if err != nil {
+			return ErrInvalidStockTakeCSV
+		}
+
+		reqStockTakeCounts = append(reqStockTakeCounts, ReqStockTakeCount{
+			Sku:             row[indexSku],
+			QuantityCounted: quantity,
+		})
+	}
+
+	return mod.StoreStockTakeCount(ctx, ID, reqStockTakeCounts)
+}
+
+// PostStockTake is to post variance of counted product as correction adjustment on its location
+// adjustment is dated at the time stock is snapshotted, so stock after posting
+// equals to counted quantity plus movement after the snapshot
+// stock take can't be posted while serialized product has variance, since its unit is adjusted by serial number
+func (mod Module) PostStockTake(ctx context.Context, ID int64) error {
+	stockTakeWithVariance, err := mod.GetStockTakeByID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if stockTakeWithVariance.Status != internal.StockTakeOpen {
+		return ErrStockTakeNotOpen
+	}
+
+	products, err := mod.internal.GetProduct(ctx)
+	if err != nil {
+		return err
+	}
+
+	isSerialized := make(map[int64]bool)
+	for _, product := range products {
+		isSerialized[product.ProductID] = product.IsSerialized
+	}
+
+	for _, stockTakeDetail := range stockTakeWithVariance.Details {
+		if stockTakeDetail.Variance != 0 && isSerialized[stockTakeDetail.ProductID] {
+			return ErrSerializedVariance
+		}
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, stockTakeDetail := range stockTakeWithVariance.Details {
+		if stockTakeDetail.Variance == 0 {
+			continue
+		}
+
+		_, err = mod.storeAdjustment(ctx, tx, internal.Adjustment{
+			ProductID:   stockTakeDetail.ProductID,
+			LocationID:  stockTakeWithVariance.LocationID,
+			Quantity:    stockTakeDetail.Variance,
+			Reason:      internal.AdjustmentCorrection,
+			Note:        fmt.Sprintf("Stock Opname #%d", ID),
+			Cost:        int64(stockTakeDetail.AverageCost),
+			StockTakeID: ID,
+			Date:        stockTakeWithVariance.Date,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// date format: yyyy-MM-dd HH:mm:ss
+	stockTake := stockTakeWithVariance.StockTake
+	stockTake.Status = internal.StockTakePosted
+	stockTake.DatePostedStr = time.Now().Format("2006-01-02 15:04:05")
+	_, err = mod.internal.StoreStockTake(ctx, tx, stockTake)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteStockTake is to cancel stock take which has not been posted
+func (mod Module) DeleteStockTake(ctx context.Context, ID int64) error {
+	stockTake, err := mod.internal.GetStockTakeByID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if stockTake.StockTakeID == 0 {
+		return ErrStockTakeNotFound
+	}
+
+	if stockTake.Status != internal.StockTakeOpen {
+		return ErrStockTakeNotOpen
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = mod.internal.DeleteStockTake(ctx, tx, ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
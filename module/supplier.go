@@ -0,0 +1,90 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqSupplier is entity of inputed supplier
+// use to make request that will be stored into database
+type ReqSupplier struct {
+	internal.Supplier
+}
+
+// error of supplier request
+var (
+	// ErrEmptySupplierName is error when supplier doesn't have name
+	ErrEmptySupplierName = errors.New("supplier name is required")
+	// ErrDuplicateSupplierName is error when name has been used by other supplier
+	ErrDuplicateSupplierName = errors.New("supplier name has been used")
+	// ErrSupplierNotFound is error when requested supplier doesn't exist
+	ErrSupplierNotFound = errors.New("supplier not found")
+	// ErrSupplierHasPurchase is error when deleted supplier still has purchase
+	ErrSupplierHasPurchase = errors.New("supplier still has purchase")
+)
+
+// GetSupplier is used to get all supplier
+func (mod Module) GetSupplier(ctx context.Context) ([]internal.Supplier, error) {
+	return mod.internal.GetSupplier(ctx)
+}
+
+// GetSupplierByID is used to get supplier by ID
+func (mod Module) GetSupplierByID(ctx context.Context, ID int64) (internal.Supplier, error) {
+	return mod.internal.GetSupplierByID(ctx, ID)
+}
+
+// StoreSupplier is to store supplier into database
+func (mod Module) StoreSupplier(ctx context.Context, reqSupplier ReqSupplier) (ID int64, err error) {
+	supplier := internal.Supplier{
+		SupplierID: reqSupplier.SupplierID,
+		Name:       strings.Trim(reqSupplier.Name, " "),
+		Phone:      reqSupplier.Phone,
+		Email:      reqSupplier.Email,
+		Address:    reqSupplier.Address,
+	}
+
+	if supplier.Name == "" {
+		return 0, ErrEmptySupplierName
+	}
+
+	// name is unique for each supplier since importer matches supplier by its name
+	existingSupplier, err := mod.internal.GetSupplierByName(ctx, supplier.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	if existingSupplier.SupplierID != 0 && existingSupplier.SupplierID != supplier.SupplierID {
+		return 0, ErrDuplicateSupplierName
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	ID, err = mod.internal.StoreSupplier(ctx, tx, supplier)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
+
+// DeleteSupplier is to delete supplier which doesn't have any purchase from database
+func (mod Module) DeleteSupplier(ctx context.Context, ID int64) error {
+	supplier, err := mod.internal.GetSupplierByID(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if supplier.TotalPurchase > 0 {
+		return ErrSupplierHasPurchase
+	}
+
+	return mod.internal.DeleteSupplier(ctx, ID)
+}
@@ -0,0 +1,229 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sog01/ijahshop/module/internal"
+)
+
+// ReqTransfer is entity of inputed transfer with its transferred product
+// use to make request that will be stored into database
+type ReqTransfer struct {
+	internal.Transfer
+	DateRaw string                    `json:"date_raw"`
+	Details []internal.TransferDetail `json:"details"`
+}
+
+// TransferWithDetail is entity of transfer with its transferred product
+type TransferWithDetail struct {
+	internal.Transfer
+	Details []internal.TransferDetail `json:"details"`
+}
+
+// error of transfer request
+var (
+	// ErrTransferNotFound is error when requested transfer doesn't exist
+	ErrTransferNotFound = errors.New("transfer not found")
+	// ErrEmptyTransfer is error when transfer doesn't have any product
+	ErrEmptyTransfer = errors.New("transfer doesn't have any product")
+	// ErrSameTransferLocation is error when stock is transferred into its own location
+	ErrSameTransferLocation = errors.New("transfer location must be different")
+	// ErrInvalidTransferQuantity is error when transferred quantity is not positive
+	ErrInvalidTransferQuantity = errors.New("invalid transfer quantity")
+)
+
+// GetTransfer is used to get all transfer
+func (mod Module) GetTransfer(ctx context.Context) ([]internal.Transfer, error) {
+	return mod.internal.GetTransfer(ctx)
+}
+
+// GetTransferByID is used to get transfer with its transferred product by ID
+func (mod Module) GetTransferByID(ctx context.Context, ID int64) (TransferWithDetail, error) {
+	transfer, err := mod.internal.GetTransferByID(ctx, ID)
+	if err != nil {
+		return TransferWithDetail{}, err
+	}
+
+	if transfer.TransferID == 0 {
+		return TransferWithDetail{}, ErrTransferNotFound
+	}
+
+	transferDetails, err := mod.internal.GetTransferDetailByTransferID(ctx, ID)
+	if err != nil {
+		return TransferWithDetail{}, err
+	}
+
+	return TransferWithDetail{
+		Transfer: transfer,
+		Details:  transferDetails,
+	}, nil
+}
+
+// StoreTransfer is to store transfer which moves stock between location into database
+// transfer doesn't change cost of product, so it doesn't have any cost layer
+// and stock of source location can't be negative after the transfer
+// lots which expire first are moved with their lot number and expiry date
+// and unit of serialized product is moved by its serial numbers
+func (mod Module) StoreTransfer(ctx context.Context, reqTransfer ReqTransfer) (ID int64, err error) {
+	if len(reqTransfer.Details) == 0 {
+		return 0, ErrEmptyTransfer
+	}
+
+	// empty date means the stock is transferred now
+	// date format: yyyy-MM-dd HH:mm:ss
+	date := time.Now()
+	if reqTransfer.DateRaw != "" {
+		date, err = time.Parse("2006-01-02 15:04:05", reqTransfer.DateRaw)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	transfer := internal.Transfer{
+		FromLocationID: reqTransfer.FromLocationID,
+		ToLocationID:   reqTransfer.ToLocationID,
+		Description:    reqTransfer.Description,
+		Date:           date,
+	}
+
+	transfer.FromLocationID, err = mod.getLocationID(ctx, transfer.FromLocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	transfer.ToLocationID, err = mod.getLocationID(ctx, transfer.ToLocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	if transfer.FromLocationID == transfer.ToLocationID {
+		return 0, ErrSameTransferLocation
+	}
+
+	// movement is valued at current cost of product
+	productCosts := make(map[int64]int)
+	for _, transferDetail := range reqTransfer.Details {
+		if transferDetail.Quantity <= 0 {
+			return 0, ErrInvalidTransferQuantity
+		}
+
+		if _, ok := productCosts[transferDetail.ProductID]; ok {
+			continue
+		}
+
+		product, err := mod.internal.GetProductByID(ctx, transferDetail.ProductID)
+		if err != nil {
+			return 0, err
+		}
+
+		if product.ProductID == 0 {
+			return 0, ErrProductNotFound
+		}
+
+		productCosts[transferDetail.ProductID], err = mod.getProductCost(ctx, transferDetail.ProductID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	db := mod.Storage.DB
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	ID, err = mod.internal.StoreTransfer(ctx, tx, transfer)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	var (
+		stockMovements []internal.StockMovement
+		requested      = make(map[productLocation]int)
+	)
+	for _, transferDetail := range reqTransfer.Details {
+		transferDetail.TransferID = ID
+		_, err = mod.internal.StoreTransferDetail(ctx, tx, transferDetail)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		stockMovement := internal.StockMovement{
+			ProductID:     transferDetail.ProductID,
+			Cost:          int64(productCosts[transferDetail.ProductID]),
+			ReferenceType: internal.MovementTransfer,
+			ReferenceID:   ID,
+			Description:   fmt.Sprintf("Transfer #%d", ID),
+			Date:          transfer.Date,
+		}
+
+		// take stock from source location and then put it into destination location
+		stockMovement.Quantity = -transferDetail.Quantity
+		stockMovement.LocationID = transfer.FromLocationID
+		stockMovements = append(stockMovements, stockMovement)
+
+		stockMovement.Quantity = transferDetail.Quantity
+		stockMovement.LocationID = transfer.ToLocationID
+		stockMovements = append(stockMovements, stockMovement)
+
+		requested[productLocation{transferDetail.ProductID, transfer.FromLocationID}] += transferDetail.Quantity
+	}
+
+	err = mod.storeStockMovement(ctx, tx, stockMovements...)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = mod.checkStockAvailability(ctx, tx, requested)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, transferDetail := range reqTransfer.Details {
+		err = mod.takeSerial(ctx, tx, transferDetail.ProductID, transfer.FromLocationID, transferDetail.SerialNumbers, transferDetail.Quantity, internal.SerialHistory{
+			ReferenceType: internal.MovementTransfer,
+			ReferenceID:   ID,
+			LocationID:    transfer.ToLocationID,
+			Status:        internal.SerialInStock,
+			Description:   fmt.Sprintf("Transfer #%d", ID),
+			Date:          transfer.Date,
+		})
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		consumedLots, err := mod.consumeLot(ctx, tx, transferDetail.ProductID, transfer.FromLocationID, transferDetail.Quantity, internal.MovementTransfer, ID)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		for _, consumedLot := range consumedLots {
+			_, err = mod.internal.StoreLot(ctx, tx, internal.Lot{
+				ProductID:     consumedLot.ProductID,
+				LocationID:    transfer.ToLocationID,
+				LotNumber:     consumedLot.LotNumber,
+				ExpiryDate:    consumedLot.ExpiryDate,
+				ReferenceType: internal.MovementTransfer,
+				ReferenceID:   ID,
+				Quantity:      consumedLot.Quantity,
+				Remaining:     consumedLot.Quantity,
+				Date:          transfer.Date,
+			})
+			if err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+		}
+	}
+
+	return ID, tx.Commit()
+}
@@ -8,12 +8,126 @@ func (s Storage) Migrate() error {
 			product_id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name VARCHAR(30) NOT NULL,
 			sku VARCHAR(30) NOT NULL UNIQUE,
-			stock INT UNSIGNED NOT NULL
+			stock INT UNSIGNED NOT NULL,
+			parent_id INT UNSIGNED NOT NULL DEFAULT (0),
+			is_bundle BOOLEAN NOT NULL DEFAULT (0),
+			reorder_point INT UNSIGNED NOT NULL DEFAULT (0),
+			reorder_quantity INT UNSIGNED NOT NULL DEFAULT (0),
+			is_serialized BOOLEAN NOT NULL DEFAULT (0),
+			unit VARCHAR(20) NOT NULL DEFAULT ('pcs')
 	)`)
 	if err != nil {
 		return err
 	}
 
+	// product which was created before variant is introduced doesn't have parent
+	err = s.addColumn("product", "parent_id", "INT UNSIGNED NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	// product which was created before bundle is introduced is not a bundle
+	err = s.addColumn("product", "is_bundle", "BOOLEAN NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	// product which was created before reorder point is introduced is never reordered
+	err = s.addColumn("product", "reorder_point", "INT UNSIGNED NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	err = s.addColumn("product", "reorder_quantity", "INT UNSIGNED NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	// product which was created before serial number is introduced is not serialized
+	err = s.addColumn("product", "is_serialized", "BOOLEAN NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	// product which was created before unit of measure is introduced is counted in pieces
+	err = s.addColumn("product", "unit", "VARCHAR(20) NOT NULL DEFAULT ('pcs')")
+	if err != nil {
+		return err
+	}
+
+	// create table product unit
+	// product unit is a pack of product (e.g. dozen, carton) which contains factor of its base unit
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS product_unit (
+			product_unit_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INT UNSIGNED NOT NULL,
+			name VARCHAR(20) NOT NULL,
+			factor INT UNSIGNED NOT NULL,
+			UNIQUE (product_id, name)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table product parent
+	// product parent is an item which has product as its variant (e.g. size and color of a shirt)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS product_parent (
+			parent_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name VARCHAR(100) NOT NULL,
+			sku VARCHAR(30) NOT NULL UNIQUE,
+			description TEXT DEFAULT ('')
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table product attribute
+	// attribute is value of variant of product (e.g. size L, color Red)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS product_attribute (
+			product_attribute_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INT UNSIGNED NOT NULL,
+			name VARCHAR(30) NOT NULL,
+			value VARCHAR(100) NOT NULL,
+			UNIQUE (product_id, name)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table product component
+	// component is product which is consumed when its bundle is sold (bill of materials)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS product_component (
+			product_component_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			bundle_id INT UNSIGNED NOT NULL,
+			component_id INT UNSIGNED NOT NULL,
+			quantity INT UNSIGNED NOT NULL,
+			UNIQUE (bundle_id, component_id)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// location is a place where stock is kept (e.g. shop, warehouse)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS location (
+			location_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name VARCHAR(100) NOT NULL UNIQUE,
+			description TEXT DEFAULT (''),
+			is_damaged BOOLEAN NOT NULL DEFAULT (0)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// damaged location keeps returned item in damaged condition
+	err = s.addColumn("location", "is_damaged", "BOOLEAN NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
 	// create table purchase
 	_, err = s.DB.Exec(
 		`CREATE TABLE IF NOT EXISTS purchase (
@@ -25,7 +139,11 @@ func (s Storage) Migrate() error {
 			invoice_number VARCHAR(30) NOT NULL,
 			cost DECIMAL(10, 2) NOT NULL,
 			date TIMESTAMPS NOT NULL,	
-			is_finish BOOLEAN DEFAULT (0)					
+			is_finish BOOLEAN DEFAULT (0),
+			supplier_id INT UNSIGNED NOT NULL DEFAULT (0),
+			unit VARCHAR(20) NOT NULL DEFAULT (''),
+			unit_factor INT UNSIGNED NOT NULL DEFAULT (1),
+			unit_cost DECIMAL(10, 2)
 	)`)
 	if err != nil {
 		return err
@@ -38,12 +156,68 @@ func (s Storage) Migrate() error {
 			purchase_id INT UNSIGNED NOT NULL,
 			quantity INT UNSIGNED NOT NULL,
 			description TEXT DEFAULT (''),			
-			date TIMESTAMPS NOT NULL						
+			date TIMESTAMPS NOT NULL,
+			location_id INT UNSIGNED NOT NULL DEFAULT (1),
+			lot_number VARCHAR(50) NOT NULL DEFAULT (''),
+			expiry_date TEXT NOT NULL DEFAULT ('')
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// receipt which was recorded before location is introduced is received at default location
+	err = s.addColumn("purchase_detail", "location_id", "INT UNSIGNED NOT NULL DEFAULT (1)")
+	if err != nil {
+		return err
+	}
+
+	// receipt which was recorded before lot is introduced doesn't have any lot
+	err = s.addColumn("purchase_detail", "lot_number", "VARCHAR(50) NOT NULL DEFAULT ('')")
+	if err != nil {
+		return err
+	}
+
+	err = s.addColumn("purchase_detail", "expiry_date", "TEXT NOT NULL DEFAULT ('')")
+	if err != nil {
+		return err
+	}
+
+	// create table supplier
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS supplier (
+			supplier_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name VARCHAR(100) NOT NULL UNIQUE,
+			phone VARCHAR(30) NOT NULL DEFAULT (''),
+			email VARCHAR(100) NOT NULL DEFAULT (''),
+			address TEXT DEFAULT ('')
 	)`)
 	if err != nil {
 		return err
 	}
 
+	// purchase without supplier (e.g. recorded before supplier is introduced) has zero supplier_id
+	err = s.addColumn("purchase", "supplier_id", "INT UNSIGNED NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	// purchase which was recorded before unit of measure is introduced is bought in base unit of its product
+	err = s.addColumn("purchase", "unit", "VARCHAR(20) NOT NULL DEFAULT ('')")
+	if err != nil {
+		return err
+	}
+
+	err = s.addColumn("purchase", "unit_factor", "INT UNSIGNED NOT NULL DEFAULT (1)")
+	if err != nil {
+		return err
+	}
+
+	// cost is kept as it is inputed in unit of purchase, since cost of base unit is rounded
+	err = s.addColumn("purchase", "unit_cost", "DECIMAL(10, 2)")
+	if err != nil {
+		return err
+	}
+
 	// create table orders
 	_, err = s.DB.Exec(
 		`CREATE TABLE IF NOT EXISTS orders (
@@ -53,15 +227,631 @@ func (s Storage) Migrate() error {
 			quantity INT UNSIGNED NOT NULL,
 			description TEXT NOT NULL,			
 			date TIMESTAMPS NOT NULL,
-			price DECIMAL(10, 2) NOT NULL					
+			price DECIMAL(10, 2) NOT NULL,
+			cost DECIMAL(10, 2),
+			cogs DECIMAL(10, 2),
+			sales_order_id INT UNSIGNED NOT NULL DEFAULT (0),
+			customer_id INT UNSIGNED NOT NULL DEFAULT (0),
+			location_id INT UNSIGNED NOT NULL DEFAULT (1),
+			unit VARCHAR(20) NOT NULL DEFAULT (''),
+			unit_factor INT UNSIGNED NOT NULL DEFAULT (1),
+			unit_price DECIMAL(10, 2)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// unit cost of order is snapshotted when the order is stored
+	// so the sales report doesn't change when new stock is purchased
+	err = s.addColumn("orders", "cost", "DECIMAL(10, 2)")
+	if err != nil {
+		return err
+	}
+
+	// cost of goods sold of order is snapshotted as its total value, since value of layers
+	// consumed by the order (fifo) may not be a whole unit cost. Order which was stored before
+	// is valued at its unit cost
+	err = s.addColumn("orders", "cogs", "DECIMAL(10, 2)")
+	if err != nil {
+		return err
+	}
+
+	// create table sales order
+	// sales order is a header of order, each row of orders is a line of sales order
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS sales_order (
+			sales_order_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_number VARCHAR(30) NOT NULL UNIQUE,
+			customer VARCHAR(100) NOT NULL DEFAULT (''),
+			status VARCHAR(30) NOT NULL DEFAULT ('confirmed'),
+			notes TEXT DEFAULT (''),
+			date TIMESTAMPS NOT NULL,
+			customer_id INT UNSIGNED NOT NULL DEFAULT (0)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	err = s.addColumn("orders", "sales_order_id", "INT UNSIGNED NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	// create table customer
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS customer (
+			customer_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name VARCHAR(100) NOT NULL,
+			phone VARCHAR(30) NOT NULL DEFAULT (''),
+			address TEXT DEFAULT (''),
+			channel VARCHAR(30) NOT NULL DEFAULT ('')
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// customer of sales order is copied into each of its line (orders)
+	// order without customer (e.g. walk-in buyer or imported) has zero customer_id
+	err = s.addColumn("sales_order", "customer_id", "INT UNSIGNED NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	err = s.addColumn("orders", "customer_id", "INT UNSIGNED NOT NULL DEFAULT (0)")
+	if err != nil {
+		return err
+	}
+
+	// order line takes stock from its location,
+	// order which was recorded before location is introduced is taken from default location
+	err = s.addColumn("orders", "location_id", "INT UNSIGNED NOT NULL DEFAULT (1)")
+	if err != nil {
+		return err
+	}
+
+	// order which was recorded before unit of measure is introduced is sold in base unit of its product
+	err = s.addColumn("orders", "unit", "VARCHAR(20) NOT NULL DEFAULT ('')")
+	if err != nil {
+		return err
+	}
+
+	err = s.addColumn("orders", "unit_factor", "INT UNSIGNED NOT NULL DEFAULT (1)")
+	if err != nil {
+		return err
+	}
+
+	// price is kept as it is inputed in unit of order, since price of base unit is rounded
+	err = s.addColumn("orders", "unit_price", "DECIMAL(10, 2)")
+	if err != nil {
+		return err
+	}
+
+	// create view sales order line
+	// table orders is the table of sales order line: each row of orders is one line of the sales order
+	// which is referred by sales_order_id (so existing order, report and import keep working).
+	// sales_order_line is not a table, it is a view which is only used to read the lines,
+	// writing into it fails, so line is always inserted, updated and deleted on table orders
+	_, err = s.DB.Exec(
+		`CREATE VIEW IF NOT EXISTS sales_order_line AS
+		SELECT
+			order_id as sales_order_line_id,
+			sales_order_id,
+			product_id,
+			quantity,
+			price,
+			cost,
+			description
+		FROM orders
+	`)
+	if err != nil {
+		return err
+	}
+
+	// create table sales return
+	// sales return refers to the returned order line, returned item in damaged condition
+	// goes to damaged location instead of sellable stock
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS sales_return (
+			sales_return_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id INT UNSIGNED NOT NULL,
+			product_id INT UNSIGNED NOT NULL,
+			quantity INT UNSIGNED NOT NULL,
+			price DECIMAL(10, 2) NOT NULL,
+			cost DECIMAL(10, 2) NOT NULL DEFAULT (0),
+			condition VARCHAR(30) NOT NULL DEFAULT ('good'),
+			description TEXT DEFAULT (''),
+			date TIMESTAMPS NOT NULL,
+			unit_price DECIMAL(10, 2)
 	)`)
 	if err != nil {
 		return err
 	}
 
+	// refund is kept as it is inputed in unit of the order line, since price of base unit is rounded
+	err = s.addColumn("sales_return", "unit_price", "DECIMAL(10, 2)")
+	if err != nil {
+		return err
+	}
+
+	// create table purchase return
+	// purchase return refers to the returned purchase and optionally its receipt,
+	// so original purchase is not edited when defective goods are sent back
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS purchase_return (
+			purchase_return_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			purchase_id INT UNSIGNED NOT NULL,
+			purchase_detail_id INT UNSIGNED NOT NULL DEFAULT (0),
+			product_id INT UNSIGNED NOT NULL,
+			quantity INT UNSIGNED NOT NULL,
+			cost DECIMAL(10, 2) NOT NULL,
+			description TEXT DEFAULT (''),
+			date TIMESTAMPS NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table adjustment
+	// adjustment is manual change of stock (e.g. lost, damaged, sample)
+	// stock take which is posted records its variance as adjustment
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS adjustment (
+			adjustment_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INT UNSIGNED NOT NULL,
+			quantity INT NOT NULL,
+			reason VARCHAR(30) NOT NULL,
+			note TEXT DEFAULT (''),
+			user_name VARCHAR(100) NOT NULL DEFAULT (''),
+			cost DECIMAL(10, 2) NOT NULL DEFAULT (0),
+			stock_take_id INT UNSIGNED NOT NULL DEFAULT (0),
+			date TIMESTAMPS NOT NULL,
+			location_id INT UNSIGNED NOT NULL DEFAULT (1)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	err = s.addColumn("adjustment", "location_id", "INT UNSIGNED NOT NULL DEFAULT (1)")
+	if err != nil {
+		return err
+	}
+
+	// create table stock take
+	// stock take is a session of physical count (stock opname)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS stock_take (
+			stock_take_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			description TEXT DEFAULT (''),
+			status VARCHAR(30) NOT NULL DEFAULT ('open'),
+			date TIMESTAMPS NOT NULL,
+			date_posted TEXT NOT NULL DEFAULT (''),
+			location_id INT UNSIGNED NOT NULL DEFAULT (1)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// stock take counts stock of one location
+	err = s.addColumn("stock_take", "location_id", "INT UNSIGNED NOT NULL DEFAULT (1)")
+	if err != nil {
+		return err
+	}
+
+	// create table stock take detail
+	// stock of each product is snapshotted when the session is opened
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS stock_take_detail (
+			stock_take_detail_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			stock_take_id INT UNSIGNED NOT NULL,
+			product_id INT UNSIGNED NOT NULL,
+			quantity_system INT NOT NULL,
+			quantity_counted INT NOT NULL DEFAULT (0),
+			is_counted BOOLEAN DEFAULT (0)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table transfer
+	// transfer is a document which moves stock from one location to another
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS transfer (
+			transfer_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			from_location_id INT UNSIGNED NOT NULL,
+			to_location_id INT UNSIGNED NOT NULL,
+			description TEXT DEFAULT (''),
+			date TIMESTAMPS NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table transfer detail
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS transfer_detail (
+			transfer_detail_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			transfer_id INT UNSIGNED NOT NULL,
+			product_id INT UNSIGNED NOT NULL,
+			quantity INT UNSIGNED NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table stock movement
+	// stock movement is a ledger (kartu stok) of every in/out of product,
+	// stock of product is derived from this table
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS stock_movement (
+			stock_movement_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INT UNSIGNED NOT NULL,
+			quantity INT NOT NULL,
+			cost DECIMAL(10, 2) NOT NULL DEFAULT (0),
+			reference_type VARCHAR(30) NOT NULL,
+			reference_id INT UNSIGNED NOT NULL DEFAULT (0),
+			description TEXT DEFAULT (''),
+			date TIMESTAMPS NOT NULL,
+			location_id INT UNSIGNED NOT NULL DEFAULT (1)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// stock of product is kept per location,
+	// movement which was recorded before location is introduced is at default location
+	err = s.addColumn("stock_movement", "location_id", "INT UNSIGNED NOT NULL DEFAULT (1)")
+	if err != nil {
+		return err
+	}
+
+	// create table cost layer
+	// each stock in creates a cost layer which is consumed by stock out (FIFO)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS cost_layer (
+			cost_layer_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INT UNSIGNED NOT NULL,
+			reference_type VARCHAR(30) NOT NULL,
+			reference_id INT UNSIGNED NOT NULL,
+			quantity INT NOT NULL,
+			remaining INT NOT NULL,
+			cost DECIMAL(10, 2) NOT NULL,
+			date TIMESTAMPS NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table cost layer consumption
+	// cost layer consumption records which layer is consumed by stock out (e.g. order line)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS cost_layer_consumption (
+			cost_layer_consumption_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			cost_layer_id INT UNSIGNED NOT NULL,
+			product_id INT UNSIGNED NOT NULL,
+			reference_type VARCHAR(30) NOT NULL,
+			reference_id INT UNSIGNED NOT NULL,
+			quantity INT NOT NULL,
+			cost DECIMAL(10, 2) NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table lot
+	// each receipt which has lot number or expiry date creates a lot on its location,
+	// remaining quantity of lot is consumed by stock out (FEFO)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS lot (
+			lot_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INT UNSIGNED NOT NULL,
+			location_id INT UNSIGNED NOT NULL DEFAULT (1),
+			lot_number VARCHAR(50) NOT NULL DEFAULT (''),
+			expiry_date TEXT NOT NULL DEFAULT (''),
+			reference_type VARCHAR(30) NOT NULL,
+			reference_id INT UNSIGNED NOT NULL,
+			quantity INT NOT NULL,
+			remaining INT NOT NULL,
+			date TIMESTAMPS NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table lot allocation
+	// lot allocation records which lot is consumed by stock out (e.g. order line)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS lot_allocation (
+			lot_allocation_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			lot_id INT UNSIGNED NOT NULL,
+			product_id INT UNSIGNED NOT NULL,
+			reference_type VARCHAR(30) NOT NULL,
+			reference_id INT UNSIGNED NOT NULL,
+			quantity INT NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table serial
+	// each unit of serialized product is registered by its serial number,
+	// status and location follow the last document which moves the unit
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS serial (
+			serial_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INT UNSIGNED NOT NULL,
+			serial_number VARCHAR(100) NOT NULL UNIQUE,
+			location_id INT UNSIGNED NOT NULL DEFAULT (1),
+			status VARCHAR(20) NOT NULL,
+			purchase_detail_id INT UNSIGNED NOT NULL DEFAULT (0),
+			date TIMESTAMPS NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// create table serial history
+	// serial history records every document which moves the unit (e.g. receipt, order line and return)
+	_, err = s.DB.Exec(
+		`CREATE TABLE IF NOT EXISTS serial_history (
+			serial_history_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			serial_id INT UNSIGNED NOT NULL,
+			reference_type VARCHAR(30) NOT NULL,
+			reference_id INT UNSIGNED NOT NULL,
+			location_id INT UNSIGNED NOT NULL DEFAULT (1),
+			status VARCHAR(20) NOT NULL,
+			description VARCHAR(100) NOT NULL DEFAULT (''),
+			date TIMESTAMPS NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	err = s.syncLocation()
+	if err != nil {
+		return err
+	}
+
+	err = s.syncStockMovement()
+	if err != nil {
+		return err
+	}
+
+	err = s.syncSalesOrder()
+	if err != nil {
+		return err
+	}
+
+	return s.syncPurchaseReceipt()
+}
+
+// addColumn to add column into existing table
+// which was created before the column is introduced
+func (s Storage) addColumn(table, column, definition string) error {
+	var total int
+	err := s.DB.Get(&total, "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column)
+	if err != nil {
+		return err
+	}
+
+	if total > 0 {
+		return nil
+	}
+
+	_, err = s.DB.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+	return err
+}
+
+// syncLocation to create default location
+// which keeps all stock recorded before location is introduced
+// and damaged location which keeps returned item in damaged condition
+func (s Storage) syncLocation() error {
+	_, err := s.DB.Exec(
+		`INSERT INTO location 
+			(location_id, name, description)
+		SELECT 
+			1,
+			'Toko',
+			'Lokasi Utama'
+		WHERE 
+			NOT EXISTS (SELECT 1 FROM location WHERE location_id = 1)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(
+		`INSERT INTO location 
+			(name, description, is_damaged)
+		SELECT 
+			'Barang Rusak',
+			'Barang Retur Rusak',
+			1
+		WHERE 
+			NOT EXISTS (SELECT 1 FROM location WHERE is_damaged = 1 OR name = 'Barang Rusak')
+	`)
+	if err != nil {
+		return err
+	}
+
+	// location which has been named as damaged location by user becomes the damaged location
+	_, err = s.DB.Exec(
+		`UPDATE location 
+		SET is_damaged = 1
+		WHERE 
+			name = 'Barang Rusak'
+			AND NOT EXISTS (SELECT 1 FROM location WHERE is_damaged = 1)
+	`)
+	return err
+}
+
+// syncStockMovement to record movement of purchase and order
+// which has not been recorded into stock movement yet (e.g. imported from excel)
+func (s Storage) syncStockMovement() error {
+	// bundle never has any movement since its stock is made from its components,
+	// so opening balance which was recorded for bundle is removed
+	_, err := s.DB.Exec(
+		`DELETE FROM stock_movement
+		WHERE 
+			reference_type = 'opening' AND
+			product_id IN (SELECT product_id FROM product WHERE is_bundle = 1)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// record opening balance for product which doesn't have any movement,
+	// the balance is taken from stock before purchase and order are recorded
+	_, err = s.DB.Exec(
+		`INSERT INTO stock_movement 
+			(product_id, quantity, cost, reference_type, reference_id, description, date)
+		SELECT 
+			product.product_id,
+			product.stock 
+				- COALESCE((SELECT SUM(purchase.quantity_accepted) FROM purchase WHERE purchase.product_id = product.product_id), 0)
+				+ COALESCE((SELECT SUM(orders.quantity) FROM orders WHERE orders.product_id = product.product_id), 0),
+			COALESCE((SELECT ROUND(AVG(purchase.cost)) FROM purchase WHERE purchase.product_id = product.product_id), 0),
+			'opening',
+			product.product_id,
+			'Saldo Awal',
+			COALESCE((
+				SELECT MIN(history.date) FROM (
+					SELECT purchase.date FROM purchase WHERE purchase.product_id = product.product_id
+					UNION ALL
+					SELECT orders.date FROM orders WHERE orders.product_id = product.product_id
+				) history
+			), CURRENT_TIMESTAMP)
+		FROM product
+		WHERE 
+			product.is_bundle = 0 AND
+			NOT EXISTS (SELECT 1 FROM stock_movement WHERE stock_movement.product_id = product.product_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// record purchase
+	_, err = s.DB.Exec(
+		`INSERT INTO stock_movement 
+			(product_id, quantity, cost, reference_type, reference_id, description, date)
+		SELECT 
+			purchase.product_id,
+			purchase.quantity_accepted,
+			purchase.cost,
+			'purchase',
+			purchase.purchase_id,
+			purchase.invoice_number,
+			purchase.date
+		FROM purchase
+		WHERE 
+			purchase.quantity_accepted != 0 AND
+			NOT EXISTS (
+				SELECT 1 FROM stock_movement 
+				WHERE stock_movement.reference_type = 'purchase' AND stock_movement.reference_id = purchase.purchase_id
+			)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// record order
+	_, err = s.DB.Exec(
+		`INSERT INTO stock_movement 
+			(product_id, quantity, cost, reference_type, reference_id, description, date)
+		SELECT 
+			orders.product_id,
+			-orders.quantity,
+			COALESCE((SELECT ROUND(AVG(purchase.cost)) FROM purchase WHERE purchase.product_id = orders.product_id), 0),
+			'order',
+			orders.order_id,
+			orders.order_id_format,
+			orders.date
+		FROM orders
+		WHERE 
+			orders.quantity != 0 AND
+			NOT EXISTS (
+				SELECT 1 FROM stock_movement 
+				WHERE stock_movement.reference_type = 'order' AND stock_movement.reference_id = orders.order_id
+			)
+	`)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// syncSalesOrder to group order which doesn't have sales order yet (e.g. imported from excel)
+// into sales order by its order number (order_id_format)
+func (s Storage) syncSalesOrder() error {
+	// orders which have the same order number are grouped into one sales order,
+	// order without order number becomes its own sales order
+	qOrderNumber := `CASE 
+			WHEN orders.order_id_format <> '' THEN orders.order_id_format
+			ELSE 'SO-' || orders.order_id 
+		END`
+
+	_, err := s.DB.Exec(
+		`INSERT INTO sales_order 
+			(order_number, customer, status, notes, date)
+		SELECT 
+			` + qOrderNumber + `,
+			'',
+			'confirmed',
+			'',
+			MIN(orders.date)
+		FROM orders
+		WHERE 
+			orders.sales_order_id = 0
+			AND NOT EXISTS (SELECT 1 FROM sales_order WHERE sales_order.order_number = ` + qOrderNumber + `)
+		GROUP BY ` + qOrderNumber + `
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(
+		`UPDATE orders
+		SET 
+			sales_order_id = (SELECT sales_order.sales_order_id FROM sales_order WHERE sales_order.order_number = ` + qOrderNumber + `)
+		WHERE 
+			orders.sales_order_id = 0
+	`)
+	return err
+}
+
+// syncPurchaseReceipt to record receipt (purchase detail) of accepted quantity
+// which was typed in manually before quantity accepted is derived from receipts
+func (s Storage) syncPurchaseReceipt() error {
+	qReceived := `COALESCE((SELECT SUM(purchase_detail.quantity) FROM purchase_detail WHERE purchase_detail.purchase_id = purchase.purchase_id), 0)`
+
+	// finish status is derived from receipts, so it is only backfilled for purchase
+	// which doesn't have any receipt yet (before its initial receipt is recorded below)
+	_, err := s.DB.Exec(
+		`UPDATE purchase
+		SET 
+			is_finish = (quantity_accepted >= quantity_order)
+		WHERE 
+			NOT EXISTS (SELECT 1 FROM purchase_detail WHERE purchase_detail.purchase_id = purchase.purchase_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(
+		`INSERT INTO purchase_detail 
+			(purchase_id, quantity, description, date)
+		SELECT 
+			purchase.purchase_id,
+			purchase.quantity_accepted - ` + qReceived + `,
+			'Penerimaan Awal',
+			purchase.date
+		FROM purchase
+		WHERE 
+			purchase.quantity_accepted > ` + qReceived + `
+	`)
+	return err
+}
+
 // Rollback to rollback all table from database
 func (s Storage) Rollback() error {
 	// drop table product
@@ -70,6 +860,30 @@ func (s Storage) Rollback() error {
 		return err
 	}
 
+	// drop table product parent
+	_, err = s.DB.Exec("DROP TABLE product_parent")
+	if err != nil {
+		return err
+	}
+
+	// drop table product attribute
+	_, err = s.DB.Exec("DROP TABLE product_attribute")
+	if err != nil {
+		return err
+	}
+
+	// drop table product component
+	_, err = s.DB.Exec("DROP TABLE product_component")
+	if err != nil {
+		return err
+	}
+
+	// drop table product unit
+	_, err = s.DB.Exec("DROP TABLE product_unit")
+	if err != nil {
+		return err
+	}
+
 	// drop table purchase
 	_, err = s.DB.Exec("DROP TABLE purchase")
 	if err != nil {
@@ -82,11 +896,125 @@ func (s Storage) Rollback() error {
 		return err
 	}
 
+	// drop table supplier
+	_, err = s.DB.Exec("DROP TABLE supplier")
+	if err != nil {
+		return err
+	}
+
+	// drop view sales order line
+	_, err = s.DB.Exec("DROP VIEW sales_order_line")
+	if err != nil {
+		return err
+	}
+
 	// drop table orders
 	_, err = s.DB.Exec("DROP TABLE orders")
 	if err != nil {
 		return err
 	}
 
+	// drop table sales order
+	_, err = s.DB.Exec("DROP TABLE sales_order")
+	if err != nil {
+		return err
+	}
+
+	// drop table customer
+	_, err = s.DB.Exec("DROP TABLE customer")
+	if err != nil {
+		return err
+	}
+
+	// drop table sales return
+	_, err = s.DB.Exec("DROP TABLE sales_return")
+	if err != nil {
+		return err
+	}
+
+	// drop table purchase return
+	_, err = s.DB.Exec("DROP TABLE purchase_return")
+	if err != nil {
+		return err
+	}
+
+	// drop table adjustment
+	_, err = s.DB.Exec("DROP TABLE adjustment")
+	if err != nil {
+		return err
+	}
+
+	// drop table stock take
+	_, err = s.DB.Exec("DROP TABLE stock_take")
+	if err != nil {
+		return err
+	}
+
+	// drop table stock take detail
+	_, err = s.DB.Exec("DROP TABLE stock_take_detail")
+	if err != nil {
+		return err
+	}
+
+	// drop table location
+	_, err = s.DB.Exec("DROP TABLE location")
+	if err != nil {
+		return err
+	}
+
+	// drop table transfer
+	_, err = s.DB.Exec("DROP TABLE transfer")
+	if err != nil {
+		return err
+	}
+
+	// drop table transfer detail
+	_, err = s.DB.Exec("DROP TABLE transfer_detail")
+	if err != nil {
+		return err
+	}
+
+	// drop table stock movement
+	_, err = s.DB.Exec("DROP TABLE stock_movement")
+	if err != nil {
+		return err
+	}
+
+	// drop table cost layer
+	_, err = s.DB.Exec("DROP TABLE cost_layer")
+	if err != nil {
+		return err
+	}
+
+	// drop table cost layer consumption
+	_, err = s.DB.Exec("DROP TABLE cost_layer_consumption")
+	if err != nil {
+		return err
+	}
+
+	// drop table lot
+	_, err = s.DB.Exec("DROP TABLE lot")
+	if err != nil {
+		return err
+	}
+
+	// drop table lot allocation
+	_, err = s.DB.Exec("DROP TABLE lot_allocation")
+	if err != nil {
+		return err
+	}
+
+	// drop table serial
+	_, err = s.DB.Exec("DROP TABLE serial")
+	if err != nil {
+		return err
+	}
+
+	// drop table serial history
+	_, err = s.DB.Exec("DROP TABLE serial_history")
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"strings"
@@ -107,6 +108,8 @@ func (s Storage) SeedProductFromEXCEL(filePath string) error {
 	mapColumn["Waktu"] = "date"
 	mapColumn["Jumlah Keluar"] = "quantity"
 	mapColumn["Harga Jual"] = "price"
+	mapColumn["Pemasok"] = "supplier_id"
+	mapColumn["Supplier"] = "supplier_id"
 
 	xlFile, err := xlsx.OpenFile(filePath)
 	if err != nil {
@@ -158,6 +161,19 @@ func (s Storage) SeedProductFromEXCEL(filePath string) error {
 							columnName = "product_id"
 							text = fmt.Sprintf("%d", productID)
 						}
+
+						if columnName == "supplier_id" {
+							// only purchase has supplier
+							if data.Table != "purchase" {
+								continue
+							}
+
+							supplierID, err := s.supplierNameToID(text)
+							if err != nil {
+								log.Println(data.Table, text, err)
+							}
+							text = fmt.Sprintf("%d", supplierID)
+						}
 					}
 
 					if data.Table == "orders" {
@@ -189,7 +205,21 @@ func (s Storage) SeedProductFromEXCEL(filePath string) error {
 			return err
 		}
 	}
-	return nil
+
+	// imported purchase and order need to be recorded into stock movement
+	err = s.syncStockMovement()
+	if err != nil {
+		return err
+	}
+
+	// imported order need to be grouped into sales order
+	err = s.syncSalesOrder()
+	if err != nil {
+		return err
+	}
+
+	// imported accepted quantity of purchase need to be recorded as receipt
+	return s.syncPurchaseReceipt()
 }
 
 func (s Storage) skuToProductID(sku string) (int64, error) {
@@ -201,3 +231,24 @@ func (s Storage) skuToProductID(sku string) (int64, error) {
 
 	return productID, err
 }
+
+// supplierNameToID to match supplier by its name
+// supplier which doesn't exist yet is created
+func (s Storage) supplierNameToID(name string) (int64, error) {
+	var supplierID int64
+	db := s.DB
+	name = strings.Trim(name, " ")
+	query := "SELECT supplier_id FROM supplier WHERE name = ?"
+	row := db.QueryRow(db.Rebind(query), name)
+	err := row.Scan(&supplierID)
+	if err != sql.ErrNoRows {
+		return supplierID, err
+	}
+
+	result, err := db.Exec(db.Rebind("INSERT INTO supplier (name) VALUES (?)"), name)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}